@@ -0,0 +1,443 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/taylorskalyo/goreader/display"
+	"github.com/taylorskalyo/goreader/epub"
+	"github.com/taylorskalyo/goreader/state"
+)
+
+// annotationsExport holds the bookmarks and highlights in a book. It is the
+// JSON export format, which can be imported again.
+type annotationsExport struct {
+	ID         string           `json:"id"`
+	Title      string           `json:"title"`
+	Author     string           `json:"author"`
+	Bookmarks  []bookmarkEntry  `json:"bookmarks"`
+	Highlights []highlightEntry `json:"highlights"`
+}
+
+// bookmarkEntry is an exported bookmark. Chapters are numbered from 1.
+type bookmarkEntry struct {
+	Name         string    `json:"name"`
+	Chapter      int       `json:"chapter"`
+	ChapterTitle string    `json:"chapterTitle,omitempty"`
+	Position     float64   `json:"position"`
+	Created      time.Time `json:"created"`
+}
+
+// highlightEntry is an exported highlight. Chapters are numbered from 1.
+type highlightEntry struct {
+	Chapter      int       `json:"chapter"`
+	ChapterTitle string    `json:"chapterTitle,omitempty"`
+	Start        int       `json:"start"`
+	End          int       `json:"end"`
+	Text         string    `json:"text"`
+	Note         string    `json:"note,omitempty"`
+	Color        string    `json:"color,omitempty"`
+	Created      time.Time `json:"created"`
+}
+
+// runAnnotations exports or imports the bookmarks and highlights in a book.
+func runAnnotations(args []string) error {
+	usage := func() {
+		fmt.Fprintln(os.Stderr, "Usage: goreader annotations export [-format markdown|json|org] [-o file] <epub file>")
+		fmt.Fprintln(os.Stderr, "       goreader annotations import <epub file> <json file>")
+	}
+
+	if len(args) < 1 {
+		usage()
+		return errUsage
+	}
+
+	switch args[0] {
+	case "export":
+		return runAnnotationsExport(args[1:])
+	case "import":
+		return runAnnotationsImport(args[1:])
+	}
+
+	usage()
+
+	return errUsage
+}
+
+// runAnnotationsExport writes the bookmarks and highlights in a book to stdout
+// or to a file.
+func runAnnotationsExport(args []string) error {
+	flags := flag.NewFlagSet("annotations export", flag.ContinueOnError)
+	format := flags.String("format", "markdown", "output format: markdown (md), json, or org")
+	output := flags.String("o", "", "write to a file instead of stdout")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: goreader annotations export [-format markdown|json|org] [-o file] <epub file>")
+		fmt.Fprintln(flags.Output(), "")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errUsage
+	}
+
+	var write func(io.Writer, annotationsExport) error
+	switch strings.ToLower(*format) {
+	case "markdown", "md":
+		write = writeAnnotationsMarkdown
+	case "json":
+		write = writeAnnotationsJSON
+	case "org":
+		write = writeAnnotationsOrg
+	default:
+		fmt.Fprintf(flags.Output(), "Unrecognized format \"%s\".\n", *format)
+		return errUsage
+	}
+
+	rc, err := epub.OpenReader(flags.Arg(0), epub.Lenient())
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	book := rc.DefaultRendition()
+
+	store, err := openStore()
+	if err != nil {
+		return err
+	}
+
+	id, _, err := state.Identify(store, state.Keys(book))
+	if err != nil {
+		return err
+	}
+
+	progress, err := store.LoadProgress(id)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+
+	w := bufio.NewWriter(out)
+	if err := write(w, exportAnnotations(book, id, progress)); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
+
+// runAnnotationsImport restores bookmarks and highlights from a JSON export.
+// Bookmarks replace those with the same name, and highlights that already
+// exist are skipped.
+func runAnnotationsImport(args []string) error {
+	flags := flag.NewFlagSet("annotations import", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: goreader annotations import <epub file> <json file>")
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return errUsage
+	}
+
+	rc, err := epub.OpenReader(flags.Arg(0), epub.Lenient())
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	book := rc.DefaultRendition()
+	keys := state.Keys(book)
+
+	data, err := os.ReadFile(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	var a annotationsExport
+	if err := json.Unmarshal(data, &a); err != nil {
+		return fmt.Errorf("parse %s: %w", flags.Arg(1), err)
+	}
+
+	store, err := openStore()
+	if err != nil {
+		return err
+	}
+
+	id, _, err := state.Identify(store, keys)
+	if err != nil {
+		return err
+	}
+
+	if a.ID != "" && a.ID != id && !keys.Matches(a.ID) {
+		fmt.Fprintf(os.Stderr, "%s holds annotations for %s (%s), not %s.\n", flags.Arg(1), a.Title, a.ID, book.Title)
+		return errFailed
+	}
+
+	progress, err := store.LoadProgress(id)
+	if os.IsNotExist(err) {
+		progress = state.Progress{
+			Title:    book.Title,
+			Author:   book.Creator,
+			Chapters: len(book.Spine.Itemrefs),
+		}
+	} else if err != nil {
+		return err
+	}
+
+	// Modified is left alone, since importing does not move the reading
+	// position: the store merges the annotations into the stored record, and a
+	// reader with the book open keeps its position when it next saves.
+	bookmarks, highlights := importAnnotations(&progress, a)
+	if keys.Fingerprint != "" {
+		progress.Fingerprint = keys.Fingerprint
+	}
+	if err := store.StoreProgress(id, progress); err != nil {
+		return err
+	}
+	if err := store.StoreAliases(keys.Aliases(id)); err != nil {
+		return err
+	}
+
+	fmt.Printf("Imported %d bookmarks and %d highlights.\n", bookmarks, highlights)
+
+	return nil
+}
+
+// exportAnnotations collects the bookmarks and highlights in a book's reading
+// progress, along with the titles of the chapters they are in.
+func exportAnnotations(book *epub.Rootfile, id string, progress state.Progress) annotationsExport {
+	chapterTitle := func(chapter int) string {
+		if chapter < 0 || chapter >= len(book.Spine.Itemrefs) {
+			return ""
+		}
+
+		return display.OneLine(book.ItemName(book.Spine.Itemrefs[chapter].HREF))
+	}
+
+	a := annotationsExport{
+		ID:         id,
+		Title:      book.Title,
+		Author:     book.Creator,
+		Bookmarks:  []bookmarkEntry{},
+		Highlights: []highlightEntry{},
+	}
+
+	for _, b := range progress.Bookmarks {
+		a.Bookmarks = append(a.Bookmarks, bookmarkEntry{
+			Name:         b.Name,
+			Chapter:      b.Chapter + 1,
+			ChapterTitle: chapterTitle(b.Chapter),
+			Position:     b.Position,
+			Created:      b.Created,
+		})
+	}
+
+	for _, h := range progress.Highlights {
+		a.Highlights = append(a.Highlights, highlightEntry{
+			Chapter:      h.Chapter + 1,
+			ChapterTitle: chapterTitle(h.Chapter),
+			Start:        h.Start,
+			End:          h.End,
+			Text:         h.Text,
+			Note:         h.Note,
+			Color:        h.Color,
+			Created:      h.Created,
+		})
+	}
+
+	return a
+}
+
+// importAnnotations adds exported bookmarks and highlights to reading
+// progress, and returns how many of each were added.
+func importAnnotations(progress *state.Progress, a annotationsExport) (bookmarks, highlights int) {
+	for _, b := range a.Bookmarks {
+		progress.SetBookmark(state.Bookmark{
+			Name:     b.Name,
+			Chapter:  b.Chapter - 1,
+			Position: b.Position,
+			Created:  b.Created,
+		})
+		bookmarks++
+	}
+
+	for _, e := range a.Highlights {
+		h := state.Highlight{
+			Chapter: e.Chapter - 1,
+			Start:   e.Start,
+			End:     e.End,
+			Text:    e.Text,
+			Note:    e.Note,
+			Color:   e.Color,
+			Created: e.Created,
+		}
+
+		exists := false
+		for _, other := range progress.Highlights {
+			if other.Chapter == h.Chapter && other.Start == h.Start && other.End == h.End {
+				exists = true
+				break
+			}
+		}
+
+		if !exists {
+			progress.AddHighlight(h)
+			highlights++
+		}
+	}
+
+	return bookmarks, highlights
+}
+
+// writeAnnotationsJSON writes annotations in the JSON format.
+func writeAnnotationsJSON(w io.Writer, a annotationsExport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(a)
+}
+
+// writeAnnotationsMarkdown writes annotations as a Markdown document, with
+// highlights grouped by chapter.
+func writeAnnotationsMarkdown(w io.Writer, a annotationsExport) error {
+	var out strings.Builder
+	fmt.Fprintf(&out, "# %s\n", a.Title)
+	if a.Author != "" {
+		fmt.Fprintf(&out, "\n%s\n", a.Author)
+	}
+
+	if len(a.Bookmarks) > 0 {
+		fmt.Fprintf(&out, "\n## Bookmarks\n\n")
+		for _, b := range a.Bookmarks {
+			fmt.Fprintf(&out, "- **%s**: %s, %.0f%%", b.Name, chapterHeading(b.Chapter, b.ChapterTitle), b.Position*100)
+			if !b.Created.IsZero() {
+				fmt.Fprintf(&out, " (%s)", b.Created.Local().Format("2006-01-02 15:04"))
+			}
+			out.WriteString("\n")
+		}
+	}
+
+	if len(a.Highlights) > 0 {
+		fmt.Fprintf(&out, "\n## Highlights\n")
+	}
+
+	for i, h := range a.Highlights {
+		if i == 0 || h.Chapter != a.Highlights[i-1].Chapter {
+			fmt.Fprintf(&out, "\n### %s\n", chapterHeading(h.Chapter, h.ChapterTitle))
+		}
+
+		fmt.Fprintf(&out, "\n> %s\n", h.Text)
+		if h.Note != "" {
+			fmt.Fprintf(&out, "\n%s\n", h.Note)
+		}
+		if !h.Created.IsZero() {
+			fmt.Fprintf(&out, "\n*%s*\n", h.Created.Local().Format("2006-01-02 15:04"))
+		}
+	}
+
+	_, err := io.WriteString(w, out.String())
+
+	return err
+}
+
+// writeAnnotationsOrg writes annotations as an Org-mode document, with
+// highlights grouped by chapter.
+func writeAnnotationsOrg(w io.Writer, a annotationsExport) error {
+	var out strings.Builder
+	fmt.Fprintf(&out, "#+TITLE: %s\n", a.Title)
+	if a.Author != "" {
+		fmt.Fprintf(&out, "#+AUTHOR: %s\n", a.Author)
+	}
+
+	if len(a.Bookmarks) > 0 {
+		fmt.Fprintf(&out, "\n* Bookmarks\n")
+		for _, b := range a.Bookmarks {
+			fmt.Fprintf(&out, "** %s\n", b.Name)
+			fmt.Fprintf(&out, ":PROPERTIES:\n")
+			fmt.Fprintf(&out, ":CHAPTER: %s\n", chapterHeading(b.Chapter, b.ChapterTitle))
+			fmt.Fprintf(&out, ":POSITION: %.0f%%\n", b.Position*100)
+			if !b.Created.IsZero() {
+				fmt.Fprintf(&out, ":CREATED: %s\n", orgTimestamp(b.Created))
+			}
+			fmt.Fprintf(&out, ":END:\n")
+		}
+	}
+
+	if len(a.Highlights) > 0 {
+		fmt.Fprintf(&out, "\n* Highlights\n")
+	}
+
+	for i, h := range a.Highlights {
+		if i == 0 || h.Chapter != a.Highlights[i-1].Chapter {
+			fmt.Fprintf(&out, "** %s\n", chapterHeading(h.Chapter, h.ChapterTitle))
+		}
+
+		fmt.Fprintf(&out, "*** %s\n", orgHeadline(h.Text))
+		if !h.Created.IsZero() || h.Color != "" {
+			fmt.Fprintf(&out, ":PROPERTIES:\n")
+			if !h.Created.IsZero() {
+				fmt.Fprintf(&out, ":CREATED: %s\n", orgTimestamp(h.Created))
+			}
+			if h.Color != "" {
+				fmt.Fprintf(&out, ":COLOR: %s\n", h.Color)
+			}
+			fmt.Fprintf(&out, ":END:\n")
+		}
+		fmt.Fprintf(&out, "#+BEGIN_QUOTE\n%s\n#+END_QUOTE\n", h.Text)
+		if h.Note != "" {
+			fmt.Fprintf(&out, "%s\n", h.Note)
+		}
+	}
+
+	_, err := io.WriteString(w, out.String())
+
+	return err
+}
+
+// chapterHeading names a chapter by its number and, if known, its title.
+func chapterHeading(chapter int, title string) string {
+	if title == "" {
+		return fmt.Sprintf("Chapter %d", chapter)
+	}
+
+	return fmt.Sprintf("Chapter %d: %s", chapter, title)
+}
+
+// orgHeadline shortens text to fit in an Org-mode headline.
+func orgHeadline(text string) string {
+	const maxLength = 60
+	if runes := []rune(text); len(runes) > maxLength {
+		return strings.TrimSpace(string(runes[:maxLength])) + "…"
+	}
+
+	return text
+}
+
+// orgTimestamp formats a time as an inactive Org-mode timestamp.
+func orgTimestamp(t time.Time) string {
+	return t.Local().Format("[2006-01-02 Mon 15:04]")
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taylorskalyo/goreader/epub"
+	"github.com/taylorskalyo/goreader/state"
+)
+
+func TestAnnotations(t *testing.T) {
+	rc, err := epub.OpenReader("epub/_test_files/alice.epub")
+	require.NoError(t, err)
+	defer rc.Close()
+	book := rc.DefaultRendition()
+
+	created := time.Date(2024, 3, 1, 9, 30, 0, 0, time.Local)
+	progress := state.Progress{
+		Bookmarks: []state.Bookmark{
+			{Name: "rabbit hole", Chapter: 1, Position: 0.5, Created: created},
+		},
+		Highlights: []state.Highlight{
+			{Chapter: 1, Start: 10, End: 22, Text: "was beginning", Note: "how it starts", Color: "green", Created: created},
+			{Chapter: 3, Start: 0, End: 4, Text: "Down"},
+		},
+	}
+
+	a := exportAnnotations(book, "id", progress)
+	assert.Equal(t, 2, a.Highlights[0].Chapter)
+	assert.Equal(t, "ALICE'S ADVENTURES IN WONDERLAND", a.Highlights[0].ChapterTitle)
+
+	var md bytes.Buffer
+	require.NoError(t, writeAnnotationsMarkdown(&md, a))
+	assert.Contains(t, md.String(), "- **rabbit hole**: Chapter 2: ALICE'S ADVENTURES IN WONDERLAND, 50% (2024-03-01 09:30)")
+	assert.Contains(t, md.String(), "### Chapter 2: ALICE'S ADVENTURES IN WONDERLAND\n\n> was beginning\n\nhow it starts\n\n*2024-03-01 09:30*\n\n### Chapter 4\n\n> Down\n")
+
+	var org bytes.Buffer
+	require.NoError(t, writeAnnotationsOrg(&org, a))
+	assert.Contains(t, org.String(), "*** was beginning\n:PROPERTIES:\n:CREATED: [2024-03-01 Fri 09:30]\n:COLOR: green\n:END:\n#+BEGIN_QUOTE\nwas beginning\n#+END_QUOTE\nhow it starts\n")
+
+	// Importing the JSON export restores the annotations, and importing it
+	// again adds nothing new.
+	var data bytes.Buffer
+	require.NoError(t, writeAnnotationsJSON(&data, a))
+
+	var imported annotationsExport
+	require.NoError(t, json.Unmarshal(data.Bytes(), &imported))
+
+	var restored state.Progress
+	bookmarks, highlights := importAnnotations(&restored, imported)
+	assert.Equal(t, 1, bookmarks)
+	assert.Equal(t, 2, highlights)
+	assert.Equal(t, len(progress.Bookmarks), len(restored.Bookmarks))
+	for i := range progress.Highlights {
+		assert.True(t, progress.Highlights[i].Created.Equal(restored.Highlights[i].Created))
+		restored.Highlights[i].Created = progress.Highlights[i].Created
+	}
+	assert.Equal(t, progress.Highlights, restored.Highlights)
+
+	_, highlights = importAnnotations(&restored, imported)
+	assert.Zero(t, highlights)
+	assert.Len(t, restored.Highlights, 2)
+}
+
+func TestAnnotationsImportThenSave(t *testing.T) {
+	useTempState(t)
+
+	const path = "epub/_test_files/alice.epub"
+	rc, err := epub.OpenReader(path)
+	require.NoError(t, err)
+	defer rc.Close()
+	keys := state.Keys(rc.DefaultRendition())
+
+	store, err := openStore()
+	require.NoError(t, err)
+	id, _, err := state.Identify(store, keys)
+	require.NoError(t, err)
+
+	// A reader has the book open, and has moved on since it last saved.
+	opened := time.Now().Add(-time.Hour)
+	require.NoError(t, store.StoreProgress(id, state.Progress{Chapter: 1, Modified: opened}))
+	reader, err := store.LoadProgress(id)
+	require.NoError(t, err)
+	reader.Chapter, reader.Modified = 4, time.Now()
+
+	created := time.Date(2024, 3, 1, 9, 30, 0, 0, time.Local)
+	imported := filepath.Join(t.TempDir(), "alice.json")
+	data, err := json.Marshal(annotationsExport{
+		ID:         id,
+		Bookmarks:  []bookmarkEntry{{Name: "rabbit hole", Chapter: 2, Position: 0.5, Created: created}},
+		Highlights: []highlightEntry{{Chapter: 3, Start: 0, End: 4, Text: "Down", Created: created}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(imported, data, 0644))
+
+	stdout, _, err := runCommand(t, runAnnotationsImport, path, imported)
+	require.NoError(t, err)
+	assert.Equal(t, "Imported 1 bookmarks and 1 highlights.\n", stdout)
+
+	require.NoError(t, store.StoreProgress(id, reader))
+
+	progress, err := store.LoadProgress(id)
+	require.NoError(t, err)
+	assert.Equal(t, 4, progress.Chapter)
+	if assert.Len(t, progress.Bookmarks, 1) {
+		assert.Equal(t, "rabbit hole", progress.Bookmarks[0].Name)
+	}
+	if assert.Len(t, progress.Highlights, 1) {
+		assert.Equal(t, "Down", progress.Highlights[0].Text)
+	}
+}
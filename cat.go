@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/taylorskalyo/goreader/config"
+	"github.com/taylorskalyo/goreader/epub"
+	"github.com/taylorskalyo/goreader/render"
+)
+
+// runCat writes the rendered text of an epub file to stdout or to a file.
+func runCat(args []string) error {
+	flags := flag.NewFlagSet("cat", flag.ContinueOnError)
+	format := flags.String("format", "plain", "output format: plain, ansi, or markdown (md)")
+	chapters := flags.String("chapters", "", "range of chapters to write, e.g. 3 or 3-5 (default all)")
+	width := flags.Int("width", 80, "column at which to wrap plain and ansi text")
+	output := flags.String("o", "", "write to a file instead of stdout")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: goreader cat [-format plain|ansi|markdown] [-chapters N-M] [-width N] [-o file] <epub file>")
+		fmt.Fprintln(flags.Output(), "")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errUsage
+	}
+
+	f, err := render.ParseFormat(*format)
+	if err != nil || f == render.FormatTview {
+		fmt.Fprintf(flags.Output(), "Unrecognized format \"%s\".\n", *format)
+		return errUsage
+	}
+
+	if *width < 1 {
+		fmt.Fprintln(flags.Output(), "Width must be positive.")
+		return errUsage
+	}
+
+	rc, err := epub.OpenReader(flags.Arg(0), epub.Lenient())
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	book := rc.DefaultRendition()
+	first, last, err := parseChapters(*chapters, len(book.Spine.Itemrefs))
+	if err != nil {
+		fmt.Fprintf(flags.Output(), "Cannot select chapters: %s.\n", err)
+		return errUsage
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	renderer := render.New(&book.Package)
+	renderer.SetTheme(cfg.Theme)
+	renderer.SetImages(cfg.Images)
+	renderer.SetWidth(*width)
+	renderer.SetFormat(f)
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+
+	w := bufio.NewWriter(out)
+	if err := renderer.RenderChapters(context.Background(), first, last, w); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
+
+// parseChapters parses a 1-based, inclusive range of chapters, such as "3",
+// "3-5", "3-", or "-5", and returns the 0-based indices of the first and last
+// chapters. An empty range selects all chapters.
+func parseChapters(text string, count int) (first, last int, err error) {
+	first, last = 1, count
+	if text != "" {
+		start, end, isRange := strings.Cut(text, "-")
+		if !isRange {
+			end = start
+		}
+
+		if start != "" {
+			if first, err = strconv.Atoi(start); err != nil {
+				return 0, 0, fmt.Errorf("invalid chapter range \"%s\"", text)
+			}
+		}
+
+		if end != "" {
+			if last, err = strconv.Atoi(end); err != nil {
+				return 0, 0, fmt.Errorf("invalid chapter range \"%s\"", text)
+			}
+		}
+	}
+
+	if first < 1 || last > count || first > last {
+		return 0, 0, fmt.Errorf("chapter range \"%s\" is outside of 1-%d", text, count)
+	}
+
+	return first - 1, last - 1, nil
+}
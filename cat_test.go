@@ -0,0 +1,158 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseChapters(t *testing.T) {
+	for _, tc := range []struct {
+		text  string
+		first int
+		last  int
+		err   string
+	}{
+		{text: "", first: 0, last: 13},
+		{text: "3", first: 2, last: 2},
+		{text: "3-5", first: 2, last: 4},
+		{text: "3-", first: 2, last: 13},
+		{text: "-5", first: 0, last: 4},
+		{text: "1-14", first: 0, last: 13},
+		{text: "0", err: `chapter range "0" is outside of 1-14`},
+		{text: "15", err: `chapter range "15" is outside of 1-14`},
+		{text: "5-3", err: `chapter range "5-3" is outside of 1-14`},
+		{text: "3-15", err: `chapter range "3-15" is outside of 1-14`},
+		{text: "three", err: `invalid chapter range "three"`},
+		{text: "3-five", err: `invalid chapter range "3-five"`},
+		{text: "1-2-3", err: `invalid chapter range "1-2-3"`},
+	} {
+		t.Run(tc.text, func(t *testing.T) {
+			first, last, err := parseChapters(tc.text, 14)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.first, first)
+				assert.Equal(t, tc.last, last)
+			}
+		})
+	}
+}
+
+func TestCat(t *testing.T) {
+	useTempState(t)
+	broken := writeBroken(t)
+
+	for _, tc := range []struct {
+		name     string
+		args     []string
+		err      bool
+		usage    bool
+		stdout   string
+		excluded string
+		stderr   string
+	}{
+		{
+			name:     "plain",
+			args:     []string{"-chapters", "3", alice},
+			stdout:   "CHAPTER I\n\nDown the Rabbit-Hole\n",
+			excluded: "CHAPTER II",
+		},
+		{
+			name:     "markdown",
+			args:     []string{"-format", "md", "-chapters", "3-4", alice},
+			stdout:   "<a id=\"chapter-3\"></a>\n\nCHAPTER I\n",
+			excluded: "\x1b[",
+		},
+		{
+			name:   "ansi",
+			args:   []string{"-format", "ansi", "-chapters", "3", alice},
+			stdout: "\x1b[0;",
+		},
+		{
+			name:     "chapters to the end",
+			args:     []string{"-chapters", "13-", alice},
+			stdout:   "CHAPTER XII\n",
+			excluded: "CHAPTER X\n",
+		},
+		{
+			name:   "chapters out of range",
+			args:   []string{"-chapters", "15", alice},
+			usage:  true,
+			stderr: "Cannot select chapters: chapter range \"15\" is outside of 1-14.\n",
+		},
+		{
+			name:   "chapters past the end",
+			args:   []string{"-chapters", "12-20", alice},
+			usage:  true,
+			stderr: "Cannot select chapters: chapter range \"12-20\" is outside of 1-14.\n",
+		},
+		{
+			name:   "invalid chapters",
+			args:   []string{"-chapters", "three", alice},
+			usage:  true,
+			stderr: "Cannot select chapters: invalid chapter range \"three\".\n",
+		},
+		{
+			name:   "unknown format",
+			args:   []string{"-format", "pdf", alice},
+			usage:  true,
+			stderr: "Unrecognized format \"pdf\".\n",
+		},
+		{
+			name:   "internal format",
+			args:   []string{"-format", "tview", alice},
+			usage:  true,
+			stderr: "Unrecognized format \"tview\".\n",
+		},
+		{
+			name:   "invalid width",
+			args:   []string{"-width", "0", alice},
+			usage:  true,
+			stderr: "Width must be positive.\n",
+		},
+		{
+			name: "unreadable",
+			args: []string{broken},
+			err:  true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			stdout, stderr, err := runCommand(t, runCat, tc.args...)
+			switch {
+			case tc.usage:
+				assert.Equal(t, errUsage, err)
+			case tc.err:
+				assert.Error(t, err)
+				assert.NotEqual(t, errUsage, err)
+			default:
+				assert.NoError(t, err)
+			}
+
+			assert.Contains(t, stdout, tc.stdout)
+			if tc.excluded != "" {
+				assert.NotContains(t, stdout, tc.excluded)
+			}
+			assert.Contains(t, stderr, tc.stderr)
+		})
+	}
+}
+
+func TestCatOutputFile(t *testing.T) {
+	useTempState(t)
+	output := filepath.Join(t.TempDir(), "alice.md")
+
+	stdout, _, err := runCommand(t, runCat, "-format", "markdown", "-chapters", "3", "-o", output, alice)
+	require.NoError(t, err)
+	assert.Empty(t, stdout)
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "CHAPTER I\n\nDown the Rabbit-Hole\n")
+}
@@ -1,6 +1,9 @@
 package config
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 const (
 	ActionExit Action = iota
@@ -12,6 +15,16 @@ const (
 	ActionForward
 	ActionChapterPrevious
 	ActionChapterNext
+	ActionGotoPage
+	ActionLibrary
+	ActionGotoPercent
+	ActionCommand
+	ActionScrollLines
+	ActionBookmark
+	ActionVisual
+	ActionAnnotations
+	ActionStats
+	ActionReload
 )
 
 var (
@@ -26,6 +39,16 @@ var (
 		ActionForward:         "Forward",
 		ActionChapterPrevious: "ChapterPrevious",
 		ActionChapterNext:     "ChapterNext",
+		ActionGotoPage:        "GotoPage",
+		ActionLibrary:         "Library",
+		ActionGotoPercent:     "GotoPercent",
+		ActionCommand:         "Command",
+		ActionScrollLines:     "ScrollLines",
+		ActionBookmark:        "Bookmark",
+		ActionVisual:          "Visual",
+		ActionAnnotations:     "Annotations",
+		ActionStats:           "Stats",
+		ActionReload:          "Reload",
 		ActionExit:            "Exit",
 	}
 
@@ -43,6 +66,17 @@ func init() {
 // Action is an action that can be bound to a sequence of key presses.
 type Action int16
 
+// LookupAction looks up an action by name, ignoring case.
+func LookupAction(name string) (Action, bool) {
+	for action, actionName := range ActionNames {
+		if strings.EqualFold(name, actionName) {
+			return action, true
+		}
+	}
+
+	return 0, false
+}
+
 // UnmarshalText creates a new Action from text.
 func (e *Action) UnmarshalText(text []byte) error {
 	var ok bool
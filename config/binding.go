@@ -0,0 +1,159 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Param is the kind of argument that an action accepts.
+type Param int
+
+const (
+	// ParamNone means that an action does not accept an argument.
+	ParamNone Param = iota
+
+	// ParamNumber is an optional positive number, such as a number of times
+	// to repeat an action or a chapter number.
+	ParamNumber
+
+	// ParamPercent is an optional percentage from 0 to 100.
+	ParamPercent
+
+	// ParamLines is a required, non-zero number of lines. Negative numbers
+	// count backward.
+	ParamLines
+
+	// ParamLabel is optional text that may also be given as a number, such
+	// as a page label.
+	ParamLabel
+
+	// ParamText is optional text, such as a name.
+	ParamText
+)
+
+// ActionParams holds the kind of argument each action accepts. Actions that
+// are not listed do not accept an argument.
+var ActionParams = map[Action]Param{
+	ActionUp:              ParamNumber,
+	ActionDown:            ParamNumber,
+	ActionTop:             ParamNumber,
+	ActionBackward:        ParamNumber,
+	ActionForward:         ParamNumber,
+	ActionChapterPrevious: ParamNumber,
+	ActionChapterNext:     ParamNumber,
+	ActionGotoPage:        ParamLabel,
+	ActionGotoPercent:     ParamPercent,
+	ActionScrollLines:     ParamLines,
+	ActionBookmark:        ParamText,
+}
+
+// Binding is an action, along with an argument if the action accepts one.
+type Binding struct {
+	Action Action
+	Arg    string
+}
+
+// ParseBinding parses a Binding from text. The action name comes first,
+// followed by its argument, if any. Action names are case-insensitive.
+//
+// For example:
+//
+// GotoPercent 50
+// ScrollLines -10
+// Bookmark Chapter one
+func ParseBinding(text string) (Binding, error) {
+	name, arg, _ := strings.Cut(strings.TrimSpace(text), " ")
+	arg = strings.TrimSpace(arg)
+
+	action, ok := LookupAction(name)
+	if !ok {
+		return Binding{}, fmt.Errorf("config: unrecognized event \"%s\"", name)
+	}
+
+	b := Binding{Action: action, Arg: arg}
+
+	return b, b.validate()
+}
+
+// validate checks that a Binding's argument is one its action accepts.
+func (b Binding) validate() error {
+	name := ActionNames[b.Action]
+	param := ActionParams[b.Action]
+	if b.Arg == "" {
+		if param == ParamLines {
+			return fmt.Errorf("config: action \"%s\" requires a number of lines", name)
+		}
+
+		return nil
+	}
+
+	n, err := strconv.Atoi(b.Arg)
+	switch param {
+	case ParamNone:
+		return fmt.Errorf("config: action \"%s\" does not take an argument", name)
+	case ParamNumber:
+		if err != nil || n < 1 {
+			return fmt.Errorf("config: action \"%s\" takes a positive number, not \"%s\"", name, b.Arg)
+		}
+	case ParamPercent:
+		if err != nil || n < 0 || n > 100 {
+			return fmt.Errorf("config: action \"%s\" takes a percentage from 0 to 100, not \"%s\"", name, b.Arg)
+		}
+	case ParamLines:
+		if err != nil || n == 0 {
+			return fmt.Errorf("config: action \"%s\" takes a non-zero number of lines, not \"%s\"", name, b.Arg)
+		}
+	}
+
+	return nil
+}
+
+// WithCount returns the Binding with a count, typed before its keys, applied.
+// A count replaces a numeric argument, keeping the direction of a number of
+// lines. Actions that take text or no argument ignore counts.
+func (b Binding) WithCount(count int) Binding {
+	if count < 1 {
+		return b
+	}
+
+	switch ActionParams[b.Action] {
+	case ParamNumber, ParamLabel:
+		b.Arg = strconv.Itoa(count)
+	case ParamPercent:
+		if count > 100 {
+			count = 100
+		}
+		b.Arg = strconv.Itoa(count)
+	case ParamLines:
+		if strings.HasPrefix(b.Arg, "-") {
+			count = -count
+		}
+		b.Arg = strconv.Itoa(count)
+	}
+
+	return b
+}
+
+// UnmarshalText creates a new Binding from text. See ParseBinding for the
+// textual format.
+func (b *Binding) UnmarshalText(text []byte) error {
+	var err error
+	*b, err = ParseBinding(string(text))
+
+	return err
+}
+
+// MarshalText renders a Binding as text.
+func (b Binding) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// String renders a Binding as text.
+func (b Binding) String() string {
+	if b.Arg == "" {
+		return ActionNames[b.Action]
+	}
+
+	return ActionNames[b.Action] + " " + b.Arg
+}
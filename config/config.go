@@ -2,8 +2,12 @@ package config
 
 import (
 	"bytes"
+	"crypto/md5"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"sort"
 	"strings"
 
@@ -24,12 +28,292 @@ func init() {
 	ReloadEnv()
 }
 
+// Theme maps HTML tag names to the style of text within those tags. The
+// "highlight" entry styles highlighted text, and "highlight-<color>" entries
+// style text highlighted in each color.
 type Theme map[string]Style
 
+// HighlightColors are the colors that text can be highlighted in, in addition
+// to the theme's highlight style.
+var HighlightColors = []string{"yellow", "green", "aqua", "fuchsia"}
+
+// HighlightStyle returns the style of text highlighted in a color, or in the
+// theme's highlight style if color is empty. Colors that the theme does not
+// style are used as the background color.
+func (t Theme) HighlightStyle(color string) Style {
+	if color == "" {
+		if s, ok := t["highlight"]; ok {
+			return s
+		}
+		color = HighlightColors[0]
+	}
+
+	if s, ok := t["highlight-"+color]; ok {
+		return s
+	}
+
+	return Style{
+		Foreground: pString(tcell.ColorBlack.Name()),
+		Background: pString(color),
+	}
+}
+
+// Themes holds named themes that can be switched to while reading.
+type Themes map[string]Theme
+
 // Config stores configuration options.
 type Config struct {
 	Keybindings Keybindings `yaml:"keybindings"`
 	Theme       Theme       `yaml:"theme"`
+	Themes      Themes      `yaml:"themes,omitempty"`
+
+	// ThemeName chooses one of Themes to read with instead of Theme, which is
+	// named "default".
+	ThemeName string `yaml:"theme-name,omitempty"`
+
+	// Width is the column at which text is wrapped.
+	Width int `yaml:"width"`
+
+	// Images controls whether images are drawn. If false, only their alt text
+	// is shown.
+	Images bool `yaml:"images"`
+
+	Library Library `yaml:"library"`
+	Layout  Layout  `yaml:"layout"`
+	Sync    Sync    `yaml:"sync,omitempty"`
+	Storage Storage `yaml:"storage,omitempty"`
+
+	// Books overrides settings for individual books. Books are keyed by
+	// identifier (as printed by goreader info), "title:<title>", or
+	// "md5:<fingerprint>".
+	Books map[string]Overrides `yaml:"books,omitempty"`
+}
+
+// MinWidth is the narrowest column at which text can be wrapped.
+const MinWidth = 20
+
+// Overrides replace some of a Config's settings. They are keyed as in the
+// config file.
+type Overrides map[string]interface{}
+
+// Override returns a copy of the config with overrides applied in order. Like
+// the config file, overrides replace whole settings, except for maps such as
+// keybindings and themes, whose entries are replaced one by one.
+func (c Config) Override(overrides ...Overrides) (*Config, error) {
+	c.Keybindings = copyMap(c.Keybindings)
+	c.Theme = copyMap(c.Theme)
+	c.Themes = copyMap(c.Themes)
+	c.Books = copyMap(c.Books)
+
+	for _, o := range overrides {
+		if len(o) == 0 {
+			continue
+		}
+
+		if err := checkOverrides(o); err != nil {
+			return nil, err
+		}
+
+		data, err := yaml.Marshal(o)
+		if err != nil {
+			return nil, err
+		}
+
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&c); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// Validate reports settings that cannot be used.
+func (c Config) Validate() error {
+	if c.Width < MinWidth {
+		return fmt.Errorf("width must be at least %d", MinWidth)
+	}
+
+	return nil
+}
+
+// CheckBooks reports settings in the books section that cannot be overridden
+// for individual books, and removes them, so that the rest of each book's
+// settings still apply.
+func (c *Config) CheckBooks() error {
+	keys := make([]string, 0, len(c.Books))
+	for key := range c.Books {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	msgs := []string{}
+	c.Books = copyMap(c.Books)
+	for _, key := range keys {
+		o := copyMap(c.Books[key])
+		for _, name := range overrideNames(o) {
+			if !isBookSetting(name) {
+				msgs = append(msgs, fmt.Sprintf("books: %s: %s cannot be set for individual books", key, name))
+				delete(o, name)
+			}
+		}
+		c.Books[key] = o
+	}
+
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	return errors.New(strings.Join(msgs, "; "))
+}
+
+// bookSettings are the settings that can be overridden for individual books,
+// as they appear in the config file. The others, such as storage and sync,
+// apply to goreader as a whole.
+var bookSettings = []string{"images", "keybindings", "layout", "theme", "theme-name", "themes", "width"}
+
+// SettingNames returns the names of the settings that can be overridden for
+// individual books, as they appear in the config file.
+func SettingNames() []string {
+	return append([]string{}, bookSettings...)
+}
+
+// IsSetting reports whether name is a setting in the config file, whether or
+// not it can be overridden for individual books.
+func IsSetting(name string) bool {
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		if n, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ","); n == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isBookSetting reports whether name is a setting that can be overridden for
+// individual books.
+func isBookSetting(name string) bool {
+	i := sort.SearchStrings(bookSettings, name)
+
+	return i < len(bookSettings) && bookSettings[i] == name
+}
+
+// overrideNames returns the names of the settings in o, in order.
+func overrideNames(o Overrides) []string {
+	names := make([]string, 0, len(o))
+	for name := range o {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// checkOverrides reports settings that cannot be overridden for individual
+// books.
+func checkOverrides(o Overrides) error {
+	for _, name := range overrideNames(o) {
+		if !isBookSetting(name) {
+			return fmt.Errorf("%s cannot be set for individual books", name)
+		}
+	}
+
+	return nil
+}
+
+// copyMap returns a shallow copy of a map.
+func copyMap[M ~map[K]V, K comparable, V any](m M) M {
+	if m == nil {
+		return nil
+	}
+
+	copied := make(M, len(m))
+	for k, v := range m {
+		copied[k] = v
+	}
+
+	return copied
+}
+
+// Storage configures how reading progress is saved.
+type Storage struct {
+	// Backend is "json" (the default) to save progress in a JSON file, "bolt"
+	// to save it in a key-value database, or "memory" to not save it at all.
+	Backend string `yaml:"backend,omitempty"`
+}
+
+// Library configures where books are found.
+type Library struct {
+	// Roots are directories that are searched recursively for epub files.
+	Roots []string `yaml:"roots,omitempty"`
+}
+
+// RootDirs returns the library roots with environment variables and a leading
+// "~" expanded.
+func (l Library) RootDirs() []string {
+	dirs := make([]string, 0, len(l.Roots))
+	for _, root := range l.Roots {
+		dirs = append(dirs, ExpandPath(root))
+	}
+
+	return dirs
+}
+
+// ExpandPath returns path with environment variables and a leading "~"
+// expanded.
+func ExpandPath(path string) string {
+	path = os.ExpandEnv(path)
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[1:])
+		}
+	}
+
+	return path
+}
+
+// Sync configures syncing reading progress with a KOReader sync server.
+type Sync struct {
+	// Server is the URL of the sync server, e.g. https://sync.koreader.rocks.
+	Server   string `yaml:"server,omitempty"`
+	Username string `yaml:"username,omitempty"`
+
+	// Password is the account's password. Key may be set instead to the MD5
+	// hash of the password, which is what KOReader stores.
+	Password string `yaml:"password,omitempty"`
+	Key      string `yaml:"key,omitempty"`
+
+	// Device names this device to the server. It defaults to "goreader".
+	Device string `yaml:"device,omitempty"`
+}
+
+// Enabled reports whether a sync server has been configured.
+func (s Sync) Enabled() bool {
+	return s.Server != "" && s.Username != ""
+}
+
+// AuthKey returns the key that the sync server authenticates the user with.
+func (s Sync) AuthKey() string {
+	if s.Key != "" {
+		return s.Key
+	}
+
+	return fmt.Sprintf("%x", md5.Sum([]byte(s.Password)))
+}
+
+// DeviceName returns the name of this device.
+func (s Sync) DeviceName() string {
+	if s.Device != "" {
+		return s.Device
+	}
+
+	return "goreader"
 }
 
 // Style controls an individual element's visual appearance when rendered.
@@ -44,12 +328,16 @@ type Style struct {
 }
 
 // Merge returns a new Style with attributes from other applied if present.
+// Neither style is modified.
 func (s Style) Merge(other Style) Style {
-	if out, err := yaml.Marshal(other); err == nil {
-		_ = yaml.Unmarshal(out, &s)
+	var merged Style
+	for _, style := range []Style{s, other} {
+		if out, err := yaml.Marshal(style); err == nil {
+			_ = yaml.Unmarshal(out, &merged)
+		}
 	}
 
-	return s
+	return merged
 }
 
 // String renders a Style as a tview style tag.
@@ -89,19 +377,19 @@ func (s Style) String() string {
 	return b.String()
 }
 
-// Keybindings maps key presses to actions.
-type Keybindings map[KeyChord]Action
+// Keybindings maps sequences of key presses to actions.
+type Keybindings map[KeySequence]Binding
 
-// lookup returns a list of KeyChords that are bound to the given action.
-func (k Keybindings) lookup(target Action) []KeyChord {
-	chords := []KeyChord{}
-	for chord, action := range k {
-		if action == target {
-			chords = append(chords, chord)
+// lookup returns a list of KeySequences that are bound to the given binding.
+func (k Keybindings) lookup(target Binding) []KeySequence {
+	sequences := []KeySequence{}
+	for sequence, binding := range k {
+		if binding == target {
+			sequences = append(sequences, sequence)
 		}
 	}
 
-	return chords
+	return sequences
 }
 
 // String pretty-prints keybindings in a tabular format.
@@ -112,27 +400,48 @@ func (k Keybindings) String() string {
 	t.SetOutputMirror(&b)
 	t.AppendHeader(table.Row{"Action", "Key"})
 
-	actions := []Action{}
+	// List every action, even if unbound, followed by any bindings of the
+	// action with arguments.
+	bindings := []Binding{}
 	for action := range ActionNames {
-		actions = append(actions, action)
+		bindings = append(bindings, Binding{Action: action})
 	}
-	sort.Slice(actions, func(i, j int) bool {
-		return actions[i] < actions[j]
+	for _, binding := range k {
+		if binding.Arg != "" {
+			bindings = append(bindings, binding)
+		}
+	}
+	sort.Slice(bindings, func(i, j int) bool {
+		if bindings[i].Action != bindings[j].Action {
+			return bindings[i].Action < bindings[j].Action
+		}
+
+		return bindings[i].Arg < bindings[j].Arg
 	})
 
-	for _, action := range actions {
-		name := ActionNames[action]
-		chords := k.lookup(action)
-		chordStrs := make([]string, len(chords))
-		for i, chord := range chords {
-			chordStrs[i] = chord.String()
+	for i, binding := range bindings {
+		if i > 0 && binding == bindings[i-1] {
+			continue
+		} else if binding.validate() != nil {
+			// Actions that require an argument cannot be bound without one.
+			continue
+		}
+
+		sequences := k.lookup(binding)
+		keyStrs := make([]string, len(sequences))
+		for i, sequence := range sequences {
+			keyStrs[i] = sequence.String()
 		}
-		sort.Slice(chordStrs, func(i, j int) bool {
-			return len(chordStrs[i]) < len(chordStrs[j])
+		sort.Slice(keyStrs, func(i, j int) bool {
+			if len(keyStrs[i]) != len(keyStrs[j]) {
+				return len(keyStrs[i]) < len(keyStrs[j])
+			}
+
+			return keyStrs[i] < keyStrs[j]
 		})
 
 		t.AppendRows([]table.Row{
-			{name, strings.Join(chordStrs, " / ")},
+			{binding.String(), strings.Join(keyStrs, " / ")},
 		})
 	}
 
@@ -150,29 +459,47 @@ func Default() Config {
 	return Config{
 		Keybindings: DefaultKeybindings(),
 		Theme:       DefaultTheme(),
+		Themes:      DefaultThemes(),
+		Width:       80,
+		Images:      true,
+		Layout:      DefaultLayout(),
 	}
 }
 
 // DefaultKeybindings is the default keybinding.
 func DefaultKeybindings() Keybindings {
 	return Keybindings{
-		KeyChord{Key: tcell.KeyDown}: ActionDown,
-		KeyChord{Key: tcell.KeyUp}:   ActionUp,
-		KeyChord{Key: tcell.KeyHome}: ActionTop,
-		KeyChord{Key: tcell.KeyEnd}:  ActionBottom,
-		KeyChord{Key: tcell.KeyEsc}:  ActionExit,
-		KeyChord{Key: tcell.KeyPgDn}: ActionForward,
-		KeyChord{Key: tcell.KeyPgUp}: ActionBackward,
-
-		KeyChord{Key: tcell.KeyRune, Rune: 'j'}: ActionDown,
-		KeyChord{Key: tcell.KeyRune, Rune: 'k'}: ActionUp,
-		KeyChord{Key: tcell.KeyRune, Rune: 'g'}: ActionTop,
-		KeyChord{Key: tcell.KeyRune, Rune: 'G'}: ActionBottom,
-		KeyChord{Key: tcell.KeyRune, Rune: 'q'}: ActionExit,
-		KeyChord{Key: tcell.KeyRune, Rune: 'f'}: ActionForward,
-		KeyChord{Key: tcell.KeyRune, Rune: 'b'}: ActionBackward,
-		KeyChord{Key: tcell.KeyRune, Rune: 'L'}: ActionChapterNext,
-		KeyChord{Key: tcell.KeyRune, Rune: 'H'}: ActionChapterPrevious,
+		"Down": {Action: ActionDown},
+		"Up":   {Action: ActionUp},
+		"Home": {Action: ActionTop},
+		"End":  {Action: ActionBottom},
+		"Esc":  {Action: ActionExit},
+		"PgDn": {Action: ActionForward},
+		"PgUp": {Action: ActionBackward},
+
+		"j":  {Action: ActionDown},
+		"k":  {Action: ActionUp},
+		"g":  {Action: ActionTop},
+		"G":  {Action: ActionBottom},
+		"q":  {Action: ActionExit},
+		"f":  {Action: ActionForward},
+		"b":  {Action: ActionBackward},
+		"L":  {Action: ActionChapterNext},
+		"H":  {Action: ActionChapterPrevious},
+		"]]": {Action: ActionChapterNext},
+		"[[": {Action: ActionChapterPrevious},
+		"P":  {Action: ActionGotoPage},
+		"%":  {Action: ActionGotoPercent},
+		":":  {Action: ActionCommand},
+		"m":  {Action: ActionBookmark},
+		"o":  {Action: ActionLibrary},
+		"v":  {Action: ActionVisual},
+		"a":  {Action: ActionAnnotations},
+		"S":  {Action: ActionStats},
+
+		"ctrl+d": {Action: ActionScrollLines, Arg: "10"},
+		"ctrl+u": {Action: ActionScrollLines, Arg: "-10"},
+		"ctrl+r": {Action: ActionReload},
 	}
 }
 
@@ -202,6 +529,46 @@ func DefaultTheme() Theme {
 		atom.H4.String(): headingGeneric,
 		atom.H5.String(): headingGeneric,
 		atom.H6.String(): headingGeneric,
+		"highlight": Style{
+			Foreground: pString(tcell.ColorBlack.Name()),
+			Background: pString(tcell.ColorOlive.Name()),
+		},
+	}
+}
+
+// DefaultThemes are named themes available in addition to those in the config
+// file. The dark and light themes use brighter and darker colors respectively,
+// for terminals with dark and light backgrounds.
+func DefaultThemes() Themes {
+	bold := Style{Bold: pBool(true)}
+	theme := func(italic, title, h1, h2, heading, highlight tcell.Color) Theme {
+		headingGeneric := Style{Foreground: pString(heading.Name())}
+
+		return Theme{
+			atom.Strong.String(): bold,
+			atom.Em.String():     bold,
+			atom.B.String():      bold,
+			atom.I.String(): Style{
+				Italic:     pBool(true),
+				Foreground: pString(italic.Name()),
+			},
+			atom.Title.String(): Style{Foreground: pString(title.Name())},
+			atom.H1.String():    Style{Foreground: pString(h1.Name())},
+			atom.H2.String():    Style{Foreground: pString(h2.Name())},
+			atom.H3.String():    headingGeneric,
+			atom.H4.String():    headingGeneric,
+			atom.H5.String():    headingGeneric,
+			atom.H6.String():    headingGeneric,
+			"highlight": Style{
+				Foreground: pString(tcell.ColorBlack.Name()),
+				Background: pString(highlight.Name()),
+			},
+		}
+	}
+
+	return Themes{
+		"dark":  theme(tcell.ColorYellow, tcell.ColorRed, tcell.ColorFuchsia, tcell.ColorBlue, tcell.ColorAqua, tcell.ColorYellow),
+		"light": theme(tcell.ColorOlive, tcell.ColorMaroon, tcell.ColorPurple, tcell.ColorNavy, tcell.ColorTeal, tcell.ColorOlive),
 	}
 }
 
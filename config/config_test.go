@@ -30,8 +30,19 @@ func TestStringifyKeybindings(t *testing.T) {
  Botom            G / End  
  Backward         b / PgUp 
  Forward          f / PgDn 
- ChapterPrevious  H        
- ChapterNext      L        
+ ChapterPrevious  H / [[   
+ ChapterNext      L / ]]   
+ GotoPage         P        
+ Library          o        
+ GotoPercent      %        
+ Command          :        
+ ScrollLines -10  ctrl+u   
+ ScrollLines 10   ctrl+d   
+ Bookmark         m        
+ Visual           v        
+ Annotations      a        
+ Stats            S        
+ Reload           ctrl+r   
 `
 	assert.Equal(t, expected, bindings.String())
 }
@@ -48,10 +59,10 @@ func TestUnmarshal(t *testing.T) {
   "Ctrl+ALT+PgUp": Up`),
 			Config{
 				Keybindings: Keybindings{
-					KeyChord{
+					NewKeySequence(KeyChord{
 						Key:     tcell.KeyPgUp,
 						ModMask: tcell.ModCtrl | tcell.ModAlt,
-					}: ActionUp,
+					}): {Action: ActionUp},
 				},
 			},
 		},
@@ -61,11 +72,44 @@ func TestUnmarshal(t *testing.T) {
   "ctrl+c": Exit`),
 			Config{
 				Keybindings: Keybindings{
-					KeyChord{
+					NewKeySequence(KeyChord{
 						Key:     tcell.KeyCtrlC,
 						ModMask: tcell.ModCtrl,
 						Rune:    3,
-					}: ActionExit,
+					}): {Action: ActionExit},
+				},
+			},
+		},
+		{
+			"KeySequences",
+			[]byte(`keybindings:
+  "gg": Top
+  "ctrl+x ctrl+c": Exit
+  "g Space": Forward`),
+			Config{
+				Keybindings: Keybindings{
+					"gg": {Action: ActionTop},
+					NewKeySequence(
+						KeyChord{Key: tcell.KeyCtrlX, ModMask: tcell.ModCtrl, Rune: 24},
+						KeyChord{Key: tcell.KeyCtrlC, ModMask: tcell.ModCtrl, Rune: 3},
+					): {Action: ActionExit},
+					"g Space": {Action: ActionForward},
+				},
+			},
+		},
+		{
+			"ActionArguments",
+			[]byte(`keybindings:
+  "5": GotoPercent 50
+  "Ctrl+d": ScrollLines 10
+  "m": Bookmark
+  "M": Bookmark  favorite  spot `),
+			Config{
+				Keybindings: Keybindings{
+					"5":      {Action: ActionGotoPercent, Arg: "50"},
+					"ctrl+d": {Action: ActionScrollLines, Arg: "10"},
+					"m":      {Action: ActionBookmark},
+					"M":      {Action: ActionBookmark, Arg: "favorite  spot"},
 				},
 			},
 		},
@@ -84,6 +128,34 @@ func TestUnmarshal(t *testing.T) {
 				},
 			},
 		},
+		{
+			"LayoutVariations",
+			[]byte(`layout:
+  header: "{{.Title}}"
+  footer:
+    left: "{{.Clock}}"
+    right: "{{.BookPercent}}"`),
+			Config{
+				Layout: Layout{
+					Header: Bar{Center: "{{.Title}}"},
+					Footer: Bar{Left: "{{.Clock}}", Right: "{{.BookPercent}}"},
+				},
+			},
+		},
+		{
+			"LayoutHidden",
+			[]byte(`layout:
+  header: false
+  footer:
+    hidden: true
+    center: "{{.Title}}"`),
+			Config{
+				Layout: Layout{
+					Header: Bar{Hidden: true},
+					Footer: Bar{Hidden: true, Center: "{{.Title}}"},
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -120,6 +192,24 @@ func TestUnmarshalError(t *testing.T) {
   "x": ThisActionDoesntExist`),
 			"unrecognized event",
 		},
+		{
+			"BadArgument",
+			[]byte(`keybindings:
+  "5": GotoPercent 500`),
+			"takes a percentage from 0 to 100",
+		},
+		{
+			"MissingArgument",
+			[]byte(`keybindings:
+  "Ctrl+d": ScrollLines`),
+			"requires a number of lines",
+		},
+		{
+			"UnexpectedArgument",
+			[]byte(`keybindings:
+  "q": Exit now`),
+			"does not take an argument",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -137,12 +227,85 @@ func TestStyleMerge(t *testing.T) {
 		Foreground: pString(tcell.ColorYellow.String()),
 	}
 
-	actual := Style{
+	base := Style{
 		Bold:       pBool(false),
 		Foreground: pString(tcell.ColorYellow.String()),
-	}.Merge(Style{
+	}
+	actual := base.Merge(Style{
 		Bold: pBool(true),
 	})
 
 	assert.Equal(t, expected.String(), actual.String())
+	assert.False(t, *base.Bold, "Merge should not modify the original style")
+}
+
+func TestHighlightStyle(t *testing.T) {
+	theme := Theme{
+		"highlight":       Style{Background: pString("olive")},
+		"highlight-green": Style{Background: pString("lime")},
+	}
+
+	assert.Equal(t, "olive", *theme.HighlightStyle("").Background)
+	assert.Equal(t, "lime", *theme.HighlightStyle("green").Background)
+	assert.Equal(t, "aqua", *theme.HighlightStyle("aqua").Background)
+	assert.Equal(t, "yellow", *Theme{}.HighlightStyle("").Background)
+}
+
+func TestOverride(t *testing.T) {
+	base := Default()
+	overridden, err := base.Override(
+		Overrides{"width": 100, "theme": map[string]interface{}{"h1": map[string]interface{}{"bold": false}}},
+		Overrides{"images": false, "theme-name": "dark"},
+	)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 100, overridden.Width)
+		assert.False(t, overridden.Images)
+		assert.Equal(t, "dark", overridden.ThemeName)
+		assert.False(t, *overridden.Theme["h1"].Bold)
+		assert.Equal(t, base.Theme["p"], overridden.Theme["p"])
+	}
+
+	assert.Equal(t, Default(), base, "Override should not modify the original config")
+
+	_, err = base.Override(Overrides{"width": 5})
+	assert.Error(t, err)
+
+	_, err = base.Override(Overrides{"colour": "red"})
+	assert.Error(t, err)
+
+	assert.Contains(t, SettingNames(), "width")
+	assert.NotContains(t, SettingNames(), "books")
+	assert.NotContains(t, SettingNames(), "storage")
+}
+
+func TestOverrideGlobalSettings(t *testing.T) {
+	base := Default()
+	for _, name := range []string{"storage", "sync", "library", "books"} {
+		assert.True(t, IsSetting(name))
+
+		_, err := base.Override(Overrides{name: map[string]interface{}{}})
+		assert.EqualError(t, err, name+" cannot be set for individual books")
+	}
+	assert.False(t, IsSetting("colour"))
+
+	// A bad entry for one book does not stop others' settings from applying.
+	base.Books = map[string]Overrides{
+		"title:Alice": {"width": 100},
+		"title:Bob":   {"storage": map[string]interface{}{"backend": "bolt"}, "width": 60},
+	}
+	assert.NoError(t, base.Validate())
+
+	overridden, err := base.Override(base.Books["title:Alice"])
+	if assert.NoError(t, err) {
+		assert.Equal(t, 100, overridden.Width)
+	}
+
+	// Checking the books section removes the settings that cannot be applied,
+	// leaving the rest.
+	books := base.Books
+	assert.EqualError(t, base.CheckBooks(), "books: title:Bob: storage cannot be set for individual books")
+	assert.Equal(t, Overrides{"width": 60}, base.Books["title:Bob"])
+	assert.Equal(t, Overrides{"width": 100}, base.Books["title:Alice"])
+	assert.Contains(t, books["title:Bob"], "storage", "CheckBooks should not modify the original overrides")
+	assert.NoError(t, base.CheckBooks())
 }
@@ -91,6 +91,9 @@ func (kc *KeyChord) UnmarshalText(text []byte) error {
 		(*kc).Rune = rune(key)
 	} else if key, ok := namedKeys[strings.ToLower(strKey)]; ok {
 		(*kc).Key = key
+	} else if strings.EqualFold(strKey, "space") {
+		(*kc).Key = tcell.KeyRune
+		(*kc).Rune = ' '
 	} else if runes := []rune(strKey); len(runes) == 1 {
 		(*kc).Key = tcell.KeyRune
 		(*kc).Rune = runes[0]
@@ -109,7 +112,9 @@ func (kc KeyChord) MarshalText() ([]byte, error) {
 // String renders a KeyChord as text.
 func (kc KeyChord) String() string {
 	keys := kc.modNames()
-	if kc.Key == tcell.KeyRune {
+	if kc.Key == tcell.KeyRune && kc.Rune == ' ' {
+		keys = append(keys, "Space")
+	} else if kc.Key == tcell.KeyRune {
 		keys = append(keys, string(kc.Rune))
 	} else {
 		name := tcell.KeyNames[kc.Key]
@@ -122,12 +127,13 @@ func (kc KeyChord) String() string {
 	return strings.Join(keys, "+")
 }
 
-// modNames returns the names of available key modifiers.
+// modNames returns the names of the chord's key modifiers, in a consistent
+// order.
 func (kc KeyChord) modNames() []string {
 	modNames := []string{}
-	for name, mod := range namedModifiers {
+	for _, mod := range []tcell.ModMask{tcell.ModShift, tcell.ModCtrl, tcell.ModAlt, tcell.ModMeta} {
 		if (kc.ModMask & mod) != 0 {
-			modNames = append(modNames, name)
+			modNames = append(modNames, strings.ToLower(ModifierNames[mod]))
 		}
 	}
 
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// KeySequence represents one or more KeyChords pressed one after another. It
+// is stored in its textual form, so that it can be used as a map key.
+type KeySequence string
+
+// NewKeySequence creates a new KeySequence from KeyChords.
+func NewKeySequence(chords ...KeyChord) KeySequence {
+	compact := true
+	names := make([]string, len(chords))
+	for i, chord := range chords {
+		names[i] = chord.String()
+		if chord.Key != tcell.KeyRune || chord.ModMask != 0 || unicode.IsSpace(chord.Rune) {
+			compact = false
+		}
+	}
+
+	// Sequences of plain characters are written without separators, e.g. "gg".
+	if compact {
+		return KeySequence(strings.Join(names, ""))
+	}
+
+	return KeySequence(strings.Join(names, " "))
+}
+
+// ParseKeySequence parses a KeySequence from text. A KeySequence is written as
+// either a single KeyChord, KeyChords separated by spaces, or a run of plain
+// characters.
+//
+// For example:
+//
+// Ctrl+x Ctrl+c
+// gg
+// ]]
+func ParseKeySequence(text string) ([]KeyChord, error) {
+	var chord KeyChord
+	chordErr := chord.UnmarshalText([]byte(text))
+	if chordErr == nil {
+		return []KeyChord{chord}, nil
+	}
+
+	if fields := strings.Fields(text); len(fields) > 1 {
+		chords := make([]KeyChord, len(fields))
+		for i, field := range fields {
+			if err := chords[i].UnmarshalText([]byte(field)); err != nil {
+				return nil, err
+			}
+		}
+
+		return chords, nil
+	}
+
+	// A run of plain characters, e.g. "gg". If the text is not made up of
+	// plain characters, report why it is not a valid KeyChord instead.
+	runes := []rune(text)
+	if len(runes) < 2 {
+		return nil, chordErr
+	}
+
+	chords := make([]KeyChord, len(runes))
+	for i, r := range runes {
+		if r == '+' || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return nil, chordErr
+		}
+		chords[i] = KeyChord{Key: tcell.KeyRune, Rune: r}
+	}
+
+	return chords, nil
+}
+
+// Chords returns the KeyChords that make up a KeySequence.
+func (ks KeySequence) Chords() []KeyChord {
+	chords, _ := ParseKeySequence(string(ks))
+
+	return chords
+}
+
+// UnmarshalText creates a new KeySequence from text. See ParseKeySequence for
+// the textual format.
+func (ks *KeySequence) UnmarshalText(text []byte) error {
+	chords, err := ParseKeySequence(string(text))
+	if err != nil {
+		return err
+	}
+
+	*ks = NewKeySequence(chords...)
+
+	return nil
+}
+
+// MarshalText renders a KeySequence as text.
+func (ks KeySequence) MarshalText() ([]byte, error) {
+	return []byte(ks), nil
+}
+
+// String renders a KeySequence as text.
+func (ks KeySequence) String() string {
+	return string(ks)
+}
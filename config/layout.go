@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Layout configures the bars shown above and below the text.
+type Layout struct {
+	Header Bar `yaml:"header"`
+	Footer Bar `yaml:"footer"`
+}
+
+// Bar holds text/template templates for the left, center, and right of a bar.
+// A bar may also be given as a single template, which is centered.
+type Bar struct {
+	Hidden bool   `yaml:"hidden,omitempty"`
+	Left   string `yaml:"left,omitempty"`
+	Center string `yaml:"center,omitempty"`
+	Right  string `yaml:"right,omitempty"`
+}
+
+// UnmarshalYAML creates a Bar from either a template string or a mapping of
+// slots to templates. A value of false hides the bar, and true shows it.
+func (b *Bar) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Tag == "!!bool" {
+			var show bool
+			if err := node.Decode(&show); err != nil {
+				return err
+			}
+
+			// True keeps the default templates.
+			b.Hidden = !show
+			return nil
+		}
+
+		*b = Bar{}
+		return node.Decode(&b.Center)
+	case yaml.MappingNode:
+		// Replace rather than merge with the default slots.
+		type plain Bar
+		var bar plain
+		if err := node.Decode(&bar); err != nil {
+			return err
+		}
+
+		*b = Bar(bar)
+		return nil
+	}
+
+	return fmt.Errorf("config: line %d: bar must be a template or a mapping of left, center, and right templates", node.Line)
+}
+
+// DefaultLayout is the default layout.
+func DefaultLayout() Layout {
+	return Layout{
+		Header: Bar{
+			Center: `{{with .ChapterTitle}}{{.}} • {{end}}{{.Page}} OF {{.Pages}}` +
+				`{{with .ChapterTimeLeft}} • {{.}} left in chapter{{end}}`,
+		},
+		Footer: Bar{
+			Center: `{{shorten .Title}}{{with .PrintPage}} • p. {{.}}{{end}}` +
+				`{{with .BookPercent}} • {{$.Bar 10}} {{.}}{{end}}` +
+				`{{with .TimeLeft}} • {{.}} left{{end}}`,
+		},
+	}
+}
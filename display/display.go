@@ -0,0 +1,39 @@
+// Package display formats values for people to read, both in the reader and
+// in the output of subcommands.
+package display
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Duration formats a duration in hours and minutes, e.g. "1 h 5 min".
+func Duration(d time.Duration) string {
+	minutes := int(d.Round(time.Minute).Minutes())
+	switch {
+	case minutes < 1:
+		return "<1 min"
+	case minutes < 60:
+		return fmt.Sprintf("%d min", minutes)
+	case minutes%60 == 0:
+		return fmt.Sprintf("%d h", minutes/60)
+	}
+
+	return fmt.Sprintf("%d h %d min", minutes/60, minutes%60)
+}
+
+// Plural formats a count of things, e.g. "1 day" or "3 days".
+func Plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// OneLine collapses whitespace, including line breaks, so that text such as a
+// title or an error message fits on a single line.
+func OneLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,31 @@
+package display
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDuration(t *testing.T) {
+	for d, expected := range map[time.Duration]string{
+		20 * time.Second:              "<1 min",
+		12 * time.Minute:              "12 min",
+		2 * time.Hour:                 "2 h",
+		time.Hour + 5*time.Minute + 1: "1 h 5 min",
+	} {
+		assert.Equal(t, expected, Duration(d))
+	}
+}
+
+func TestPlural(t *testing.T) {
+	assert.Equal(t, "0 days", Plural(0, "day"))
+	assert.Equal(t, "1 day", Plural(1, "day"))
+	assert.Equal(t, "3 sessions", Plural(3, "session"))
+}
+
+func TestOneLine(t *testing.T) {
+	assert.Equal(t, "", OneLine(" \n "))
+	assert.Equal(t, "yaml: line 2: did not find expected key", OneLine("yaml: line 2:\n  did not find expected key\n"))
+	assert.Equal(t, "Alice's Adventures in Wonderland", OneLine("Alice's  Adventures\tin\r\nWonderland"))
+}
@@ -2,7 +2,6 @@ package epub
 
 import (
 	"encoding/xml"
-	"io"
 )
 
 const containerPath = "META-INF/container.xml"
@@ -22,35 +21,37 @@ type Container struct {
 
 // setContainer unmarshals the epub's container.xml file.
 func (r *Reader) setContainer() error {
-	f, err := r.files[containerPath].Open()
-	if err != nil {
-		return err
+	if r.files[containerPath] == nil {
+		return &Error{Path: containerPath, Err: ErrNoContainer}
 	}
 
-	data, err := io.ReadAll(f)
+	data, err := r.readFile(containerPath)
 	if err != nil {
 		return err
 	}
 
 	err = xml.Unmarshal(data, &r.Container)
 	if err != nil {
-		return err
+		return xmlError(containerPath, err)
 	}
 
 	if len(r.Container.Rootfiles) < 1 {
-		return ErrNoRootfile
+		return &Error{Path: containerPath, Err: ErrNoRootfile}
 	}
 
 	return nil
 }
 
-// ItemName attempts to find a name for the given item reference.
+// ItemName attempts to find a name for the given item reference. The href is
+// relative to the package document, as with Item.HREF.
 func (rf Rootfile) ItemName(href string) string {
+	target, _ := resolveHref(rf.FullPath, href)
+
 	// EPUB 3.0 compatible.
-	if label := rf.navItemName(href); label != "" {
+	if label := rf.navItemName(target); label != "" {
 		return label
 	}
 
 	// EPUB 2.0 compatible.
-	return rf.ncxItemName(href)
+	return rf.ncxItemName(target)
 }
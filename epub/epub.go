@@ -7,10 +7,15 @@ import (
 	"archive/zip"
 	"errors"
 	"io"
+	"io/fs"
 	"os"
 )
 
 var (
+	// ErrNoContainer occurs when the epub does not contain a
+	// META-INF/container.xml file.
+	ErrNoContainer = errors.New("epub: container not found")
+
 	// ErrNoRootfile occurs when there are no rootfile entries found in
 	// container.xml.
 	ErrNoRootfile = errors.New("epub: no rootfile found in container")
@@ -30,12 +35,34 @@ var (
 	// ErrBadManifest occurs when a manifest in content.opf references an item
 	// that does not exist in the zip.
 	ErrBadManifest = errors.New("epub: manifest references non-existent item")
+
+	// ErrBadXML occurs when a file within the epub is not well-formed XML.
+	ErrBadXML = errors.New("epub: malformed XML")
 )
 
 // Reader represents a readable epub file.
 type Reader struct {
 	Container
-	files map[string]*zip.File
+
+	// Warnings lists recoverable problems found while reading the epub. Each
+	// warning is an *Error.
+	Warnings []error
+
+	files   map[string]*zip.File
+	sources map[string][]byte
+	lenient bool
+}
+
+// Option configures how an epub is read.
+type Option func(*Reader)
+
+// Lenient causes problems that can be worked around, such as a spine entry
+// referencing a missing item or a malformed navigation document, to be
+// recorded as warnings rather than preventing the epub from being read.
+func Lenient() Option {
+	return func(r *Reader) {
+		r.lenient = true
+	}
 }
 
 // ReadCloser represents a readable epub file that can be closed.
@@ -46,7 +73,7 @@ type ReadCloser struct {
 
 // OpenReader will open the epub file specified by name and return a
 // ReadCloser.
-func OpenReader(name string) (*ReadCloser, error) {
+func OpenReader(name string, opts ...Option) (*ReadCloser, error) {
 	f, err := os.Open(name)
 	if err != nil {
 		return nil, err
@@ -63,10 +90,16 @@ func OpenReader(name string) (*ReadCloser, error) {
 
 	z, err := zip.NewReader(f, fi.Size())
 	if err != nil {
+		f.Close()
 		return nil, err
 	}
 
+	for _, opt := range opts {
+		opt(&rc.Reader)
+	}
+
 	if err = rc.init(z); err != nil {
+		f.Close()
 		return nil, err
 	}
 
@@ -75,13 +108,17 @@ func OpenReader(name string) (*ReadCloser, error) {
 
 // NewReader returns a new Reader reading from ra, which is assumed to have the
 // given size in bytes.
-func NewReader(ra io.ReaderAt, size int64) (*Reader, error) {
+func NewReader(ra io.ReaderAt, size int64, opts ...Option) (*Reader, error) {
 	z, err := zip.NewReader(ra, size)
 	if err != nil {
 		return nil, err
 	}
 
 	r := new(Reader)
+	for _, opt := range opts {
+		opt(r)
+	}
+
 	if err = r.init(z); err != nil {
 		return nil, err
 	}
@@ -90,11 +127,23 @@ func NewReader(ra io.ReaderAt, size int64) (*Reader, error) {
 }
 
 func (r *Reader) init(z *zip.Reader) error {
-	// Create a file lookup table
+	// Create a file lookup table. Files are also indexed by their normalized
+	// path so that they can be found regardless of Unicode normalization form.
 	r.files = make(map[string]*zip.File)
 	for _, f := range z.File {
 		r.files[f.Name] = f
 	}
+	for _, f := range z.File {
+		if p := normalizePath(f.Name); r.files[p] == nil {
+			r.files[p] = f
+		}
+	}
+
+	r.sources = make(map[string][]byte)
+	defer func() {
+		// Sources are only needed to report problems while reading.
+		r.sources = nil
+	}()
 
 	err := r.setContainer()
 	if err != nil {
@@ -124,6 +173,46 @@ func (r *Reader) init(z *zip.Reader) error {
 	return nil
 }
 
+// tolerate records err as a warning and returns nil if the Reader is lenient.
+// Otherwise, it returns err.
+func (r *Reader) tolerate(err error) error {
+	if r.lenient {
+		r.Warnings = append(r.Warnings, err)
+		return nil
+	}
+
+	return err
+}
+
+// warn records err as a warning.
+func (r *Reader) warn(err error) {
+	r.Warnings = append(r.Warnings, err)
+}
+
+// readFile reads the contents of a file within the epub.
+func (r *Reader) readFile(p string) ([]byte, error) {
+	zf := r.files[p]
+	if zf == nil {
+		return nil, &Error{Path: p, Err: fs.ErrNotExist}
+	}
+
+	f, err := zf.Open()
+	if err != nil {
+		return nil, &Error{Path: p, Err: err}
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, &Error{Path: p, Err: err}
+	}
+	if r.sources != nil {
+		r.sources[p] = data
+	}
+
+	return data, nil
+}
+
 // DefaultRendition selects the default rendition from a list of rootfiles of
 // an epub container.
 func (c *Container) DefaultRendition() *Rootfile {
@@ -1,7 +1,10 @@
 package epub
 
 import (
+	"archive/zip"
+	"bytes"
 	"os"
+	"sort"
 	"testing"
 )
 
@@ -128,3 +131,155 @@ func (ct *containerTest) TestManifest() {
 		})
 	}
 }
+
+// newTestReader builds an epub in memory from a map of file names to contents.
+func newTestReader(t *testing.T, files map[string]string) (*Reader, error) {
+	t.Helper()
+
+	buf := buildTestZip(t, files)
+
+	return NewReader(buf, buf.Size())
+}
+
+// buildTestZip writes a map of file names to contents to an in-memory zip.
+func buildTestZip(t *testing.T, files map[string]string) *bytes.Reader {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// The mimetype file must come first and must not be compressed.
+	sort.SliceStable(names, func(i, j int) bool {
+		return names[i] == "mimetype"
+	})
+
+	for _, name := range names {
+		method := zip.Deflate
+		if name == "mimetype" {
+			method = zip.Store
+		}
+
+		f, err := w.CreateHeader(&zip.FileHeader{Name: name, Method: method})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := f.Write([]byte(files[name])); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return bytes.NewReader(buf.Bytes())
+}
+
+// epub3Files returns the files of a minimal EPUB 3.0 book.
+func epub3Files() map[string]string {
+	return map[string]string{
+		"mimetype": "application/epub+zip",
+		"META-INF/container.xml": `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>`,
+		"OEBPS/content.opf": `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="uid">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="uid">urn:uuid:00000000-0000-0000-0000-000000000000</dc:identifier>
+    <dc:title>Test Book</dc:title>
+    <dc:creator>Test Author</dc:creator>
+    <dc:language>en</dc:language>
+  </metadata>
+  <manifest>
+    <item id="nav-document" href="nav/nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="cover" href="text/cover.xhtml" media-type="application/xhtml+xml"/>
+    <item id="ch1" href="text/ch1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="ch2" href="text/ch2.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine>
+    <itemref idref="cover"/>
+    <itemref idref="ch1"/>
+    <itemref idref="ch2"/>
+  </spine>
+</package>`,
+		"OEBPS/nav/nav.xhtml": `<?xml version="1.0"?>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+  <body>
+    <nav epub:type="toc">
+      <ol>
+        <li><a href="../text/ch1.xhtml#start">Chapter One</a></li>
+        <li><a href="../text/ch%32.xhtml">Chapter Two</a></li>
+      </ol>
+    </nav>
+    <nav epub:type="landmarks">
+      <ol>
+        <li><a epub:type="cover" href="../text/cover.xhtml">Cover</a></li>
+        <li><a epub:type="bodymatter" href="../text/ch1.xhtml#start">Start</a></li>
+      </ol>
+    </nav>
+    <nav epub:type="page-list" hidden="">
+      <ol>
+        <li><a href="../text/ch1.xhtml#p1">1</a></li>
+        <li><a href="../text/ch1.xhtml#p2">2</a></li>
+        <li><a href="../text/ch2.xhtml#p3">3</a></li>
+      </ol>
+    </nav>
+  </body>
+</html>`,
+		"OEBPS/text/cover.xhtml": `<html xmlns="http://www.w3.org/1999/xhtml"><body><p>Cover</p></body></html>`,
+		"OEBPS/text/ch1.xhtml": `<html xmlns="http://www.w3.org/1999/xhtml"><body>
+<h1 id="start">Chapter One</h1>
+<p><span id="p1"/>First page.</p>
+<p><span id="p2"/>Second page.</p>
+</body></html>`,
+		"OEBPS/text/ch2.xhtml": `<html xmlns="http://www.w3.org/1999/xhtml"><body>
+<h1>Chapter Two</h1>
+<p><span id="p3"/>Third page.</p>
+</body></html>`,
+	}
+}
+
+// epub2Files returns the files of a minimal EPUB 2.0 book.
+func epub2Files() map[string]string {
+	return map[string]string{
+		"mimetype":               "application/epub+zip",
+		"META-INF/container.xml": epub3Files()["META-INF/container.xml"],
+		"OEBPS/content.opf": `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="2.0" unique-identifier="uid">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:opf="http://www.idpf.org/2007/opf">
+    <dc:identifier id="uid" opf:scheme="ISBN">9780000000000</dc:identifier>
+    <dc:title>Test Book</dc:title>
+  </metadata>
+  <manifest>
+    <item id="navigation" href="toc/book.ncx" media-type="application/x-dtbncx+xml"/>
+    <item id="ch1" href="ch1.html" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine toc="navigation">
+    <itemref idref="ch1"/>
+  </spine>
+  <guide>
+    <reference type="text" title="Start" href="ch1.html#start"/>
+  </guide>
+</package>`,
+		"OEBPS/toc/book.ncx": `<?xml version="1.0"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+  <navMap>
+    <navPoint id="np-1" playOrder="1">
+      <navLabel><text>Chapter One</text></navLabel>
+      <content src="../ch1.html#start"/>
+    </navPoint>
+  </navMap>
+</ncx>`,
+		"OEBPS/ch1.html": `<html xmlns="http://www.w3.org/1999/xhtml"><body><h1 id="start">Chapter One</h1></body></html>`,
+	}
+}
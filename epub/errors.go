@@ -0,0 +1,78 @@
+package epub
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// Error describes a problem with the contents of an epub. It records where the
+// problem was found to help track down the offending file or element.
+type Error struct {
+	// Path is the location of the offending file within the epub.
+	Path string
+
+	// ID is the ID of the offending element, if any.
+	ID string
+
+	// Line is the line within Path at which the problem was found, if known.
+	Line int
+
+	// Err is the underlying error.
+	Err error
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString("epub: ")
+
+	if e.Path != "" {
+		b.WriteString(e.Path)
+		if e.Line > 0 {
+			fmt.Fprintf(&b, ":%d", e.Line)
+		}
+		b.WriteString(": ")
+	}
+
+	b.WriteString(strings.TrimPrefix(e.Err.Error(), "epub: "))
+
+	if e.ID != "" {
+		fmt.Fprintf(&b, " (id \"%s\")", e.ID)
+	}
+
+	return b.String()
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// xmlError wraps an error returned while unmarshaling the file at path. Syntax
+// errors are reported as ErrBadXML, along with the line they occurred on.
+func xmlError(path string, err error) *Error {
+	if se, ok := err.(*xml.SyntaxError); ok {
+		return &Error{
+			Path: path,
+			Line: se.Line,
+			Err:  fmt.Errorf("%w: %s", ErrBadXML, se.Msg),
+		}
+	}
+
+	return &Error{Path: path, Err: err}
+}
+
+// lineOf returns the line within data on which an attribute with the given name
+// and value appears, or 0 if it cannot be found.
+func lineOf(data []byte, name, value string) int {
+	for _, quote := range []string{`"`, `'`} {
+		needle := []byte(name + "=" + quote + value + quote)
+		if i := bytes.Index(data, needle); i >= 0 {
+			return bytes.Count(data[:i], []byte("\n")) + 1
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,119 @@
+package epub
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestStrictErrors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		mutate  func(files map[string]string)
+		expErr  error
+		expPath string
+		expID   string
+		expLine int
+	}{
+		{
+			"DanglingItemref",
+			func(files map[string]string) {
+				files["OEBPS/content.opf"] = strings.Replace(files["OEBPS/content.opf"], `<itemref idref="ch2"/>`, `<itemref idref="missing"/>`, 1)
+			},
+			ErrBadItemref, "OEBPS/content.opf", "missing", 18,
+		},
+		{
+			"MalformedNav",
+			func(files map[string]string) {
+				files["OEBPS/nav/nav.xhtml"] = strings.Replace(files["OEBPS/nav/nav.xhtml"], "</nav>", "</navv>", 1)
+			},
+			ErrBadXML, "OEBPS/nav/nav.xhtml", "", 9,
+		},
+		{
+			"MissingContainer",
+			func(files map[string]string) {
+				delete(files, "META-INF/container.xml")
+			},
+			ErrNoContainer, "META-INF/container.xml", "", 0,
+		},
+		{
+			"MissingRootfile",
+			func(files map[string]string) {
+				delete(files, "OEBPS/content.opf")
+			},
+			ErrBadRootfile, "META-INF/container.xml", "", 4,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			files := epub3Files()
+			tc.mutate(files)
+
+			_, err := newTestReader(t, files)
+			if !errors.Is(err, tc.expErr) {
+				t.Fatalf(expFormat, tc.expErr, err)
+			}
+
+			var epubErr *Error
+			if !errors.As(err, &epubErr) {
+				t.Fatalf(expFormat, "*Error", err)
+			}
+
+			if epubErr.Path != tc.expPath {
+				t.Errorf(expFormat, tc.expPath, epubErr.Path)
+			}
+
+			if epubErr.ID != tc.expID {
+				t.Errorf(expFormat, tc.expID, epubErr.ID)
+			}
+
+			if epubErr.Line != tc.expLine {
+				t.Errorf(expFormat, tc.expLine, epubErr.Line)
+			}
+		})
+	}
+}
+
+func TestLenient(t *testing.T) {
+	files := epub3Files()
+	opf := files["OEBPS/content.opf"]
+	opf = strings.Replace(opf, `<itemref idref="ch2"/>`, `<itemref idref="missing"/>`, 1)
+	files["OEBPS/content.opf"] = opf
+	files["OEBPS/nav/nav.xhtml"] = strings.Replace(files["OEBPS/nav/nav.xhtml"], "</nav>", "</navv>", 1)
+	delete(files, "OEBPS/text/cover.xhtml")
+
+	buf := buildTestZip(t, files)
+	r, err := NewReader(buf, buf.Size(), Lenient())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []error{ErrBadManifest, ErrBadItemref, ErrBadXML}
+	if len(r.Warnings) != len(expected) {
+		t.Fatalf(expFormat, expected, r.Warnings)
+	}
+
+	for i, exp := range expected {
+		if !errors.Is(r.Warnings[i], exp) {
+			t.Errorf(expFormat, exp, r.Warnings[i])
+		}
+	}
+
+	rf := r.DefaultRendition()
+	if len(rf.Spine.Itemrefs) != 2 {
+		t.Errorf(expFormat, 2, len(rf.Spine.Itemrefs))
+	}
+
+	if _, err := rf.Spine.Itemrefs[0].Open(); !errors.Is(err, ErrBadManifest) {
+		t.Errorf(expFormat, ErrBadManifest, err)
+	}
+}
+
+func TestErrorString(t *testing.T) {
+	err := &Error{Path: "OEBPS/content.opf", ID: "ch1", Line: 12, Err: ErrBadItemref}
+	exp := `epub: OEBPS/content.opf:12: itemref references non-existent item (id "ch1")`
+	if err.Error() != exp {
+		t.Errorf(expFormat, exp, err.Error())
+	}
+}
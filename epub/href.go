@@ -0,0 +1,134 @@
+package epub
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// resolveHref resolves an href found within the file at base. It returns the
+// path of the referenced file within the zip and the href's fragment.
+//
+// Hrefs are URLs, so they may be percent-encoded and may contain a query.
+// Paths are cleaned and normalized to Unicode NFC so that they can be compared
+// with zip entries regardless of how either was encoded. External references
+// (e.g. "https://example.com") cannot be resolved and return an empty path.
+func resolveHref(base, href string) (string, string) {
+	href, fragment := SplitFragment(href)
+	if i := strings.IndexByte(href, '?'); i >= 0 {
+		href = href[:i]
+	}
+
+	if IsExternal(href) {
+		return "", fragment
+	}
+
+	if p, err := url.PathUnescape(href); err == nil {
+		href = p
+	}
+
+	if f, err := url.PathUnescape(fragment); err == nil {
+		fragment = f
+	}
+
+	// A bare fragment refers to the base file itself.
+	if href == "" {
+		return base, fragment
+	}
+
+	if !strings.HasPrefix(href, "/") {
+		href = path.Join(path.Dir(base), href)
+	}
+
+	return normalizePath(href), fragment
+}
+
+// normalizePath cleans a path within the zip and converts it to Unicode NFC.
+func normalizePath(p string) string {
+	p = strings.TrimPrefix(path.Clean("/"+p), "/")
+
+	return norm.NFC.String(p)
+}
+
+// IsExternal reports whether href is an absolute URL with a scheme, and
+// therefore refers to something outside of the epub.
+func IsExternal(href string) bool {
+	u, err := url.Parse(href)
+
+	return err == nil && u.Scheme != ""
+}
+
+// relativeHref is the inverse of resolveHref. It returns an href that
+// references target from within the file at base.
+func relativeHref(base, target, fragment string) string {
+	dir := path.Dir(base)
+	rel := target
+
+	if dir != "." {
+		up := ""
+		for dir != "." && !strings.HasPrefix(target, dir+"/") {
+			dir = path.Dir(dir)
+			up += "../"
+		}
+
+		if dir != "." {
+			rel = strings.TrimPrefix(target, dir+"/")
+		}
+		rel = up + rel
+	}
+
+	u := url.URL{Path: rel, Fragment: fragment}
+
+	return u.String()
+}
+
+// SplitFragment splits an href into its path and fragment identifier.
+func SplitFragment(href string) (string, string) {
+	if i := strings.IndexByte(href, '#'); i >= 0 {
+		return href[:i], href[i+1:]
+	}
+
+	return href, ""
+}
+
+// ResolveItem returns the manifest item referenced by href from within the
+// given item, along with the href's fragment. It returns nil if href does not
+// reference an item in the manifest.
+func (m Manifest) ResolveItem(from *Item, href string) (*Item, string) {
+	return m.resolveItem(from.path, href)
+}
+
+// resolveItem returns the manifest item referenced by href from within the
+// file at base, along with the href's fragment.
+func (m Manifest) resolveItem(base, href string) (*Item, string) {
+	target, fragment := resolveHref(base, href)
+	if target == "" {
+		return nil, fragment
+	}
+
+	for i := range m.Items {
+		if m.Items[i].path == target {
+			return &m.Items[i], fragment
+		}
+	}
+
+	return nil, fragment
+}
+
+// Index returns the position of item within the spine, or -1 if the item is
+// not part of the spine.
+func (s Spine) Index(item *Item) int {
+	if item == nil {
+		return -1
+	}
+
+	for i, itemref := range s.Itemrefs {
+		if itemref.Item == item {
+			return i
+		}
+	}
+
+	return -1
+}
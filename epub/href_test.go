@@ -0,0 +1,115 @@
+package epub
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveHref(t *testing.T) {
+	testCases := []struct {
+		base, href       string
+		expPath, expFrag string
+	}{
+		{"OEBPS/content.opf", "text/ch1.xhtml", "OEBPS/text/ch1.xhtml", ""},
+		{"OEBPS/content.opf", "text/ch1.xhtml#p1", "OEBPS/text/ch1.xhtml", "p1"},
+		{"OEBPS/text/ch1.xhtml", "../images/a.png", "OEBPS/images/a.png", ""},
+		{"OEBPS/text/ch1.xhtml", "./a%20b.png?size=large", "OEBPS/text/a b.png", ""},
+		{"OEBPS/text/ch1.xhtml", "#note", "OEBPS/text/ch1.xhtml", "note"},
+		{"OEBPS/text/ch1.xhtml", "/cover.xhtml", "cover.xhtml", ""},
+		{"OEBPS/text/ch1.xhtml", "../../../escape.xhtml", "escape.xhtml", ""},
+		{"OEBPS/text/ch1.xhtml", "https://example.com/a.xhtml", "", ""},
+		{"content.opf", "cafe\u0301.xhtml", "caf\u00e9.xhtml", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.href, func(t *testing.T) {
+			p, frag := resolveHref(tc.base, tc.href)
+			if p != tc.expPath {
+				t.Errorf(expFormat, tc.expPath, p)
+			}
+
+			if frag != tc.expFrag {
+				t.Errorf(expFormat, tc.expFrag, frag)
+			}
+		})
+	}
+}
+
+func TestRelativeHref(t *testing.T) {
+	testCases := []struct {
+		base, target, fragment string
+		exp                    string
+	}{
+		{"OEBPS/content.opf", "OEBPS/text/ch1.xhtml", "", "text/ch1.xhtml"},
+		{"OEBPS/content.opf", "images/a b.png", "", "../images/a%20b.png"},
+		{"content.opf", "ch1.xhtml", "p1", "ch1.xhtml#p1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.target, func(t *testing.T) {
+			if rel := relativeHref(tc.base, tc.target, tc.fragment); rel != tc.exp {
+				t.Errorf(expFormat, tc.exp, rel)
+			}
+		})
+	}
+}
+
+func TestManifestHrefs(t *testing.T) {
+	files := epub3Files()
+	opf := files["OEBPS/content.opf"]
+
+	// Percent-encoded href for a file name containing a space.
+	files["OEBPS/text/chapter one.xhtml"] = files["OEBPS/text/ch1.xhtml"]
+	delete(files, "OEBPS/text/ch1.xhtml")
+	opf = strings.Replace(opf, `href="text/ch1.xhtml"`, `href="text/chapter%20one.xhtml"`, 1)
+
+	// Href that leaves and re-enters the package directory, with a query.
+	opf = strings.Replace(opf, `href="text/ch2.xhtml"`, `href="../OEBPS/text/./ch2.xhtml?v=2"`, 1)
+
+	// Zip entry stored in NFD while the manifest uses NFC.
+	files["OEBPS/text/cove\u0301r.xhtml"] = files["OEBPS/text/cover.xhtml"]
+	delete(files, "OEBPS/text/cover.xhtml")
+	opf = strings.Replace(opf, `href="text/cover.xhtml"`, "href=\"text/cov\u00e9r.xhtml\"", 1)
+
+	files["OEBPS/content.opf"] = opf
+
+	r, err := newTestReader(t, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rf := r.DefaultRendition()
+	for _, itemref := range rf.Spine.Itemrefs {
+		t.Run(itemref.IDREF, func(t *testing.T) {
+			f, err := itemref.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+		})
+	}
+
+	t.Run("ResolveItem", func(t *testing.T) {
+		from := rf.Spine.Itemrefs[1].Item
+		item, fragment := rf.ResolveItem(from, "ch2.xhtml#p3")
+		if item == nil || item.ID != "ch2" {
+			t.Fatalf(expFormat, "ch2", item)
+		}
+
+		if fragment != "p3" {
+			t.Errorf(expFormat, "p3", fragment)
+		}
+
+		if n := rf.Spine.Index(item); n != 2 {
+			t.Errorf(expFormat, 2, n)
+		}
+
+		if n, _ := rf.SpineIndex("text/ch2.xhtml#p3"); n != 2 {
+			t.Errorf(expFormat, 2, n)
+		}
+
+		if item, _ := rf.ResolveItem(from, "missing.xhtml"); item != nil {
+			t.Errorf(expFormat, nil, item.ID)
+		}
+	})
+}
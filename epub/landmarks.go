@@ -0,0 +1,120 @@
+package epub
+
+import "strings"
+
+// Landmark identifies a structural component of the epub, such as the cover or
+// the start of the body matter. Its Href is relative to the package document.
+type Landmark struct {
+	Type  string
+	Label string
+	Href  string
+}
+
+// Page identifies where a page of the print edition begins. Its Href is
+// relative to the package document.
+type Page struct {
+	Label string
+	Href  string
+}
+
+// Landmarks lists the structural components of the epub. EPUB 3.0 landmarks
+// are preferred; EPUB 2.0 guide references are used as a fallback.
+func (rf Rootfile) Landmarks() []Landmark {
+	landmarks := []Landmark{}
+
+	// EPUB 3.0 compatible.
+	if nav := rf.NavDoc.LandmarksNav(); nav != nil {
+		for _, li := range nav.Items {
+			for _, item := range li.flatten() {
+				landmarks = append(landmarks, Landmark{
+					Type:  item.Link.Type,
+					Label: strings.TrimSpace(item.Link.Text),
+					Href:  rf.packageHref(rf.NavDoc.path, item.Link.Href),
+				})
+			}
+		}
+
+		return landmarks
+	}
+
+	// EPUB 2.0 compatible.
+	for _, ref := range rf.Guide.References {
+		landmarks = append(landmarks, Landmark{
+			Type:  ref.Type,
+			Label: ref.Title,
+			Href:  rf.packageHref(rf.FullPath, ref.HREF),
+		})
+	}
+
+	return landmarks
+}
+
+// Landmark returns the first landmark matching any of the given types, in
+// order of preference.
+func (rf Rootfile) Landmark(types ...string) (Landmark, bool) {
+	landmarks := rf.Landmarks()
+	for _, t := range types {
+		for _, landmark := range landmarks {
+			if hasType(landmark.Type, t) {
+				return landmark, true
+			}
+		}
+	}
+
+	return Landmark{}, false
+}
+
+// StartOfText returns the landmark marking where the main content of the epub
+// begins.
+func (rf Rootfile) StartOfText() (Landmark, bool) {
+	// The EPUB 2.0 guide uses "text" in place of "bodymatter".
+	return rf.Landmark("bodymatter", "text")
+}
+
+// Pages lists the page breaks of the print edition the epub was derived from,
+// in reading order. EPUB 3.0 page-list navs are preferred; EPUB 2.0 NCX page
+// lists are used as a fallback.
+func (rf Rootfile) Pages() []Page {
+	pages := []Page{}
+
+	// EPUB 3.0 compatible.
+	if nav := rf.NavDoc.PageListNav(); nav != nil {
+		for _, li := range nav.Items {
+			for _, item := range li.flatten() {
+				pages = append(pages, Page{
+					Label: strings.TrimSpace(item.Link.Text),
+					Href:  rf.packageHref(rf.NavDoc.path, item.Link.Href),
+				})
+			}
+		}
+
+		return pages
+	}
+
+	// EPUB 2.0 compatible.
+	for _, target := range rf.NCX.PageTargets {
+		pages = append(pages, Page{
+			Label: strings.TrimSpace(target.NavLabel.Text),
+			Href:  rf.packageHref(rf.NCX.path, target.Content.Src),
+		})
+	}
+
+	return pages
+}
+
+// SpineIndex returns the position within the spine of the item referenced by
+// href, or -1 if the item is not part of the spine. The href is relative to
+// the package document. The decoded fragment of href is also returned.
+func (rf *Rootfile) SpineIndex(href string) (int, string) {
+	item, fragment := rf.resolveItem(rf.FullPath, href)
+
+	return rf.Spine.Index(item), fragment
+}
+
+// packageHref rewrites an href found within the file at base so that it is
+// relative to the package document.
+func (rf Rootfile) packageHref(base, href string) string {
+	target, fragment := resolveHref(base, href)
+
+	return relativeHref(rf.FullPath, target, fragment)
+}
@@ -2,18 +2,33 @@ package epub
 
 import (
 	"encoding/xml"
-	"io"
+	"strings"
 )
 
-const idTOC = "toc"
+// Navigation types as defined by the EPUB 3 Structural Semantics Vocabulary.
+//
+// https://www.w3.org/TR/epub-ssv-11/#sec-nav-types
+const (
+	NavTypeTOC       = "toc"
+	NavTypeLandmarks = "landmarks"
+	NavTypePageList  = "page-list"
+)
+
+// propertyNav identifies the navigation document within the manifest.
+const propertyNav = "nav"
 
 // NavDoc represents an EPUB 3.0 compatible navigation document.
 type NavDoc struct {
 	Nav []Nav `xml:"body>nav"`
+
+	// path is the location of the navigation document within the zip. Hrefs
+	// in the document are relative to it.
+	path string
 }
 
 // Nav represents a list of navigable items.
 type Nav struct {
+	Type  string     `xml:"type,attr"`
 	Items []ListItem `xml:"ol>li"`
 }
 
@@ -21,61 +36,125 @@ type Nav struct {
 // to.
 type ListItem struct {
 	Link struct {
+		Type string `xml:"type,attr"`
 		Href string `xml:"href,attr"`
 		Text string `xml:",chardata"`
 	} `xml:"a"`
 	SubItems *[]ListItem `xml:"ol>li"`
 }
 
-// Load EPUB 3.0 compatible navigation documents.
+// Load EPUB 3.0 compatible navigation documents. The navigation document is
+// the manifest item with the "nav" property.
 func (r *Reader) setTOC() error {
 	for _, rf := range r.Container.Rootfiles {
-		for _, item := range rf.Manifest.Items {
-			if item.ID == idTOC {
-				f, err := item.f.Open()
-				if err != nil {
-					return err
-				}
-				defer f.Close()
-
-				data, err := io.ReadAll(f)
-				if err != nil {
-					return err
-				}
-
-				err = xml.Unmarshal(data, &rf.NavDoc)
-				if err != nil {
-					return err
-				}
+		// Missing items have already been reported by setItems.
+		item := rf.navItem()
+		if item == nil || item.f == nil {
+			continue
+		}
+
+		data, err := r.readFile(item.f.Name)
+		if err != nil {
+			return err
+		}
+
+		err = xml.Unmarshal(data, &rf.NavDoc)
+		if err != nil {
+			rf.NavDoc = NavDoc{}
+			if err := r.tolerate(xmlError(item.f.Name, err)); err != nil {
+				return err
 			}
+
+			continue
 		}
+
+		rf.NavDoc.path = item.path
 	}
 
 	return nil
 }
 
-// navItemName searches for the name of an item in a NavDoc document.
-func (rf Rootfile) navItemName(href string) string {
-	for _, nav := range rf.NavDoc.Nav {
-		for _, item := range nav.Items {
-			if label := item.lookupItemName(href); label != "" {
-				return label
-			}
+// navItem returns the manifest item of the navigation document.
+func (rf *Rootfile) navItem() *Item {
+	for i := range rf.Manifest.Items {
+		if hasType(rf.Manifest.Items[i].Properties, propertyNav) {
+			return &rf.Manifest.Items[i]
+		}
+	}
+
+	return nil
+}
+
+// HasType reports whether the nav element is of the given epub:type. An
+// epub:type attribute may hold several whitespace-separated types.
+func (nav Nav) HasType(t string) bool {
+	return hasType(nav.Type, t)
+}
+
+// TOCNav returns the table of contents nav. Navigation documents without any
+// typed nav elements fall back to the first nav.
+func (nd NavDoc) TOCNav() *Nav {
+	if nav := nd.navByType(NavTypeTOC); nav != nil {
+		return nav
+	}
+
+	for i := range nd.Nav {
+		if nd.Nav[i].Type == "" {
+			return &nd.Nav[i]
+		}
+	}
+
+	return nil
+}
+
+// LandmarksNav returns the landmarks nav, if present.
+func (nd NavDoc) LandmarksNav() *Nav {
+	return nd.navByType(NavTypeLandmarks)
+}
+
+// PageListNav returns the page-list nav, if present.
+func (nd NavDoc) PageListNav() *Nav {
+	return nd.navByType(NavTypePageList)
+}
+
+// navByType returns the first nav element of the given epub:type.
+func (nd NavDoc) navByType(t string) *Nav {
+	for i := range nd.Nav {
+		if nd.Nav[i].HasType(t) {
+			return &nd.Nav[i]
+		}
+	}
+
+	return nil
+}
+
+// navItemName searches for the name of the file at target in a NavDoc
+// document.
+func (rf Rootfile) navItemName(target string) string {
+	toc := rf.NavDoc.TOCNav()
+	if toc == nil {
+		return ""
+	}
+
+	for _, item := range toc.Items {
+		if label := item.lookupItemName(rf.NavDoc.path, target); label != "" {
+			return label
 		}
 	}
 
 	return ""
 }
 
-// lookupItemName traverses a ListItem looking for the name of an item.
-func (li ListItem) lookupItemName(href string) string {
-	if li.Link.Href == href {
-		return li.Link.Text
+// lookupItemName traverses a ListItem looking for the name of the file at
+// target. Links are resolved relative to base and their fragments are ignored.
+func (li ListItem) lookupItemName(base, target string) string {
+	if p, _ := resolveHref(base, li.Link.Href); p == target {
+		return strings.TrimSpace(li.Link.Text)
 	}
 
 	if li.SubItems != nil {
 		for _, item := range *li.SubItems {
-			if label := item.lookupItemName(href); label != "" {
+			if label := item.lookupItemName(base, target); label != "" {
 				return label
 			}
 		}
@@ -83,3 +162,26 @@ func (li ListItem) lookupItemName(href string) string {
 
 	return ""
 }
+
+// flatten returns the ListItem and all of its descendants in document order.
+func (li ListItem) flatten() []ListItem {
+	items := []ListItem{li}
+	if li.SubItems != nil {
+		for _, item := range *li.SubItems {
+			items = append(items, item.flatten()...)
+		}
+	}
+
+	return items
+}
+
+// hasType reports whether a whitespace-separated list of types contains t.
+func hasType(types, t string) bool {
+	for _, field := range strings.Fields(types) {
+		if field == t {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,166 @@
+package epub
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNavTypes(t *testing.T) {
+	r, err := newTestReader(t, epub3Files())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rf := r.DefaultRendition()
+
+	t.Run("ItemName", func(t *testing.T) {
+		if name := rf.ItemName("text/ch2.xhtml"); name != "Chapter Two" {
+			t.Errorf(expFormat, "Chapter Two", name)
+		}
+
+		// Landmark labels are not chapter titles.
+		if name := rf.ItemName("text/cover.xhtml"); name != "" {
+			t.Errorf(expFormat, "", name)
+		}
+	})
+
+	t.Run("StartOfText", func(t *testing.T) {
+		landmark, ok := rf.StartOfText()
+		if !ok {
+			t.Fatalf(expFormat, "landmark", "none")
+		}
+
+		if landmark.Href != "text/ch1.xhtml#start" {
+			t.Errorf(expFormat, "text/ch1.xhtml#start", landmark.Href)
+		}
+
+		if n, fragment := rf.SpineIndex(landmark.Href); n != 1 || fragment != "start" {
+			t.Errorf(expFormat, "1 start", fmt.Sprint(n, " ", fragment))
+		}
+	})
+
+	t.Run("Pages", func(t *testing.T) {
+		pages := rf.Pages()
+		if len(pages) != 3 {
+			t.Fatalf(expFormat, 3, len(pages))
+		}
+
+		if pages[2].Label != "3" || pages[2].Href != "text/ch2.xhtml#p3" {
+			t.Errorf(expFormat, "3 text/ch2.xhtml#p3", pages[2])
+		}
+	})
+}
+
+func TestNCXPages(t *testing.T) {
+	r, err := OpenReader("_test_files/alice.epub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	rf := r.DefaultRendition()
+	pages := rf.Pages()
+	if len(pages) == 0 {
+		t.Fatalf(expFormat, "pages", "none")
+	}
+
+	if pages[0].Label != "[ii]" {
+		t.Errorf(expFormat, "[ii]", pages[0].Label)
+	}
+
+	if _, ok := rf.StartOfText(); ok {
+		t.Errorf(expFormat, "no start of text", "start of text")
+	}
+}
+
+func TestNavDiscovery(t *testing.T) {
+	testCases := []struct {
+		name  string
+		files map[string]string
+		href  string
+		title string
+	}{
+		{"NavProperty", epub3Files(), "text/ch1.xhtml", "Chapter One"},
+		{"NavPercentEncoded", epub3Files(), "text/ch2.xhtml", "Chapter Two"},
+		{"SpineTOC", epub2Files(), "ch1.html", "Chapter One"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := newTestReader(t, tc.files)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if title := r.DefaultRendition().ItemName(tc.href); title != tc.title {
+				t.Errorf(expFormat, tc.title, title)
+			}
+		})
+	}
+
+	t.Run("NCXMediaType", func(t *testing.T) {
+		files := epub2Files()
+		files["OEBPS/content.opf"] = strings.Replace(files["OEBPS/content.opf"], `<spine toc="navigation">`, "<spine>", 1)
+
+		r, err := newTestReader(t, files)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if title := r.DefaultRendition().ItemName("ch1.html"); title != "Chapter One" {
+			t.Errorf(expFormat, "Chapter One", title)
+		}
+	})
+
+	t.Run("GuideStartOfText", func(t *testing.T) {
+		r, err := newTestReader(t, epub2Files())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rf := r.DefaultRendition()
+		landmark, ok := rf.StartOfText()
+		if n, _ := rf.SpineIndex(landmark.Href); !ok || n != 0 {
+			t.Errorf(expFormat, "ch1.html#start", landmark.Href)
+		}
+	})
+}
+
+func TestTOC(t *testing.T) {
+	testCases := []struct {
+		name  string
+		files map[string]string
+		exp   []TOCEntry
+	}{
+		{
+			"Nav",
+			epub3Files(),
+			[]TOCEntry{
+				{Label: "Chapter One", Href: "text/ch1.xhtml#start"},
+				{Label: "Chapter Two", Href: "text/ch2.xhtml"},
+			},
+		},
+		{
+			"NCX",
+			epub2Files(),
+			[]TOCEntry{
+				{Label: "Chapter One", Href: "ch1.html#start", Children: []TOCEntry{}},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := newTestReader(t, tc.files)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			toc := r.DefaultRendition().TOC()
+			if !reflect.DeepEqual(toc, tc.exp) {
+				t.Errorf(expFormat, tc.exp, toc)
+			}
+		})
+	}
+}
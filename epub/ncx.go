@@ -2,14 +2,20 @@ package epub
 
 import (
 	"encoding/xml"
-	"io"
+	"strings"
 )
 
-const idNCX = "ncx"
+// mediaTypeNCX identifies NCX documents within the manifest.
+const mediaTypeNCX = "application/x-dtbncx+xml"
 
 // NCX represents an EPUB 2.0 compatible navigation document.
 type NCX struct {
-	NavPoints []NavPoint `xml:"navMap>navPoint"`
+	NavPoints   []NavPoint   `xml:"navMap>navPoint"`
+	PageTargets []PageTarget `xml:"pageList>pageTarget"`
+
+	// path is the location of the NCX document within the zip. Sources in the
+	// document are relative to it.
+	path string
 }
 
 // NavPoint represents a location within the epub file that can be navigated
@@ -26,37 +32,72 @@ type NavPoint struct {
 	NavPoints []NavPoint `xml:"navPoint,omitempty"`
 }
 
-// Load EPUB 2.0 compatible navigation documents.
+// PageTarget represents a page break in the print edition of a book.
+type PageTarget struct {
+	ID       string `xml:"id,attr"`
+	Type     string `xml:"type,attr"`
+	Value    string `xml:"value,attr"`
+	NavLabel struct {
+		Text string `xml:"text"`
+	} `xml:"navLabel"`
+	Content struct {
+		Src string `xml:"src,attr"`
+	} `xml:"content"`
+}
+
+// Load EPUB 2.0 compatible navigation documents. The NCX document is the
+// manifest item referenced by the spine's toc attribute.
 func (r *Reader) setNCX() error {
 	for _, rf := range r.Container.Rootfiles {
-		for _, item := range rf.Manifest.Items {
-			if item.ID == idNCX {
-				f, err := item.f.Open()
-				if err != nil {
-					return err
-				}
-				defer f.Close()
-
-				data, err := io.ReadAll(f)
-				if err != nil {
-					return err
-				}
-
-				err = xml.Unmarshal(data, &rf.NCX)
-				if err != nil {
-					return err
-				}
+		// Missing items have already been reported by setItems.
+		item := rf.ncxItem()
+		if item == nil || item.f == nil {
+			continue
+		}
+
+		data, err := r.readFile(item.f.Name)
+		if err != nil {
+			return err
+		}
+
+		err = xml.Unmarshal(data, &rf.NCX)
+		if err != nil {
+			rf.NCX = NCX{}
+			if err := r.tolerate(xmlError(item.f.Name, err)); err != nil {
+				return err
 			}
+
+			continue
 		}
+
+		rf.NCX.path = item.path
 	}
 
 	return nil
 }
 
-// ncxItemName searches for the name of an item in an NCX document.
-func (rf Rootfile) ncxItemName(href string) string {
+// ncxItem returns the manifest item of the NCX document. If the spine does not
+// reference one, the first item with the NCX media type is used.
+func (rf *Rootfile) ncxItem() *Item {
+	var fallback *Item
+	for i := range rf.Manifest.Items {
+		item := &rf.Manifest.Items[i]
+		if rf.Spine.TOC != "" && item.ID == rf.Spine.TOC {
+			return item
+		}
+
+		if fallback == nil && item.MediaType == mediaTypeNCX {
+			fallback = item
+		}
+	}
+
+	return fallback
+}
+
+// ncxItemName searches for the name of the file at target in an NCX document.
+func (rf Rootfile) ncxItemName(target string) string {
 	for _, point := range rf.NCX.NavPoints {
-		if label := point.lookupItemName(href); label != "" {
+		if label := point.lookupItemName(rf.NCX.path, target); label != "" {
 			return label
 		}
 	}
@@ -64,14 +105,16 @@ func (rf Rootfile) ncxItemName(href string) string {
 	return ""
 }
 
-// lookupItemName traverses a NavPoint looking for the name of an item.
-func (np NavPoint) lookupItemName(href string) string {
-	if np.Content.Src == href {
-		return np.NavLabel.Text
+// lookupItemName traverses a NavPoint looking for the name of the file at
+// target. Sources are resolved relative to base and their fragments are
+// ignored.
+func (np NavPoint) lookupItemName(base, target string) string {
+	if p, _ := resolveHref(base, np.Content.Src); p == target {
+		return strings.TrimSpace(np.NavLabel.Text)
 	}
 
 	for _, point := range np.NavPoints {
-		if label := point.lookupItemName(href); label != "" {
+		if label := point.lookupItemName(base, target); label != "" {
 			return label
 		}
 	}
@@ -9,34 +9,36 @@ import (
 
 // Package represents an epub .opf file.
 type Package struct {
+	Version string `xml:"version,attr"`
 	Metadata
 	Manifest
 	Spine
+	Guide
 }
 
 // Metadata contains publishing information about the epub.
 type Metadata struct {
-	Title      string `xml:"metadata>title"`
-	Language   string `xml:"metadata>language"`
+	Title      string `xml:"metadata>title" json:"title"`
+	Language   string `xml:"metadata>language" json:"language"`
 	Identifier struct {
-		Scheme  string `xml:"scheme,attr"`
-		Content string `xml:",innerxml"`
-	} `xml:"metadata>identifier"`
-	Creator     string `xml:"metadata>creator"`
-	Contributor string `xml:"metadata>contributor"`
-	Publisher   string `xml:"metadata>publisher"`
-	Subject     string `xml:"metadata>subject"`
-	Description string `xml:"metadata>description"`
+		Scheme  string `xml:"scheme,attr" json:"scheme"`
+		Content string `xml:",innerxml" json:"content"`
+	} `xml:"metadata>identifier" json:"identifier"`
+	Creator     string `xml:"metadata>creator" json:"creator"`
+	Contributor string `xml:"metadata>contributor" json:"contributor"`
+	Publisher   string `xml:"metadata>publisher" json:"publisher"`
+	Subject     string `xml:"metadata>subject" json:"subject"`
+	Description string `xml:"metadata>description" json:"description"`
 	Dates       []struct {
-		Event string `xml:"event,attr"`
-		Date  string `xml:",innerxml"`
-	} `xml:"metadata>date"`
-	Type     string `xml:"metadata>type"`
-	Format   string `xml:"metadata>format"`
-	Source   string `xml:"metadata>source"`
-	Relation string `xml:"metadata>relation"`
-	Coverage string `xml:"metadata>coverage"`
-	Rights   string `xml:"metadata>rights"`
+		Event string `xml:"event,attr" json:"event"`
+		Date  string `xml:",innerxml" json:"date"`
+	} `xml:"metadata>date" json:"dates"`
+	Type     string `xml:"metadata>type" json:"type"`
+	Format   string `xml:"metadata>format" json:"format"`
+	Source   string `xml:"metadata>source" json:"source"`
+	Relation string `xml:"metadata>relation" json:"relation"`
+	Coverage string `xml:"metadata>coverage" json:"coverage"`
+	Rights   string `xml:"metadata>rights" json:"rights"`
 }
 
 // Manifest lists every file that is part of the epub.
@@ -46,18 +48,32 @@ type Manifest struct {
 
 // Item represents a file stored in the epub.
 type Item struct {
-	ID        string `xml:"id,attr"`
-	HREF      string `xml:"href,attr"`
-	MediaType string `xml:"media-type,attr"`
-	Label     string
-	f         *zip.File
+	ID         string `xml:"id,attr"`
+	HREF       string `xml:"href,attr"`
+	MediaType  string `xml:"media-type,attr"`
+	Properties string `xml:"properties,attr"`
+	Label      string
+	f          *zip.File
+
+	// path is the location of the item within the zip.
+	path string
+}
+
+// Size returns the uncompressed size of the item's contents, or 0 if the item
+// does not exist in the zip.
+func (item *Item) Size() uint64 {
+	if item.f == nil {
+		return 0
+	}
+
+	return item.f.UncompressedSize64
 }
 
 // Open returns a ReadCloser that provides access to the Items's contents.
 // Multiple items may be read concurrently.
 func (item *Item) Open() (r io.ReadCloser, err error) {
 	if item.f == nil {
-		return nil, ErrBadManifest
+		return nil, &Error{Path: item.path, ID: item.ID, Err: ErrBadManifest}
 	}
 
 	return item.f.Open()
@@ -70,6 +86,8 @@ func (rc *ReadCloser) Close() {
 
 // Spine defines the reading order of the epub documents.
 type Spine struct {
+	// TOC is the ID of the manifest item holding the NCX document.
+	TOC      string    `xml:"-"`
 	Itemrefs []Itemref `xml:"spine>itemref"`
 }
 
@@ -79,27 +97,49 @@ type Itemref struct {
 	*Item
 }
 
+// Guide lists EPUB 2.0 reference locations, such as the start of the text. It
+// is superseded by the landmarks nav in EPUB 3.0.
+type Guide struct {
+	References []Reference `xml:"guide>reference"`
+}
+
+// Reference points to a structural component of the epub.
+type Reference struct {
+	Type  string `xml:"type,attr"`
+	Title string `xml:"title,attr"`
+	HREF  string `xml:"href,attr"`
+}
+
 // setPackages unmarshal's each of the epub's .opf files.
 func (r *Reader) setPackages() error {
 	for _, rf := range r.Container.Rootfiles {
 		if r.files[rf.FullPath] == nil {
-			return ErrBadRootfile
+			return &Error{
+				Path: containerPath,
+				Line: lineOf(r.sources[containerPath], "full-path", rf.FullPath),
+				Err:  ErrBadRootfile,
+			}
 		}
 
-		f, err := r.files[rf.FullPath].Open()
+		data, err := r.readFile(rf.FullPath)
 		if err != nil {
 			return err
 		}
-		defer f.Close()
 
-		data, err := io.ReadAll(f)
+		err = xml.Unmarshal(data, &rf.Package)
 		if err != nil {
-			return err
+			return xmlError(rf.FullPath, err)
 		}
 
-		err = xml.Unmarshal(data, &rf.Package)
-		if err != nil {
-			return err
+		// encoding/xml flattens the fields of the embedded Spine into Package,
+		// so the spine's attributes cannot be given in its tags.
+		var attrs struct {
+			Spine struct {
+				TOC string `xml:"toc,attr"`
+			} `xml:"spine"`
+		}
+		if err := xml.Unmarshal(data, &attrs); err == nil {
+			rf.Spine.TOC = attrs.Spine.TOC
 		}
 	}
 
@@ -116,17 +156,46 @@ func (r *Reader) setItems() error {
 			item := &rf.Manifest.Items[i]
 			itemMap[item.ID] = item
 
-			abs := path.Join(path.Dir(rf.FullPath), item.HREF)
-			item.f = r.files[abs]
+			item.path, _ = resolveHref(rf.FullPath, item.HREF)
+			item.f = r.files[item.path]
+
+			// Some epubs do not percent-encode their hrefs; fall back to
+			// the href as written.
+			if item.f == nil {
+				item.f = r.files[path.Join(path.Dir(rf.FullPath), item.HREF)]
+			}
+
+			if item.f == nil {
+				r.warn(&Error{
+					Path: rf.FullPath,
+					ID:   item.ID,
+					Line: lineOf(r.sources[rf.FullPath], "href", item.HREF),
+					Err:  ErrBadManifest,
+				})
+			}
 		}
 
-		for i := range rf.Spine.Itemrefs {
-			itemref := &rf.Spine.Itemrefs[i]
+		itemrefs := rf.Spine.Itemrefs[:0]
+		for _, itemref := range rf.Spine.Itemrefs {
 			itemref.Item = itemMap[itemref.IDREF]
 			if itemref.Item == nil {
-				return ErrBadItemref
+				err := r.tolerate(&Error{
+					Path: rf.FullPath,
+					ID:   itemref.IDREF,
+					Line: lineOf(r.sources[rf.FullPath], "idref", itemref.IDREF),
+					Err:  ErrBadItemref,
+				})
+				if err != nil {
+					return err
+				}
+
+				// Drop the itemref so that there is nothing to trip over later.
+				continue
 			}
+
+			itemrefs = append(itemrefs, itemref)
 		}
+		rf.Spine.Itemrefs = itemrefs
 		itemrefCount += len(rf.Spine.Itemrefs)
 	}
 
@@ -0,0 +1,56 @@
+package epub
+
+import "strings"
+
+// TOCEntry is an entry in the table of contents. Its Href is relative to the
+// package document.
+type TOCEntry struct {
+	Label    string     `json:"label"`
+	Href     string     `json:"href"`
+	Children []TOCEntry `json:"children,omitempty"`
+}
+
+// TOC returns the table of contents. The EPUB 3.0 navigation document is
+// preferred; the EPUB 2.0 NCX document is used as a fallback.
+func (rf Rootfile) TOC() []TOCEntry {
+	// EPUB 3.0 compatible.
+	if nav := rf.NavDoc.TOCNav(); nav != nil && len(nav.Items) > 0 {
+		return rf.navTOC(nav.Items)
+	}
+
+	// EPUB 2.0 compatible.
+	return rf.ncxTOC(rf.NCX.NavPoints)
+}
+
+// navTOC converts navigation document list items to TOC entries.
+func (rf Rootfile) navTOC(items []ListItem) []TOCEntry {
+	entries := make([]TOCEntry, 0, len(items))
+	for _, li := range items {
+		entry := TOCEntry{
+			Label: strings.TrimSpace(li.Link.Text),
+			Href:  rf.packageHref(rf.NavDoc.path, li.Link.Href),
+		}
+
+		if li.SubItems != nil {
+			entry.Children = rf.navTOC(*li.SubItems)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// ncxTOC converts NCX nav points to TOC entries.
+func (rf Rootfile) ncxTOC(points []NavPoint) []TOCEntry {
+	entries := make([]TOCEntry, 0, len(points))
+	for _, np := range points {
+		entries = append(entries, TOCEntry{
+			Label:    strings.TrimSpace(np.NavLabel.Text),
+			Href:     rf.packageHref(rf.NCX.path, np.Content.Src),
+			Children: rf.ncxTOC(np.NavPoints),
+		})
+	}
+
+	return entries
+}
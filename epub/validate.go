@@ -0,0 +1,311 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	mimetypePath    = "mimetype"
+	mimetypeContent = "application/epub+zip"
+	mediaTypeXHTML  = "application/xhtml+xml"
+)
+
+var (
+	// ErrBadMimetype occurs when the mimetype file is missing or does not
+	// identify the file as an epub.
+	ErrBadMimetype = errors.New("epub: mimetype file must contain \"" + mimetypeContent + "\"")
+
+	// ErrMimetypePosition occurs when the mimetype file is not the first,
+	// uncompressed entry in the zip.
+	ErrMimetypePosition = errors.New("epub: mimetype file must be the first entry in the zip and must not be compressed")
+
+	// ErrNoNav occurs when an EPUB 3.0 package does not have a navigation
+	// document, or when an EPUB 2.0 package does not have an NCX document.
+	ErrNoNav = errors.New("epub: no navigation document found")
+
+	// ErrDuplicateID occurs when an ID is used more than once within the same
+	// document.
+	ErrDuplicateID = errors.New("epub: duplicate ID")
+
+	// ErrBadImage occurs when a document references an image that does not
+	// exist in the zip.
+	ErrBadImage = errors.New("epub: document references non-existent image")
+
+	// ErrUnlistedImage occurs when a document references an image that exists
+	// in the zip, but is not listed in the manifest.
+	ErrUnlistedImage = errors.New("epub: document references image missing from manifest")
+)
+
+// Severity indicates how serious a problem found by Validate is.
+type Severity string
+
+const (
+	// SeverityError indicates a problem that prevents some or all of the epub
+	// from being read correctly.
+	SeverityError Severity = "error"
+
+	// SeverityWarning indicates a conformance problem that most readers are
+	// able to work around.
+	SeverityWarning Severity = "warning"
+)
+
+// Issue is a problem found by Validate.
+type Issue struct {
+	Severity Severity `json:"severity"`
+	Path     string   `json:"path,omitempty"`
+	ID       string   `json:"id,omitempty"`
+	Line     int      `json:"line,omitempty"`
+	Message  string   `json:"message"`
+
+	err error
+}
+
+// Unwrap returns the underlying error.
+func (i Issue) Unwrap() error {
+	return i.err
+}
+
+// NewIssue returns an issue of the given severity describing err. Location
+// details are taken from err if it is an *Error.
+func NewIssue(severity Severity, err error) Issue {
+	issue := Issue{
+		Severity: severity,
+		Message:  strings.TrimPrefix(err.Error(), "epub: "),
+		err:      err,
+	}
+
+	var epubErr *Error
+	if errors.As(err, &epubErr) {
+		issue.Path = epubErr.Path
+		issue.ID = epubErr.ID
+		issue.Line = epubErr.Line
+		issue.Message = strings.TrimPrefix(epubErr.Err.Error(), "epub: ")
+	}
+
+	return issue
+}
+
+// Error implements error. Issues not created by NewIssue are described by
+// their message and location.
+func (i Issue) Error() string {
+	if i.err != nil {
+		return i.err.Error()
+	}
+
+	err := &Error{Path: i.Path, ID: i.ID, Line: i.Line, Err: errors.New(i.Message)}
+
+	return err.Error()
+}
+
+// Report lists the problems found by Validate.
+type Report struct {
+	Issues []Issue `json:"issues"`
+}
+
+// Errors counts the issues with SeverityError.
+func (r Report) Errors() int {
+	return r.count(SeverityError)
+}
+
+// Warnings counts the issues with SeverityWarning.
+func (r Report) Warnings() int {
+	return r.count(SeverityWarning)
+}
+
+func (r Report) count(severity Severity) int {
+	n := 0
+	for _, issue := range r.Issues {
+		if issue.Severity == severity {
+			n++
+		}
+	}
+
+	return n
+}
+
+// add records err as an issue of the given severity.
+func (r *Report) add(severity Severity, err error) {
+	r.Issues = append(r.Issues, NewIssue(severity, err))
+}
+
+// Validate checks an epub for conformance problems. Unlike NewReader, it does
+// not stop at the first problem, but reports as many as it can find.
+func Validate(ra io.ReaderAt, size int64) Report {
+	var report Report
+
+	z, err := zip.NewReader(ra, size)
+	if err != nil {
+		report.add(SeverityError, err)
+		return report
+	}
+
+	validateMimetype(z, &report)
+
+	r := &Reader{lenient: true}
+	if err := r.init(z); err != nil {
+		report.add(SeverityError, err)
+		return report
+	}
+
+	for _, warning := range r.Warnings {
+		report.add(SeverityError, warning)
+	}
+
+	for _, rf := range r.Container.Rootfiles {
+		r.validateRootfile(rf, &report)
+	}
+
+	return report
+}
+
+// validateMimetype checks that the zip is identified as an epub.
+func validateMimetype(z *zip.Reader, report *Report) {
+	for i, f := range z.File {
+		if f.Name != mimetypePath {
+			continue
+		}
+
+		if i != 0 || f.Method != zip.Store {
+			report.add(SeverityWarning, &Error{Path: mimetypePath, Err: ErrMimetypePosition})
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			report.add(SeverityError, &Error{Path: mimetypePath, Err: err})
+			return
+		}
+		defer rc.Close()
+
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			report.add(SeverityError, &Error{Path: mimetypePath, Err: err})
+			return
+		}
+
+		if string(data) != mimetypeContent {
+			report.add(SeverityError, &Error{Path: mimetypePath, Err: ErrBadMimetype})
+		}
+
+		return
+	}
+
+	report.add(SeverityError, &Error{Path: mimetypePath, Err: ErrBadMimetype})
+}
+
+// validateRootfile checks the package document and the files it references.
+func (r *Reader) validateRootfile(rf *Rootfile, report *Report) {
+	// Navigation documents.
+	if strings.HasPrefix(rf.Package.Version, "3") {
+		if rf.navItem() == nil {
+			report.add(SeverityError, &Error{Path: rf.FullPath, Err: ErrNoNav})
+		}
+	} else if rf.ncxItem() == nil {
+		report.add(SeverityError, &Error{Path: rf.FullPath, Err: ErrNoNav})
+	}
+
+	// Manifest IDs.
+	ids := map[string]bool{}
+	for _, item := range rf.Manifest.Items {
+		if ids[item.ID] {
+			report.add(SeverityError, &Error{Path: rf.FullPath, ID: item.ID, Err: ErrDuplicateID})
+		}
+		ids[item.ID] = true
+	}
+
+	// Manifest paths, used to check image references.
+	manifest := map[string]bool{}
+	for _, item := range rf.Manifest.Items {
+		manifest[item.path] = true
+	}
+
+	for i := range rf.Manifest.Items {
+		item := &rf.Manifest.Items[i]
+		if item.MediaType == mediaTypeXHTML && item.f != nil {
+			r.validateXHTML(item, manifest, report)
+		}
+	}
+}
+
+// validateXHTML checks that a content document is well-formed, that its IDs
+// are unique, and that the images it references exist.
+func (r *Reader) validateXHTML(item *Item, manifest map[string]bool, report *Report) {
+	data, err := r.readFile(item.f.Name)
+	if err != nil {
+		report.add(SeverityError, err)
+		return
+	}
+
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Entity = xml.HTMLEntity
+
+	ids := map[string]bool{}
+	for {
+		token, err := d.Token()
+		if err == io.EOF {
+			return
+		} else if err != nil {
+			report.add(SeverityError, xmlError(item.path, err))
+			return
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		line := bytes.Count(data[:d.InputOffset()], []byte("\n")) + 1
+		for _, attr := range start.Attr {
+			switch {
+			case attr.Name.Local == "id" && attr.Name.Space == "":
+				if ids[attr.Value] {
+					report.add(SeverityError, &Error{Path: item.path, ID: attr.Value, Line: line, Err: ErrDuplicateID})
+				}
+				ids[attr.Value] = true
+			case isImageRef(start.Name.Local, attr.Name.Local):
+				r.validateImage(item, attr.Value, line, manifest, report)
+			}
+		}
+	}
+}
+
+// isImageRef reports whether an attribute of an element references an image.
+func isImageRef(element, attr string) bool {
+	switch element {
+	case "img":
+		return attr == "src"
+	case "image":
+		// SVG images use href or xlink:href.
+		return attr == "href"
+	}
+
+	return false
+}
+
+// validateImage checks that an image referenced by a document exists.
+func (r *Reader) validateImage(item *Item, src string, line int, manifest map[string]bool, report *Report) {
+	target, _ := resolveHref(item.path, src)
+	if target == "" {
+		// External images are not part of the epub.
+		return
+	}
+
+	if r.files[target] == nil {
+		report.add(SeverityError, &Error{
+			Path: item.path,
+			Line: line,
+			Err:  fmt.Errorf("%w: %s", ErrBadImage, src),
+		})
+	} else if !manifest[target] {
+		report.add(SeverityWarning, &Error{
+			Path: item.path,
+			Line: line,
+			Err:  fmt.Errorf("%w: %s", ErrUnlistedImage, src),
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		mutate      func(files map[string]string)
+		expErr      error
+		expSeverity Severity
+	}{
+		{
+			"MissingMimetype",
+			func(files map[string]string) {
+				delete(files, "mimetype")
+			},
+			ErrBadMimetype, SeverityError,
+		},
+		{
+			"MissingManifestFile",
+			func(files map[string]string) {
+				delete(files, "OEBPS/text/ch2.xhtml")
+			},
+			ErrBadManifest, SeverityError,
+		},
+		{
+			"DanglingItemref",
+			func(files map[string]string) {
+				files["OEBPS/content.opf"] = strings.Replace(files["OEBPS/content.opf"], `idref="ch2"`, `idref="ch3"`, 1)
+			},
+			ErrBadItemref, SeverityError,
+		},
+		{
+			"MissingNav",
+			func(files map[string]string) {
+				files["OEBPS/content.opf"] = strings.Replace(files["OEBPS/content.opf"], ` properties="nav"`, "", 1)
+			},
+			ErrNoNav, SeverityError,
+		},
+		{
+			"DuplicateManifestID",
+			func(files map[string]string) {
+				files["OEBPS/content.opf"] = strings.Replace(files["OEBPS/content.opf"], "</manifest>", `<item id="ch1" href="text/ch2.xhtml" media-type="application/xhtml+xml"/></manifest>`, 1)
+			},
+			ErrDuplicateID, SeverityError,
+		},
+		{
+			"DuplicateDocumentID",
+			func(files map[string]string) {
+				files["OEBPS/text/ch1.xhtml"] = strings.Replace(files["OEBPS/text/ch1.xhtml"], `id="p2"`, `id="p1"`, 1)
+			},
+			ErrDuplicateID, SeverityError,
+		},
+		{
+			"MalformedXHTML",
+			func(files map[string]string) {
+				files["OEBPS/text/ch2.xhtml"] = strings.Replace(files["OEBPS/text/ch2.xhtml"], "</h1>", "", 1)
+			},
+			ErrBadXML, SeverityError,
+		},
+		{
+			"MissingImage",
+			func(files map[string]string) {
+				files["OEBPS/text/ch2.xhtml"] = strings.Replace(files["OEBPS/text/ch2.xhtml"], "<h1>", `<img src="../images/missing.png"/><h1>`, 1)
+			},
+			ErrBadImage, SeverityError,
+		},
+		{
+			"UnlistedImage",
+			func(files map[string]string) {
+				files["OEBPS/images/unlisted.png"] = ""
+				files["OEBPS/text/ch2.xhtml"] = strings.Replace(files["OEBPS/text/ch2.xhtml"], "<h1>", `<img src="../images/unlisted.png"/><h1>`, 1)
+			},
+			ErrUnlistedImage, SeverityWarning,
+		},
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		buf := buildTestZip(t, epub3Files())
+		if report := Validate(buf, buf.Size()); len(report.Issues) > 0 {
+			t.Errorf(expFormat, "no issues", report.Issues)
+		}
+	})
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			files := epub3Files()
+			tc.mutate(files)
+
+			buf := buildTestZip(t, files)
+			report := Validate(buf, buf.Size())
+			if len(report.Issues) != 1 {
+				t.Fatalf(expFormat, "1 issue", report.Issues)
+			}
+
+			issue := report.Issues[0]
+			if !errors.Is(issue, tc.expErr) {
+				t.Errorf(expFormat, tc.expErr, issue)
+			}
+
+			if issue.Severity != tc.expSeverity {
+				t.Errorf(expFormat, tc.expSeverity, issue.Severity)
+			}
+		})
+	}
+}
+
+func TestValidateCompressedMimetype(t *testing.T) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range epub3Files() {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	report := Validate(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if report.Errors() != 0 || report.Warnings() != 1 {
+		t.Fatalf(expFormat, "1 warning", report.Issues)
+	}
+
+	if !errors.Is(report.Issues[0], ErrMimetypePosition) {
+		t.Errorf(expFormat, ErrMimetypePosition, report.Issues[0])
+	}
+}
+
+func TestIssueError(t *testing.T) {
+	testCases := []struct {
+		issue  Issue
+		expMsg string
+	}{
+		{Issue{Severity: SeverityError, Message: "no such file"}, "epub: no such file"},
+		{
+			Issue{Severity: SeverityWarning, Path: "OEBPS/ch1.xhtml", Line: 3, ID: "p1", Message: "duplicate ID"},
+			"epub: OEBPS/ch1.xhtml:3: duplicate ID (id \"p1\")",
+		},
+		{NewIssue(SeverityError, &Error{Path: "OEBPS/content.opf", Err: ErrNoNav}), "epub: OEBPS/content.opf: no navigation document found"},
+	}
+
+	for _, tc := range testCases {
+		if msg := tc.issue.Error(); msg != tc.expMsg {
+			t.Errorf(expFormat, tc.expMsg, msg)
+		}
+	}
+
+	issue := NewIssue(SeverityError, &Error{Path: "OEBPS/content.opf", Line: 7, Err: ErrNoNav})
+	if issue.Path != "OEBPS/content.opf" || issue.Line != 7 || issue.Message != "no navigation document found" {
+		t.Errorf(expFormat, "location from *Error", issue)
+	}
+
+	if !errors.Is(issue, ErrNoNav) {
+		t.Errorf(expFormat, ErrNoNav, issue)
+	}
+}
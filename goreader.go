@@ -2,20 +2,72 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"log/slog"
 	"os"
+	"strconv"
+	"strings"
 
-	"github.com/taylorskalyo/goreader/epub"
+	"github.com/taylorskalyo/goreader/config"
+	"github.com/taylorskalyo/goreader/state"
 	"github.com/taylorskalyo/goreader/views"
 )
 
 var (
 	errUsage = errors.New("exit with error")
+
+	// errFailed indicates that a command has already reported its failure to
+	// the user.
+	errFailed = errors.New("command failed")
 )
 
+// commands maps subcommand names to their implementations. Each command is
+// given the arguments that follow its name.
+var commands = map[string]func(args []string) error{
+	"annotations": runAnnotations,
+	"cat":         runCat,
+	"export":      runCat,
+	"info":        runInfo,
+	"list":        runList,
+	"stats":       runStats,
+	"validate":    runValidate,
+}
+
+// openStore opens the store chosen in the config file, in which reading
+// progress is saved.
+func openStore() (state.Store, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+
+	return state.Open(cfg.Storage.Backend)
+}
+
+// defaultRecent is how many books --recent lists if no number is given.
+const defaultRecent = 10
+
+// parseRecent parses the --recent flag, which may be given a number of books
+// to list (e.g. --recent=5). It reports whether arg is the flag.
+func parseRecent(arg string) (n int, ok bool, err error) {
+	name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+	if name != "recent" || !strings.HasPrefix(arg, "-") {
+		return 0, false, nil
+	} else if !hasValue {
+		return defaultRecent, true, nil
+	}
+
+	n, err = strconv.Atoi(value)
+	if err == nil && n < 1 {
+		err = fmt.Errorf("invalid number of books %q", value)
+	}
+
+	return n, true, err
+}
+
 func main() {
 	if err := run(); err != nil {
-		if err != errUsage {
+		if err != errUsage && err != errFailed {
 
 			// Assume non-usage errors are fatal.
 			slog.Error("Encountered fatal error.", "error", err)
@@ -28,28 +80,40 @@ func main() {
 }
 
 func run() error {
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			return cmd(os.Args[2:])
+		}
+	}
+
 	app := views.NewApplication()
 	if err := app.Configure(); err != nil {
 		return err
 	}
 
 	if len(os.Args) <= 1 {
-		app.PrintUsage()
-		return errUsage
+		go app.QueueUpdate(app.ReopenLast)
+
+		return app.Run()
+	} else if os.Args[1] == "library" {
+		go app.QueueUpdate(app.ShowLibrary)
+
+		return app.Run()
+	} else if n, ok, err := parseRecent(os.Args[1]); ok {
+		if err != nil {
+			app.PrintUsage()
+			return errUsage
+		}
+
+		go app.QueueUpdate(func() { app.ShowRecent(n) })
+
+		return app.Run()
 	} else if os.Args[1] == "-h" {
 		app.PrintHelp()
 		return nil
 	}
 
-	rc, err := epub.OpenReader(os.Args[1])
-	if err != nil {
-		return err
-	}
-	defer rc.Close()
-
-	go app.QueueUpdate(func() {
-		app.OpenBook(rc.DefaultRendition())
-	})
+	go app.QueueUpdate(func() { app.Open(os.Args[1]) })
 
 	return app.Run()
 }
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taylorskalyo/goreader/config"
+	"github.com/taylorskalyo/goreader/state"
+)
+
+const alice = "epub/_test_files/alice.epub"
+
+// useTempState keeps the config file and reading progress in temporary
+// directories for the rest of the test.
+func useTempState(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	config.ReloadEnv()
+	state.ReloadEnv()
+}
+
+// runCommand runs a command with the given arguments, and returns what it
+// wrote to stdout and stderr along with its error.
+func runCommand(t *testing.T, run func(args []string) error, args ...string) (string, string, error) {
+	dir := t.TempDir()
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	require.NoError(t, err)
+	defer stdout.Close()
+	stderr, err := os.Create(filepath.Join(dir, "stderr"))
+	require.NoError(t, err)
+	defer stderr.Close()
+
+	savedStdout, savedStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = stdout, stderr
+	err = run(args)
+	os.Stdout, os.Stderr = savedStdout, savedStderr
+
+	out, readErr := os.ReadFile(stdout.Name())
+	require.NoError(t, readErr)
+	errOut, readErr := os.ReadFile(stderr.Name())
+	require.NoError(t, readErr)
+
+	return string(out), string(errOut), err
+}
+
+// writeBroken writes a file that is not an epub, and returns its path.
+func writeBroken(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "broken.epub")
+	require.NoError(t, os.WriteFile(path, []byte("not a zip"), 0644))
+
+	return path
+}
+
+func TestCommandUsage(t *testing.T) {
+	useTempState(t)
+	const unknownFlag = "flag provided but not defined: -bogus"
+
+	for _, tc := range []struct {
+		args   []string
+		stderr string
+	}{
+		{args: []string{"annotations"}, stderr: "Usage: goreader annotations"},
+		{args: []string{"annotations", "export", "-bogus", alice}, stderr: unknownFlag},
+		{args: []string{"annotations", "import", alice}, stderr: "Usage: goreader annotations import"},
+		{args: []string{"cat"}, stderr: "Usage: goreader cat"},
+		{args: []string{"cat", "-bogus", alice}, stderr: unknownFlag},
+		{args: []string{"info"}, stderr: "Usage: goreader info"},
+		{args: []string{"info", "-bogus", alice}, stderr: unknownFlag},
+		{args: []string{"list", "-bogus"}, stderr: unknownFlag},
+		{args: []string{"stats", "-bogus"}, stderr: unknownFlag},
+		{args: []string{"validate"}, stderr: "Usage: goreader validate"},
+		{args: []string{"validate", "-bogus", alice}, stderr: unknownFlag},
+	} {
+		t.Run(strings.Join(tc.args, " "), func(t *testing.T) {
+			_, stderr, err := runCommand(t, commands[tc.args[0]], tc.args[1:]...)
+			assert.Equal(t, errUsage, err)
+			assert.Contains(t, stderr, tc.stderr)
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/taylorskalyo/goreader/display"
+	"github.com/taylorskalyo/goreader/epub"
+	"github.com/taylorskalyo/goreader/state"
+)
+
+// bookInfo describes a single epub file.
+type bookInfo struct {
+	File       string          `json:"file"`
+	Metadata   epub.Metadata   `json:"metadata"`
+	Renditions []renditionInfo `json:"renditions"`
+	Progress   *progressInfo   `json:"progress"`
+	Warnings   []string        `json:"warnings"`
+}
+
+// renditionInfo describes one of the renditions (i.e. rootfiles) of an epub.
+type renditionInfo struct {
+	Path     string          `json:"path"`
+	Version  string          `json:"version"`
+	Spine    int             `json:"spine"`
+	Manifest manifestInfo    `json:"manifest"`
+	TOC      []epub.TOCEntry `json:"toc"`
+}
+
+// manifestInfo summarizes the files listed in a manifest.
+type manifestInfo struct {
+	Items      int            `json:"items"`
+	Missing    int            `json:"missing"`
+	Size       uint64         `json:"size"`
+	MediaTypes map[string]int `json:"mediaTypes"`
+}
+
+// progressInfo describes saved reading progress.
+type progressInfo struct {
+	ID           string  `json:"id"`
+	Chapter      int     `json:"chapter"`
+	ChapterTitle string  `json:"chapterTitle,omitempty"`
+	Chapters     int     `json:"chapters"`
+	Position     float64 `json:"position"`
+}
+
+// runInfo prints metadata about epub files.
+func runInfo(args []string) error {
+	flags := flag.NewFlagSet("info", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print information as JSON")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: goreader info [-json] [epub file...]")
+		fmt.Fprintln(flags.Output(), "")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	if flags.NArg() < 1 {
+		flags.Usage()
+		return errUsage
+	}
+
+	store, err := openStore()
+	if err != nil {
+		return err
+	}
+
+	infos := make([]bookInfo, flags.NArg())
+	for i, name := range flags.Args() {
+		info, err := readInfo(store, name)
+		if err != nil {
+			return err
+		}
+		infos[i] = info
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(infos)
+	}
+
+	for i, info := range infos {
+		if i > 0 {
+			fmt.Println()
+		}
+
+		if err := printInfo(os.Stdout, info); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readInfo collects information about the epub file specified by name, and
+// its reading progress in store.
+func readInfo(store state.Store, name string) (bookInfo, error) {
+	rc, err := epub.OpenReader(name, epub.Lenient())
+	if err != nil {
+		return bookInfo{}, err
+	}
+	defer rc.Close()
+
+	info := bookInfo{
+		File:       name,
+		Renditions: []renditionInfo{},
+		Warnings:   []string{},
+	}
+
+	for _, warning := range rc.Warnings {
+		info.Warnings = append(info.Warnings, warning.Error())
+	}
+
+	for _, rf := range rc.Rootfiles {
+		info.Renditions = append(info.Renditions, readRenditionInfo(rf))
+	}
+
+	book := rc.DefaultRendition()
+	info.Metadata = book.Metadata
+
+	id, ok, err := state.Identify(store, state.Keys(book))
+	if err != nil {
+		return info, err
+	} else if !ok {
+		return info, nil
+	}
+
+	if progress, err := store.LoadProgress(id); err == nil {
+		info.Progress = &progressInfo{
+			ID:       id,
+			Chapter:  progress.Chapter,
+			Chapters: len(book.Spine.Itemrefs),
+			Position: progress.Position,
+		}
+
+		if progress.Chapter < len(book.Spine.Itemrefs) {
+			info.Progress.ChapterTitle = book.ItemName(book.Spine.Itemrefs[progress.Chapter].HREF)
+		}
+	} else if !os.IsNotExist(err) {
+		return info, err
+	}
+
+	return info, nil
+}
+
+// readRenditionInfo summarizes a single rendition.
+func readRenditionInfo(rf *epub.Rootfile) renditionInfo {
+	info := renditionInfo{
+		Path:    rf.FullPath,
+		Version: rf.Version,
+		Spine:   len(rf.Spine.Itemrefs),
+		Manifest: manifestInfo{
+			Items:      len(rf.Manifest.Items),
+			MediaTypes: map[string]int{},
+		},
+		TOC: rf.TOC(),
+	}
+
+	for i := range rf.Manifest.Items {
+		item := &rf.Manifest.Items[i]
+		info.Manifest.MediaTypes[item.MediaType]++
+		info.Manifest.Size += item.Size()
+
+		if f, err := item.Open(); err != nil {
+			info.Manifest.Missing++
+		} else {
+			f.Close()
+		}
+	}
+
+	return info
+}
+
+// printInfo prints book information in a human-readable format.
+func printInfo(w io.Writer, info bookInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	m := info.Metadata
+
+	fmt.Fprintf(tw, "File:\t%s\n", info.File)
+	for _, field := range []struct {
+		name, value string
+	}{
+		{"Title", m.Title},
+		{"Creator", m.Creator},
+		{"Contributor", m.Contributor},
+		{"Publisher", m.Publisher},
+		{"Language", m.Language},
+		{"Identifier", strings.TrimLeft(m.Identifier.Scheme+":"+m.Identifier.Content, ":")},
+		{"Subject", m.Subject},
+		{"Description", m.Description},
+		{"Type", m.Type},
+		{"Format", m.Format},
+		{"Source", m.Source},
+		{"Relation", m.Relation},
+		{"Coverage", m.Coverage},
+		{"Rights", m.Rights},
+	} {
+		if field.value != "" {
+			fmt.Fprintf(tw, "%s:\t%s\n", field.name, display.OneLine(field.value))
+		}
+	}
+
+	for _, date := range m.Dates {
+		if date.Event != "" {
+			fmt.Fprintf(tw, "Date (%s):\t%s\n", date.Event, date.Date)
+		} else {
+			fmt.Fprintf(tw, "Date:\t%s\n", date.Date)
+		}
+	}
+
+	if p := info.Progress; p != nil {
+		chapter := fmt.Sprintf("chapter %d of %d", p.Chapter+1, p.Chapters)
+		if p.ChapterTitle != "" {
+			chapter = fmt.Sprintf("%s (%s)", chapter, p.ChapterTitle)
+		}
+		fmt.Fprintf(tw, "Progress:\t%s, %.0f%% through chapter\n", chapter, p.Position*100)
+	} else {
+		fmt.Fprintf(tw, "Progress:\tnot started\n")
+	}
+
+	for _, r := range info.Renditions {
+		fmt.Fprintf(tw, "Rendition:\t%s (EPUB %s)\n", r.Path, r.Version)
+		fmt.Fprintf(tw, "  Spine:\t%d items\n", r.Spine)
+		fmt.Fprintf(tw, "  Manifest:\t%d items, %s", r.Manifest.Items, formatSize(r.Manifest.Size))
+		if r.Manifest.Missing > 0 {
+			fmt.Fprintf(tw, ", %d missing", r.Manifest.Missing)
+		}
+		fmt.Fprintln(tw)
+
+		mediaTypes := make([]string, 0, len(r.Manifest.MediaTypes))
+		for mediaType := range r.Manifest.MediaTypes {
+			mediaTypes = append(mediaTypes, mediaType)
+		}
+		sort.Strings(mediaTypes)
+		for _, mediaType := range mediaTypes {
+			fmt.Fprintf(tw, "\t  %s: %d\n", mediaType, r.Manifest.MediaTypes[mediaType])
+		}
+	}
+
+	for _, warning := range info.Warnings {
+		fmt.Fprintf(tw, "Warning:\t%s\n", warning)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	// The table of contents is printed after the tabular fields since its
+	// indentation would otherwise be lost.
+	for _, r := range info.Renditions {
+		if len(r.TOC) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "\nContents (%s):\n", r.Path)
+		printTOC(w, r.TOC, 1)
+	}
+
+	return nil
+}
+
+// printTOC prints nested table of contents entries.
+func printTOC(w io.Writer, entries []epub.TOCEntry, depth int) {
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s%s\n", strings.Repeat("  ", depth), display.OneLine(entry.Label))
+		printTOC(w, entry.Children, depth+1)
+	}
+}
+
+// formatSize formats a number of bytes for humans.
+func formatSize(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taylorskalyo/goreader/state"
+)
+
+func TestInfo(t *testing.T) {
+	useTempState(t)
+	broken := writeBroken(t)
+
+	for _, tc := range []struct {
+		name   string
+		args   []string
+		err    bool
+		stdout []string
+	}{
+		{
+			name: "text",
+			args: []string{alice},
+			stdout: []string{
+				"File:                epub/_test_files/alice.epub\n",
+				"Creator:             Lewis Carroll\n",
+				"Identifier:          URI:http://www.gutenberg.org/ebooks/28885\n",
+				"Date (publication):  2009-05-19\n",
+				"Progress:            not started\n",
+				"Rendition:           OEBPS/content.opf (EPUB 2.0)\n",
+				"  Spine:             14 items\n",
+				"application/xhtml+xml: 14\n",
+				"Contents (OEBPS/content.opf):\n  ALICE'S ADVENTURES IN WONDERLAND\n",
+			},
+		},
+		{
+			name:   "several files",
+			args:   []string{alice, alice},
+			stdout: []string{"\n\nFile:                epub/_test_files/alice.epub\n"},
+		},
+		{
+			name: "unreadable",
+			args: []string{broken},
+			err:  true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			stdout, _, err := runCommand(t, runInfo, tc.args...)
+			if tc.err {
+				assert.Error(t, err)
+				assert.NotEqual(t, errUsage, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			for _, s := range tc.stdout {
+				assert.Contains(t, stdout, s)
+			}
+		})
+	}
+}
+
+func TestInfoJSON(t *testing.T) {
+	useTempState(t)
+
+	store, err := openStore()
+	require.NoError(t, err)
+	const id = "URI:http://www.gutenberg.org/ebooks/28885"
+	require.NoError(t, store.StoreProgress(id, state.Progress{
+		Title:    "Alice's Adventures in Wonderland",
+		Chapter:  1,
+		Position: 0.5,
+		Modified: time.Now(),
+	}))
+
+	stdout, _, err := runCommand(t, runInfo, "-json", alice)
+	require.NoError(t, err)
+
+	var infos []bookInfo
+	require.NoError(t, json.Unmarshal([]byte(stdout), &infos))
+	require.Len(t, infos, 1)
+
+	info := infos[0]
+	assert.Equal(t, alice, info.File)
+	assert.Equal(t, "Lewis Carroll", info.Metadata.Creator)
+	assert.NotNil(t, info.Warnings)
+	if assert.Len(t, info.Renditions, 1) {
+		r := info.Renditions[0]
+		assert.Equal(t, "OEBPS/content.opf", r.Path)
+		assert.Equal(t, 14, r.Spine)
+		assert.Equal(t, 55, r.Manifest.Items)
+		assert.Equal(t, 0, r.Manifest.Missing)
+		assert.Equal(t, 14, r.Manifest.MediaTypes["application/xhtml+xml"])
+		assert.NotEmpty(t, r.TOC)
+	}
+	if assert.NotNil(t, info.Progress) {
+		assert.Equal(t, progressInfo{
+			ID:           id,
+			Chapter:      1,
+			ChapterTitle: "ALICE'S ADVENTURES IN WONDERLAND",
+			Chapters:     14,
+			Position:     0.5,
+		}, *info.Progress)
+	}
+
+	// The same progress is described in the text output.
+	stdout, _, err = runCommand(t, runInfo, alice)
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "Progress:            chapter 2 of 14 (ALICE'S ADVENTURES IN WONDERLAND)")
+	assert.Contains(t, stdout, "50% through chapter\n")
+}
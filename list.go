@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/taylorskalyo/goreader/config"
+	"github.com/taylorskalyo/goreader/display"
+	"github.com/taylorskalyo/goreader/state"
+)
+
+// listEntry describes a book found in the library.
+type listEntry struct {
+	File      string  `json:"file"`
+	ID        string  `json:"id"`
+	Title     string  `json:"title"`
+	Author    string  `json:"author"`
+	Chapters  int     `json:"chapters"`
+	Percent   float64 `json:"percent"`
+	Duplicate bool    `json:"duplicate"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// runList scans the library roots for epub files and lists the books found.
+func runList(args []string) error {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the list as JSON")
+	duplicatesOnly := flags.Bool("duplicates", false, "only list books found in more than one file")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: goreader list [-json] [-duplicates] [directory...]")
+		fmt.Fprintln(flags.Output(), "")
+		fmt.Fprintln(flags.Output(), "Directories default to the library roots set in config.yml.")
+		fmt.Fprintln(flags.Output(), "")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	roots := flags.Args()
+	if len(roots) == 0 {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+		roots = cfg.Library.RootDirs()
+	}
+
+	if len(roots) == 0 {
+		fmt.Fprintf(flags.Output(), "No library roots configured. Set library.roots in %s or pass directories to scan.\n", config.ConfigFile)
+		return errUsage
+	}
+
+	index, result, err := state.ScanLibrary(roots)
+	if err != nil {
+		return err
+	}
+
+	// Books in roots that could not be scanned, such as an unmounted drive,
+	// are still listed from the index.
+	for _, root := range roots {
+		if err, ok := result.Failed[root]; ok {
+			fmt.Fprintf(os.Stderr, "Could not scan %s: %s\n", root, err)
+		}
+	}
+
+	store, err := openStore()
+	if err != nil {
+		return err
+	}
+
+	library, err := store.LoadLibrary()
+	if err != nil {
+		return fmt.Errorf("load progress: %w", err)
+	}
+
+	aliases, err := store.LoadAliases()
+	if err != nil {
+		return fmt.Errorf("load progress: %w", err)
+	}
+
+	duplicates := map[string]bool{}
+	for _, group := range index.Duplicates() {
+		for _, entry := range group {
+			duplicates[entry.Path] = true
+		}
+	}
+
+	entries := []listEntry{}
+	for _, entry := range index.Entries(roots...) {
+		if *duplicatesOnly && !duplicates[entry.Path] {
+			continue
+		}
+
+		id, _ := aliases.Resolve(entry.Keys(), library)
+		entries = append(entries, listEntry{
+			File:      entry.Path,
+			ID:        id,
+			Title:     entry.Title,
+			Author:    entry.Author,
+			Chapters:  entry.Chapters,
+			Percent:   library[id].Percent(),
+			Duplicate: duplicates[entry.Path],
+			Error:     entry.Error,
+		})
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(entries); err != nil {
+			return err
+		}
+	} else {
+		if err := printList(os.Stdout, entries); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "\n%d books (%d added, %d updated, %d removed)\n", len(entries), result.Added, result.Updated, result.Removed)
+	}
+
+	if len(result.Failed) > 0 {
+		return errFailed
+	}
+
+	return nil
+}
+
+// printList prints the books found in a human-readable table.
+func printList(w io.Writer, entries []listEntry) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TITLE\tAUTHOR\tREAD\tFILE")
+	for _, entry := range entries {
+		title := display.OneLine(entry.Title)
+		if entry.Error != "" {
+			title = "(unreadable)"
+		} else if entry.Duplicate {
+			title += " (duplicate)"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%.0f%%\t%s\n", title, display.OneLine(entry.Author), entry.Percent, entry.File)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taylorskalyo/goreader/config"
+)
+
+// writeLibrary fills a library root with two copies of a book and a file that
+// is not an epub, and returns the root.
+func writeLibrary(t *testing.T) string {
+	// Paths in the index have symlinks resolved.
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(alice)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "nested"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "alice.epub"), data, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "nested", "copy.epub"), data, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "broken.epub"), []byte("not a zip"), 0644))
+
+	return root
+}
+
+func TestList(t *testing.T) {
+	useTempState(t)
+	root := writeLibrary(t)
+	missing := filepath.Join(root, "missing")
+
+	for _, tc := range []struct {
+		name   string
+		args   []string
+		err    error
+		stdout []string
+		stderr []string
+	}{
+		{
+			name: "text",
+			args: []string{root},
+			stdout: []string{
+				"TITLE",
+				"(unreadable)",
+				"Alice's Adventures in Wonderland / Illustrated by Arthur Rackham. With a Proem by Austin Dobson (duplicate)  Lewis Carroll  0%    " + filepath.Join(root, "alice.epub") + "\n",
+				filepath.Join(root, "nested", "copy.epub") + "\n",
+			},
+			stderr: []string{"\n3 books (3 added, 0 updated, 0 removed)\n"},
+		},
+		{
+			name:   "rescanned",
+			args:   []string{root},
+			stderr: []string{"\n3 books (0 added, 0 updated, 0 removed)\n"},
+		},
+		{
+			name: "missing root",
+			args: []string{missing, root},
+			err:  errFailed,
+			stdout: []string{
+				filepath.Join(root, "alice.epub") + "\n",
+			},
+			stderr: []string{
+				"Could not scan " + missing + ": ",
+				"\n3 books",
+			},
+		},
+		{
+			name:   "no roots",
+			err:    errUsage,
+			stderr: []string{"No library roots configured."},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			stdout, stderr, err := runCommand(t, runList, tc.args...)
+			assert.Equal(t, tc.err, err)
+			for _, s := range tc.stdout {
+				assert.Contains(t, stdout, s)
+			}
+			for _, s := range tc.stderr {
+				assert.Contains(t, stderr, s)
+			}
+		})
+	}
+
+	// Books that are gone are dropped from the index, and are no longer
+	// duplicated.
+	require.NoError(t, os.Remove(filepath.Join(root, "nested", "copy.epub")))
+	stdout, stderr, err := runCommand(t, runList, root)
+	require.NoError(t, err)
+	assert.NotContains(t, stdout, "(duplicate)")
+	assert.Contains(t, stderr, "\n2 books (0 added, 0 updated, 1 removed)\n")
+}
+
+func TestListJSON(t *testing.T) {
+	useTempState(t)
+	root := writeLibrary(t)
+
+	// Roots default to those in the config file.
+	require.NoError(t, os.MkdirAll(filepath.Dir(config.ConfigFile), 0700))
+	require.NoError(t, os.WriteFile(config.ConfigFile, []byte(fmt.Sprintf("library:\n  roots:\n    - %s\n", root)), 0644))
+
+	stdout, _, err := runCommand(t, runList, "-json")
+	require.NoError(t, err)
+
+	var entries []listEntry
+	require.NoError(t, json.Unmarshal([]byte(stdout), &entries))
+	require.Len(t, entries, 3)
+
+	byFile := map[string]listEntry{}
+	for _, entry := range entries {
+		byFile[entry.File] = entry
+	}
+
+	book := byFile[filepath.Join(root, "alice.epub")]
+	assert.Equal(t, "URI:http://www.gutenberg.org/ebooks/28885", book.ID)
+	assert.Equal(t, "Lewis Carroll", book.Author)
+	assert.Equal(t, 14, book.Chapters)
+	assert.True(t, book.Duplicate)
+	assert.Empty(t, book.Error)
+
+	broken := byFile[filepath.Join(root, "broken.epub")]
+	assert.False(t, broken.Duplicate)
+	assert.NotEmpty(t, broken.Error)
+
+	// Only duplicates are listed with -duplicates, and roots that cannot be
+	// scanned fail the command even with -json.
+	stdout, stderr, err := runCommand(t, runList, "-json", "-duplicates", root, filepath.Join(root, "missing"))
+	assert.Equal(t, errFailed, err)
+	assert.Contains(t, stderr, "Could not scan ")
+
+	require.NoError(t, json.Unmarshal([]byte(stdout), &entries))
+	if assert.Len(t, entries, 2) {
+		assert.True(t, entries[0].Duplicate)
+		assert.True(t, entries[1].Duplicate)
+	}
+}
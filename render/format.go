@@ -0,0 +1,218 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Format determines how rendered text is encoded.
+type Format int
+
+const (
+	// FormatTview encodes styles as tview style tags, for display within a
+	// tview.TextView.
+	FormatTview Format = iota
+
+	// FormatPlain omits styles altogether.
+	FormatPlain
+
+	// FormatANSI encodes styles as ANSI escape sequences, for display within a
+	// terminal.
+	FormatANSI
+
+	// FormatMarkdown converts the document structure (e.g. headings, emphasis,
+	// lists, links, and tables) to Markdown. Text is not wrapped.
+	FormatMarkdown
+)
+
+// FormatNames holds the written names of formats. Useful to echo back a format
+// name, or to look up a format from a string value.
+var FormatNames = map[Format]string{
+	FormatTview:    "tview",
+	FormatPlain:    "plain",
+	FormatANSI:     "ansi",
+	FormatMarkdown: "markdown",
+}
+
+// formatAliases holds abbreviated format names.
+var formatAliases = map[string]Format{
+	"md":   FormatMarkdown,
+	"text": FormatPlain,
+	"txt":  FormatPlain,
+}
+
+// ParseFormat looks up a format by name or abbreviation.
+func ParseFormat(name string) (Format, error) {
+	if format, ok := formatAliases[strings.ToLower(name)]; ok {
+		return format, nil
+	}
+
+	for format, formatName := range FormatNames {
+		if strings.EqualFold(name, formatName) {
+			return format, nil
+		}
+	}
+
+	return FormatTview, fmt.Errorf("render: unrecognized format \"%s\"", name)
+}
+
+// tagPattern matches the style tags written by Renderer.tviewStyle, i.e.
+// [foreground:background:attributes].
+var tagPattern = regexp.MustCompile(`\[([a-zA-Z0-9#-]*):([a-zA-Z0-9#-]*):([a-zA-Z-]*)\]`)
+
+// tagWriter wraps an io.Writer. It translates tview style tags into either
+// ANSI escape sequences or nothing at all. Tags must not be split across
+// writes.
+type tagWriter struct {
+	w     io.Writer
+	ansi  bool
+	style tcell.Style
+}
+
+func newTagWriter(w io.Writer, ansi bool) *tagWriter {
+	return &tagWriter{
+		w:     w,
+		ansi:  ansi,
+		style: tcell.StyleDefault,
+	}
+}
+
+// Write implements io.Writer.
+func (w *tagWriter) Write(p []byte) (int, error) {
+	var b strings.Builder
+	text := string(p)
+
+	start := 0
+	for _, loc := range tagPattern.FindAllStringSubmatchIndex(text, -1) {
+		b.WriteString(tview.Unescape(text[start:loc[0]]))
+		if style := applyTag(w.style, text[loc[2]:loc[3]], text[loc[4]:loc[5]], text[loc[6]:loc[7]]); w.ansi && style != w.style {
+			w.style = style
+			b.WriteString(sgr(w.style))
+		}
+		start = loc[1]
+	}
+	b.WriteString(tview.Unescape(text[start:]))
+
+	if _, err := io.WriteString(w.w, b.String()); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Close resets the terminal style, if it was changed.
+func (w *tagWriter) Close() error {
+	if w.ansi && w.style != tcell.StyleDefault {
+		w.style = tcell.StyleDefault
+		_, err := io.WriteString(w.w, sgr(w.style))
+
+		return err
+	}
+
+	return nil
+}
+
+// tagAttrs maps tview attribute flags to tcell attributes.
+var tagAttrs = map[rune]tcell.AttrMask{
+	'b': tcell.AttrBold,
+	'i': tcell.AttrItalic,
+	's': tcell.AttrStrikeThrough,
+	'u': tcell.AttrUnderline,
+	'd': tcell.AttrDim,
+	'l': tcell.AttrBlink,
+	'r': tcell.AttrReverse,
+}
+
+// applyTag updates a style with the components of a tview style tag. Empty
+// components leave the style unchanged, and "-" resets it.
+func applyTag(style tcell.Style, fg, bg, attrs string) tcell.Style {
+	switch fg {
+	case "":
+	case "-":
+		style = style.Foreground(tcell.ColorDefault)
+	default:
+		style = style.Foreground(tcell.GetColor(fg))
+	}
+
+	switch bg {
+	case "":
+	case "-":
+		style = style.Background(tcell.ColorDefault)
+	default:
+		style = style.Background(tcell.GetColor(bg))
+	}
+
+	_, _, mask := style.Decompose()
+	for _, flag := range attrs {
+		if flag == '-' {
+			mask = tcell.AttrNone
+		} else if a, ok := tagAttrs[flag]; ok {
+			mask |= a
+		} else if a, ok := tagAttrs[flag-'A'+'a']; ok {
+			mask &^= a
+		}
+	}
+
+	return style.Attributes(mask)
+}
+
+// sgr returns the ANSI "select graphic rendition" escape sequence for a style.
+func sgr(style tcell.Style) string {
+	fg, bg, mask := style.Decompose()
+	codes := []string{"0"}
+
+	for _, attr := range []struct {
+		mask tcell.AttrMask
+		code string
+	}{
+		{tcell.AttrBold, "1"},
+		{tcell.AttrDim, "2"},
+		{tcell.AttrItalic, "3"},
+		{tcell.AttrUnderline, "4"},
+		{tcell.AttrBlink, "5"},
+		{tcell.AttrReverse, "7"},
+		{tcell.AttrStrikeThrough, "9"},
+	} {
+		if mask&attr.mask != 0 {
+			codes = append(codes, attr.code)
+		}
+	}
+
+	if code := colorCode(fg, 30); code != "" {
+		codes = append(codes, code)
+	}
+
+	if code := colorCode(bg, 40); code != "" {
+		codes = append(codes, code)
+	}
+
+	return fmt.Sprintf("\x1b[%sm", strings.Join(codes, ";"))
+}
+
+// colorCode returns the SGR parameters that select a color. The base is 30
+// for foreground colors and 40 for background colors.
+func colorCode(c tcell.Color, base int) string {
+	if !c.Valid() {
+		return ""
+	}
+
+	if c.IsRGB() {
+		r, g, b := c.RGB()
+		return fmt.Sprintf("%d;2;%d;%d;%d", base+8, r, g, b)
+	}
+
+	index := int(c - tcell.ColorValid)
+	switch {
+	case index < 8:
+		return fmt.Sprint(base + index)
+	case index < 16:
+		return fmt.Sprint(base + 60 + index - 8)
+	default:
+		return fmt.Sprintf("%d;5;%d", base+8, index)
+	}
+}
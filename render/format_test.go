@@ -0,0 +1,122 @@
+package render
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTagWriter(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		ansi     bool
+		input    string
+		expected string
+	}{
+		{
+			name:     "plain",
+			input:    "[red:-:b]Down [the[][-:-:-] Rabbit-Hole",
+			expected: "Down [the] Rabbit-Hole",
+		},
+		{
+			name:     "ansi",
+			ansi:     true,
+			input:    "[maroon:-:bi]Down [::I]the[-:-:-] Rabbit-Hole",
+			expected: "\x1b[0;1;3;31mDown \x1b[0;1;31mthe\x1b[0m Rabbit-Hole",
+		},
+		{
+			name:     "ansi rgb",
+			ansi:     true,
+			input:    "[#ff8000:navy:u]Alice",
+			expected: "\x1b[0;4;38;2;255;128;0;44mAlice\x1b[0m",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var b strings.Builder
+			w := newTagWriter(&b, tc.ansi)
+			_, err := w.Write([]byte(tc.input))
+			assert.NoError(t, err)
+			assert.NoError(t, w.Close())
+			assert.Equal(t, tc.expected, b.String())
+		})
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	input := `<html><head><title>Chapter I</title><style>p {}</style></head><body>
+<h1>Down the <em>Rabbit</em>-Hole</h1>
+<p>Alice was <b>beginning</b> to get <i>very tired </i>of sitting by her
+sister on the <a href="bank.xhtml#bank">bank</a>.<br/>
+*Once or twice*</p>
+<ul><li>one</li><li>two<ol><li>nested</li></ol></li></ul>
+<blockquote><p>"and what is the use of a book," thought Alice</p></blockquote>
+<table><tr><th>Name</th><th>Page</th></tr><tr><td>Alice | Rabbit</td><td>1</td></tr></table>
+<p><img src="../images/alice.png" alt="Alice"/></p>
+</body></html>`
+
+	expected := "# Down the *Rabbit*-Hole\n" +
+		"\n" +
+		"Alice was **beginning** to get *very tired* of sitting by her sister on the [bank](bank.xhtml#bank).\\\n" +
+		"\\*Once or twice\\*\n" +
+		"\n" +
+		"- one\n" +
+		"- two\n" +
+		"  1. nested\n" +
+		"\n" +
+		"> \"and what is the use of a book,\" thought Alice\n" +
+		"\n" +
+		"| Name | Page |\n" +
+		"| --- | --- |\n" +
+		"| Alice \\| Rabbit | 1 |\n" +
+		"\n" +
+		"![Alice](../images/alice.png)\n"
+
+	var b strings.Builder
+	if assert.NoError(t, renderMarkdown(strings.NewReader(input), &b, nil, 0)) {
+		assert.Equal(t, expected, b.String())
+	}
+}
+
+func TestMarkdownLinks(t *testing.T) {
+	rc := openAlice(t)
+	book := rc.DefaultRendition()
+
+	export := func(first, last int) string {
+		r := New(&book.Package)
+		r.SetFormat(FormatMarkdown)
+
+		var b strings.Builder
+		require.NoError(t, r.RenderChapters(context.Background(), first, last, &b))
+
+		return b.String()
+	}
+
+	// Links between exported chapters point to anchors within the export.
+	text := export(1, 2)
+	assert.Contains(t, text, "[Frontispiece](#chapter-2-front)")
+	assert.Contains(t, text, "[13](#chapter-3-Page_13)")
+	assert.Equal(t, 1, strings.Count(text, `<a id="chapter-2-front"></a>`))
+	assert.Equal(t, 1, strings.Count(text, `<a id="chapter-3-Page_13"></a>`))
+
+	// Links to chapters that are not exported are removed, keeping their text.
+	text = export(1, 1)
+	assert.Contains(t, text, "[Frontispiece](#chapter-2-front)")
+	assert.NotContains(t, text, "chapter-3")
+	assert.NotContains(t, text, ".xhtml")
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("Markdown")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatMarkdown, format)
+
+	format, err = ParseFormat("md")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatMarkdown, format)
+
+	_, err = ParseFormat("pdf")
+	assert.Error(t, err)
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"image"
 	"image/color"
-	"path"
 	"sort"
 
 	"github.com/nfnt/resize"
@@ -43,27 +42,27 @@ func (r *Renderer) handleImage(token html.Token) error {
 
 // handleImageSrc reads a referenced image and renders it to the parser buffer.
 func (r *Renderer) handleImageSrc(href string) error {
+	if r.hideImages {
+		return nil
+	}
+
 	if r.parser.writeTarget() != r.parser.writer {
 		// NOTE: rendering images inside tables is not supported at the moment as
 		// this would add a lot of complexity.
 		return nil
 	}
 
-	if !path.IsAbs(href) {
-		href = path.Join(r.parser.basepath, href)
+	item, _ := r.content.ResolveItem(r.parser.item, href)
+	if item == nil {
+		return nil
 	}
 
-	for _, item := range r.content.Items {
-		if item.HREF == href {
-			for _, line := range imageToText(item, r.width) {
-				r.parser.ensureNewlines(1)
-				if err := r.appendText(line); err != nil {
-					return err
-				}
-				r.parser.ensureNewlines(1)
-			}
-			break
+	for _, line := range imageToText(*item, r.width) {
+		r.parser.ensureNewlines(1)
+		if err := r.appendText(line); err != nil {
+			return err
 		}
+		r.parser.ensureNewlines(1)
 	}
 
 	return nil
@@ -0,0 +1,246 @@
+package render
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/rivo/tview"
+	"github.com/taylorskalyo/goreader/config"
+)
+
+// Text within a chapter is located by offset: the number of characters of
+// source text that precede it, not counting whitespace. Unlike lines and
+// columns, offsets do not change when text is wrapped differently, so they
+// can be stored and then located again at any width or with any theme.
+
+// Highlight styles a passage of a chapter, from the character at offset Start
+// up to the character at offset End.
+type Highlight struct {
+	Chapter    int
+	Start, End int
+	Style      config.Style
+}
+
+// textRun is a run of characters with consecutive offsets that were rendered
+// side by side on a line, each of the given width. Runs end at whitespace, so
+// each starts a word or continues one from the previous line.
+type textRun struct {
+	line, col int
+	offset    int
+	n         int
+	width     int
+}
+
+// SetHighlights sets the passages that are highlighted when chapters are
+// rendered.
+func (r *Renderer) SetHighlights(highlights []Highlight) {
+	r.highlights = highlights
+}
+
+// Length returns the number of characters of source text, not counting
+// whitespace, in the most recently rendered chapter.
+func (r Renderer) Length() int {
+	return len(r.parser.index)
+}
+
+// Text returns the source text of the most recently rendered chapter from the
+// character at offset start up to the character at offset end, with
+// whitespace collapsed.
+func (r Renderer) Text(start, end int) string {
+	if start < 0 {
+		start = 0
+	}
+	if end > len(r.parser.index) {
+		end = len(r.parser.index)
+	}
+	if start >= end {
+		return ""
+	}
+
+	text := r.parser.source[r.parser.index[start] : r.parser.index[end-1]+1]
+
+	return strings.Join(strings.Fields(string(text)), " ")
+}
+
+// Locate returns the line and column of the most recently rendered chapter at
+// which the character at an offset was rendered. Text within tables cannot be
+// located.
+func (r Renderer) Locate(offset int) (line, col int, ok bool) {
+	runs := r.runs()
+	i := sort.Search(len(runs), func(i int) bool {
+		return runs[i].offset+runs[i].n > offset
+	})
+	if i == len(runs) || runs[i].offset > offset {
+		return 0, 0, false
+	}
+
+	run := runs[i]
+
+	return run.line, run.col + (offset-run.offset)*run.width, true
+}
+
+// OffsetAt returns the offset of the character rendered nearest to a column
+// of a line of the most recently rendered chapter. It reports false if there
+// is no source text on the line.
+func (r Renderer) OffsetAt(line, col int) (int, bool) {
+	runs := r.runs()
+	i := sort.Search(len(runs), func(i int) bool {
+		return runs[i].line >= line
+	})
+
+	offset, distance := 0, -1
+	for ; i < len(runs) && runs[i].line == line; i++ {
+		run := runs[i]
+		o, d := run.offset, run.col-col
+		if end := run.col + run.n*run.width; col >= end {
+			o, d = run.offset+run.n-1, col-end+1
+		} else if col >= run.col {
+			o, d = run.offset+(col-run.col)/atLeast(run.width, 1), 0
+		}
+
+		if distance < 0 || d < distance {
+			offset, distance = o, d
+		}
+	}
+
+	return offset, distance >= 0
+}
+
+// Word returns the offset of the start of a word n words after the one at
+// offset, or before it if n is negative. Counting backward starts from the
+// start of the word at offset.
+func (r Renderer) Word(offset, n int) int {
+	runs := r.runs()
+	if len(runs) == 0 {
+		return offset
+	}
+
+	i := sort.Search(len(runs), func(i int) bool {
+		return runs[i].offset+runs[i].n > offset
+	})
+	if i == len(runs) {
+		i--
+	}
+	if n < 0 && offset > runs[i].offset {
+		n++
+	}
+
+	i += n
+	if i < 0 {
+		i = 0
+	} else if i >= len(runs) {
+		i = len(runs) - 1
+	}
+
+	return runs[i].offset
+}
+
+// runs returns where the source text of the most recently rendered chapter was
+// placed.
+func (r Renderer) runs() []textRun {
+	if r.parser.writer == nil {
+		return nil
+	}
+
+	return r.parser.writer.runs
+}
+
+// appendSource appends source text in the given style, recording its offsets
+// and highlighting any part of it that is within a highlighted passage.
+func (r *Renderer) appendSource(text string, style config.Style) error {
+	if !hasText(text) {
+		return nil
+	}
+
+	p := &r.parser
+	breaks := p.pendingBreaks()
+	if strings.Contains(breaks, "\n") && len(p.source) > 0 {
+		p.source = append(p.source, '\n')
+	}
+	if _, err := io.WriteString(p.writeTarget(), breaks); err != nil {
+		return err
+	}
+
+	var segment strings.Builder
+	highlight, start := -1, p.offset()
+	for _, c := range text {
+		space := unicode.IsSpace(c)
+		if h := p.highlightAt(p.offset(), space); h != highlight {
+			if err := r.appendSegment(segment.String(), start, highlight, style); err != nil {
+				return err
+			}
+			segment.Reset()
+			highlight, start = h, p.offset()
+		}
+
+		segment.WriteRune(c)
+		if !space {
+			p.index = append(p.index, len(p.source))
+		}
+		p.source = append(p.source, c)
+	}
+
+	return r.appendSegment(segment.String(), start, highlight, style)
+}
+
+// appendSegment appends source text starting at an offset, in the style of
+// the highlight with the given index, if any. Text within tables is not
+// highlighted.
+func (r *Renderer) appendSegment(text string, offset, highlight int, style config.Style) error {
+	if text == "" {
+		return nil
+	}
+
+	p := &r.parser
+	if p.writeTarget() != p.writer {
+		_, err := io.WriteString(p.writeTarget(), tview.Escape(text))
+		return err
+	}
+
+	if highlight >= 0 {
+		hl := style.Merge(p.highlights[highlight].Style)
+		if _, err := io.WriteString(p.writer, hl.String()); err != nil {
+			return err
+		}
+	}
+
+	if err := p.writer.writeSource(tview.Escape(text), offset); err != nil {
+		return err
+	}
+
+	if highlight >= 0 {
+		_, err := io.WriteString(p.writer, style.String())
+		return err
+	}
+
+	return nil
+}
+
+// offset returns the offset of the next character of source text.
+func (p *parser) offset() int {
+	return len(p.index)
+}
+
+// highlightAt returns the index of the first highlight that covers the
+// character at offset, or -1 if there is none. Whitespace is highlighted only
+// if the characters on both sides of it are.
+func (p *parser) highlightAt(offset int, space bool) int {
+	for i, h := range p.highlights {
+		if (h.Start < offset || (h.Start == offset && !space)) && offset < h.End {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// atLeast returns n, or min if n is less.
+func atLeast(n, min int) int {
+	if n < min {
+		return min
+	}
+
+	return n
+}
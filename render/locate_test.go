@@ -0,0 +1,99 @@
+package render
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taylorskalyo/goreader/config"
+	"github.com/taylorskalyo/goreader/epub"
+)
+
+func openAlice(t *testing.T) *epub.ReadCloser {
+	t.Helper()
+
+	rc, err := epub.OpenReader("../epub/_test_files/alice.epub")
+	require.NoError(t, err)
+	t.Cleanup(func() { rc.Close() })
+
+	return rc
+}
+
+func TestLocate(t *testing.T) {
+	rc := openAlice(t)
+	book := rc.DefaultRendition()
+
+	locate := func(width int, word string) (line, col, offset int) {
+		r := New(&book.Package)
+		r.SetWidth(width)
+
+		var b strings.Builder
+		require.NoError(t, r.RenderChapter(context.Background(), 2, &b))
+		lines := strings.Split(b.String(), "\n")
+
+		n := len(strings.Join(strings.Fields(word), ""))
+		for offset = 0; offset < r.Length(); offset++ {
+			if r.Text(offset, offset+n) == word {
+				break
+			}
+		}
+		require.Less(t, offset, r.Length(), "%s not found", word)
+
+		line, col, ok := r.Locate(offset)
+		require.True(t, ok)
+		assert.Equal(t, word, plainText(lines[line])[col:col+len(word)])
+
+		back, ok := r.OffsetAt(line, col)
+		assert.True(t, ok)
+		assert.Equal(t, offset, back)
+
+		return line, col, offset
+	}
+
+	// Offsets are the same however text is wrapped, though lines are not.
+	narrowLine, narrowCol, narrow := locate(40, "White Rabbit")
+	wideLine, wideCol, wide := locate(100, "White Rabbit")
+	assert.Equal(t, narrow, wide)
+	assert.NotEqual(t, []int{narrowLine, narrowCol}, []int{wideLine, wideCol})
+}
+
+func TestHighlight(t *testing.T) {
+	rc := openAlice(t)
+	book := rc.DefaultRendition()
+
+	r := New(&book.Package)
+	var b strings.Builder
+	require.NoError(t, r.RenderChapter(context.Background(), 2, &b))
+	start := strings.Index(string(r.parser.source), "was beginning to")
+	require.GreaterOrEqual(t, start, 0)
+
+	// Find the offset of the first character of the passage.
+	offset := 0
+	for offset < r.Length() && r.parser.index[offset] < start {
+		offset++
+	}
+	assert.Equal(t, "was beginning to", r.Text(offset, offset+len("wasbeginningto")))
+
+	yellow := "yellow"
+	r.SetHighlights([]Highlight{{
+		Chapter: 2,
+		Start:   offset,
+		End:     offset + len("wasbeginning"),
+		Style:   config.Style{Background: &yellow},
+	}})
+	b.Reset()
+	require.NoError(t, r.RenderChapter(context.Background(), 2, &b))
+	assert.Regexp(t, `\[-:yellow:[bisuBISU]*\]was beginning\[-:-:[bisuBISU]*\] to`, b.String())
+}
+
+// plainText removes style tags from rendered text.
+func plainText(text string) string {
+	var b strings.Builder
+	w := newTagWriter(&b, false)
+	_, _ = w.Write([]byte(text))
+	_ = w.Close()
+
+	return b.String()
+}
@@ -0,0 +1,472 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/taylorskalyo/goreader/epub"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// markdownEscaper escapes characters that would otherwise be read as inline
+// Markdown syntax.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"*", `\*`,
+	"_", `\_`,
+	"`", "\\`",
+)
+
+// reBlockPrefix matches text that would otherwise be read as the start of a
+// heading, quote, or list.
+var reBlockPrefix = regexp.MustCompile(`^([#>+-]|\d+\.)( |$)`)
+
+// reParagraphBreak matches two or more consecutive hard line breaks.
+var reParagraphBreak = regexp.MustCompile(`(?:\\\n[\t ]*){2,}`)
+
+// blockAtoms are elements that start a new Markdown block.
+var blockAtoms = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true,
+	atom.Blockquote: true, atom.Body: true, atom.Dd: true, atom.Div: true,
+	atom.Dl: true, atom.Dt: true, atom.Figcaption: true, atom.Figure: true,
+	atom.Footer: true, atom.H1: true, atom.H2: true, atom.H3: true,
+	atom.H4: true, atom.H5: true, atom.H6: true, atom.Head: true,
+	atom.Header: true, atom.Hr: true, atom.Html: true, atom.Li: true,
+	atom.Main: true, atom.Nav: true, atom.Ol: true, atom.P: true,
+	atom.Pre: true, atom.Script: true, atom.Section: true, atom.Style: true,
+	atom.Table: true, atom.Title: true, atom.Ul: true,
+}
+
+// markdownLinks rewrites links between the chapters of a Markdown export, so
+// that they point within the exported document rather than to files that are
+// not part of it.
+type markdownLinks struct {
+	content     *epub.Package
+	first, last int
+
+	// targets holds the anchors that links in the export point to, and placed
+	// those that have been written.
+	targets map[string]bool
+	placed  map[string]bool
+}
+
+// newMarkdownLinks finds the targets of links within an inclusive range of
+// chapters.
+func newMarkdownLinks(content *epub.Package, first, last int) (*markdownLinks, error) {
+	l := &markdownLinks{
+		content: content,
+		first:   first,
+		last:    last,
+		targets: map[string]bool{},
+		placed:  map[string]bool{},
+	}
+	for chapter := first; chapter <= last; chapter++ {
+		item := content.Spine.Itemrefs[chapter].Item
+		doc, err := parseItem(item)
+		if err != nil {
+			return nil, err
+		}
+
+		walkElements(doc, func(n *html.Node) {
+			if n.DataAtom != atom.A {
+				return
+			}
+
+			if anchor, ok := l.anchor(item, attr(n, "href")); ok {
+				l.targets[anchor] = true
+			}
+		})
+	}
+
+	return l, nil
+}
+
+// anchor returns the anchor in the export that an href found within item
+// points to. It returns false if the href does not point to an exported
+// chapter.
+func (l *markdownLinks) anchor(from *epub.Item, href string) (string, bool) {
+	item, fragment := l.content.ResolveItem(from, href)
+	chapter := l.content.Spine.Index(item)
+	if chapter < l.first || chapter > l.last {
+		return "", false
+	}
+
+	return chapterAnchor(chapter, fragment), true
+}
+
+// rewrite points the links in a chapter's document at anchors in the export,
+// and marks the elements that links point to with anchors. Links to anything
+// else within the epub are removed, leaving their text, since the files they
+// point to are not exported.
+func (l *markdownLinks) rewrite(doc *html.Node, chapter int) {
+	item := l.content.Spine.Itemrefs[chapter].Item
+	walkElements(doc, func(n *html.Node) {
+		if n.DataAtom == atom.Body {
+			l.place(n, chapterAnchor(chapter, ""))
+		}
+
+		for i, a := range n.Attr {
+			// Legacy documents use named anchors rather than IDs.
+			if a.Key == "id" || (a.Key == "name" && n.DataAtom == atom.A) {
+				l.place(n, chapterAnchor(chapter, a.Val))
+			}
+
+			if a.Key != "href" || n.DataAtom != atom.A || epub.IsExternal(a.Val) {
+				continue
+			}
+
+			if anchor, ok := l.anchor(item, a.Val); ok {
+				n.Attr[i].Val = (&url.URL{Fragment: anchor}).String()
+			} else {
+				n.Attr[i].Val = ""
+			}
+		}
+	})
+}
+
+// chapterAnchor returns the name of the anchor in an export for an element of
+// a chapter, or for the start of the chapter if id is empty.
+func chapterAnchor(chapter int, id string) string {
+	if id == "" {
+		return fmt.Sprintf("chapter-%d", chapter+1)
+	}
+
+	return fmt.Sprintf("chapter-%d-%s", chapter+1, id)
+}
+
+// place adds an html anchor to the start of n, if a link points to it and it
+// has not already been placed. Markdown has no syntax of its own for anchors.
+func (l *markdownLinks) place(n *html.Node, name string) {
+	if !l.targets[name] || l.placed[name] {
+		return
+	}
+	l.placed[name] = true
+
+	n.InsertBefore(&html.Node{
+		Type: html.RawNode,
+		Data: fmt.Sprintf(`<a id="%s"></a>`, html.EscapeString(name)),
+	}, n.FirstChild)
+}
+
+// walkElements calls fn for each element in an html document, in document
+// order.
+func walkElements(n *html.Node, fn func(*html.Node)) {
+	if n.Type == html.ElementNode {
+		fn(n)
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walkElements(c, fn)
+	}
+}
+
+// parseItem parses an html document stored in the epub.
+func parseItem(item *epub.Item) (*html.Node, error) {
+	r, err := item.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return html.Parse(r)
+}
+
+// renderMarkdown converts an html document to Markdown and writes it to w. If
+// links is not nil, links between chapters are rewritten to point within the
+// export.
+func renderMarkdown(r io.Reader, w io.Writer, links *markdownLinks, chapter int) error {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return err
+	}
+
+	if links != nil {
+		links.rewrite(doc, chapter)
+	}
+
+	text := strings.Join(markdownBlocks(doc), "\n\n")
+	if text == "" {
+		return nil
+	}
+
+	_, err = io.WriteString(w, text+"\n")
+
+	return err
+}
+
+// markdownBlocks converts the children of an html node to Markdown blocks.
+// Consecutive inline children are grouped into a single paragraph.
+func markdownBlocks(n *html.Node) []string {
+	var blocks []string
+	var para strings.Builder
+
+	flush := func() {
+		// Runs of line breaks are commonly used in place of paragraphs.
+		for _, text := range reParagraphBreak.Split(para.String(), -1) {
+			text = wsRemoveSurroundLF(wsTransformSpace(text))
+			if text = trimLineBreaks(text); text != "" {
+				blocks = append(blocks, text)
+			}
+		}
+		para.Reset()
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && blockAtoms[c.DataAtom] {
+			flush()
+			blocks = append(blocks, markdownBlock(c)...)
+		} else {
+			para.WriteString(markdownInline(c))
+		}
+	}
+	flush()
+
+	return blocks
+}
+
+// markdownBlock converts a block-level html element to Markdown blocks.
+func markdownBlock(n *html.Node) []string {
+	switch n.DataAtom {
+	case atom.Head, atom.Script, atom.Style, atom.Title:
+		return nil
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		text := markdownLine(markdownInlineChildren(n))
+		if text == "" {
+			return nil
+		}
+		level := int(n.Data[1] - '0')
+
+		return []string{strings.Repeat("#", level) + " " + text}
+	case atom.Hr:
+		return []string{"---"}
+	case atom.Pre:
+		return []string{"```\n" + strings.Trim(textContent(n), "\n") + "\n```"}
+	case atom.Blockquote:
+		return []string{prefixLines(strings.Join(markdownBlocks(n), "\n\n"), "> ", ">")}
+	case atom.Ul, atom.Ol:
+		return []string{markdownList(n)}
+	case atom.Table:
+		if table := markdownTable(n); table != "" {
+			return []string{table}
+		}
+
+		return nil
+	}
+
+	return markdownBlocks(n)
+}
+
+// markdownList converts an ordered or unordered list to Markdown. Nested
+// blocks are indented to line up with the text of their list item.
+func markdownList(n *html.Node) string {
+	var items []string
+	number := 1
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.DataAtom != atom.Li {
+			continue
+		}
+
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = fmt.Sprintf("%d. ", number)
+			number++
+		}
+
+		text := strings.Join(markdownBlocks(c), "\n")
+		indent := strings.Repeat(" ", len(marker))
+		items = append(items, marker+strings.TrimPrefix(prefixLines(text, indent, ""), indent))
+	}
+
+	return strings.Join(items, "\n")
+}
+
+// markdownTable converts a table to a GitHub Flavored Markdown table. The first
+// row is used as the header.
+func markdownTable(n *html.Node) string {
+	var rows [][]string
+	columns := 0
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+
+			switch c.DataAtom {
+			case atom.Table:
+				// Nested tables cannot be represented; flatten them into cells.
+			case atom.Tr:
+				var row []string
+				for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.DataAtom == atom.Td || cell.DataAtom == atom.Th {
+						text := markdownLine(strings.Join(markdownBlocks(cell), " "))
+						row = append(row, strings.ReplaceAll(text, "|", `\|`))
+					}
+				}
+				if len(row) > columns {
+					columns = len(row)
+				}
+				rows = append(rows, row)
+			default:
+				walk(c)
+			}
+		}
+	}
+	walk(n)
+
+	if columns == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+
+		if i == 0 {
+			b.WriteString(strings.Repeat("| --- ", columns) + "|\n")
+		}
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// markdownInline converts an inline html node to Markdown.
+func markdownInline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		text := markdownEscaper.Replace(processWhitespace(n.Data))
+		if n.PrevSibling == nil {
+			text = reBlockPrefix.ReplaceAllString(text, `\$1$2`)
+		}
+
+		return text
+	case html.RawNode:
+		return n.Data
+	case html.ElementNode:
+	default:
+		return ""
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		return "\\\n"
+	case atom.Img:
+		return fmt.Sprintf("![%s](%s)", attr(n, "alt"), attr(n, "src"))
+	case atom.Em, atom.I, atom.Cite, atom.Var:
+		return emphasize(markdownInlineChildren(n), "*")
+	case atom.Strong, atom.B:
+		return emphasize(markdownInlineChildren(n), "**")
+	case atom.Code, atom.Kbd, atom.Samp, atom.Tt:
+		return emphasize(processWhitespace(textContent(n)), "`")
+	case atom.A:
+		text := markdownInlineChildren(n)
+		href := attr(n, "href")
+		if href == "" || strings.TrimSpace(text) == "" {
+			return text
+		}
+
+		return fmt.Sprintf("[%s](%s)", strings.TrimSpace(text), href)
+	case atom.Script, atom.Style:
+		return ""
+	}
+
+	return markdownInlineChildren(n)
+}
+
+// markdownInlineChildren converts the children of an html node to inline
+// Markdown.
+func markdownInlineChildren(n *html.Node) string {
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(markdownInline(c))
+	}
+
+	return b.String()
+}
+
+// emphasize wraps text in the given delimiter. Surrounding whitespace is kept
+// outside of the delimiters, where Markdown expects it.
+func emphasize(text, delim string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+
+	start := strings.Index(text, trimmed)
+	end := start + len(trimmed)
+
+	return text[:start] + delim + trimmed + delim + text[end:]
+}
+
+// textContent returns the text of an html node and all of its descendants.
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textContent(c))
+	}
+
+	return b.String()
+}
+
+// attr returns the value of an html node's attribute.
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+
+	return ""
+}
+
+// markdownLine joins the lines of Markdown text, including those ended by hard
+// line breaks, with spaces.
+func markdownLine(text string) string {
+	return strings.TrimSpace(wsTransformSpace(strings.ReplaceAll(text, "\\\n", " ")))
+}
+
+// trimLineBreaks removes leading and trailing whitespace and hard line breaks.
+func trimLineBreaks(text string) string {
+	for {
+		trimmed := strings.Trim(text, " \t\r")
+		trimmed = strings.TrimPrefix(trimmed, "\n")
+		trimmed = strings.TrimSuffix(trimmed, "\n")
+		trimmed = strings.TrimPrefix(trimmed, "\\\n")
+		if trailing := len(trimmed) - len(strings.TrimRight(trimmed, "\\")); trailing%2 == 1 {
+			// An odd number of backslashes ends with a hard line break.
+			trimmed = trimmed[:len(trimmed)-1]
+		}
+		if trimmed == text {
+			return text
+		}
+		text = trimmed
+	}
+}
+
+// prefixLines prepends prefix to each non-empty line of text, and empty to
+// each empty line.
+func prefixLines(text, prefix, empty string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = empty
+		} else {
+			lines[i] = prefix + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"path"
 	"strings"
 
 	_ "image/jpeg"
@@ -26,7 +25,17 @@ type Renderer struct {
 	content *epub.Package
 	theme   config.Theme
 	width   int
+	format  Format
 	parser  parser
+
+	// hideImages is set if images should not be drawn.
+	hideImages bool
+
+	highlights []Highlight
+
+	// links rewrites links between chapters while a range of chapters is
+	// exported as Markdown.
+	links *markdownLinks
 }
 
 // parser represents the current parsing state.
@@ -40,7 +49,18 @@ type parser struct {
 	newlines  int
 	indents   int
 	writer    *wordWrapWriter
-	basepath  string
+	item      *epub.Item
+
+	// anchors maps element IDs to the line at which they were rendered.
+	anchors map[string]int
+
+	// source holds the chapter's text, and index maps each offset to the
+	// position of its character in source. See Locate.
+	source []rune
+	index  []int
+
+	// highlights are those in the chapter being rendered.
+	highlights []Highlight
 }
 
 // New returns a new epub Renderer.
@@ -57,6 +77,22 @@ func (r *Renderer) SetTheme(theme config.Theme) {
 	r.theme = theme
 }
 
+// SetWidth sets the column at which text is wrapped.
+func (r *Renderer) SetWidth(width int) {
+	r.width = width
+}
+
+// SetImages sets whether images are drawn. If not, only their alt text is
+// shown. Images are drawn by default.
+func (r *Renderer) SetImages(show bool) {
+	r.hideImages = !show
+}
+
+// SetFormat sets how rendered text is encoded. The default is FormatTview.
+func (r *Renderer) SetFormat(format Format) {
+	r.format = format
+}
+
 // RenderChapter reads in an epub item, parses the content, and writes the
 // rendered output to the given writer.
 func (r *Renderer) RenderChapter(ctx context.Context, chapter int, w io.Writer) error {
@@ -65,18 +101,78 @@ func (r *Renderer) RenderChapter(ctx context.Context, chapter int, w io.Writer)
 	if err != nil {
 		return err
 	}
+	defer doc.Close()
+
+	switch r.format {
+	case FormatMarkdown:
+		return renderMarkdown(doc, w, r.links, chapter)
+	case FormatPlain, FormatANSI:
+		tw := newTagWriter(w, r.format == FormatANSI)
+		defer tw.Close()
+		w = tw
+	}
 
 	r.parser = parser{
 		tokenizer: html.NewTokenizer(doc),
 		writer:    newWordWrapWriter(w, r.width),
-		basepath:  path.Dir(item.HREF),
+		item:      item.Item,
+		anchors:   map[string]int{},
+	}
+	for _, h := range r.highlights {
+		if h.Chapter == chapter {
+			r.parser.highlights = append(r.parser.highlights, h)
+		}
 	}
 
 	return r.render(ctx)
 }
 
+// RenderChapters renders an inclusive range of chapters, separated by blank
+// lines, and ends the output with a newline. In Markdown, links between the
+// chapters point within the output.
+func (r *Renderer) RenderChapters(ctx context.Context, first, last int, w io.Writer) error {
+	if r.format == FormatMarkdown {
+		links, err := newMarkdownLinks(r.content, first, last)
+		if err != nil {
+			return err
+		}
+
+		r.links = links
+		defer func() { r.links = nil }()
+	}
+
+	for chapter := first; chapter <= last; chapter++ {
+		if chapter > first {
+			if _, err := io.WriteString(w, "\n\n"); err != nil {
+				return err
+			}
+		}
+
+		if err := r.RenderChapter(ctx, chapter, w); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
+
+// Anchor returns the line of the most recently rendered chapter at which the
+// element with the given ID appears.
+func (r Renderer) Anchor(id string) (int, bool) {
+	line, ok := r.parser.anchors[id]
+
+	return line, ok
+}
+
 // tviewStyle constructs a tview style tag based on HTML tags in the tag stack.
 func (r Renderer) tviewStyle(tags []atom.Atom) string {
+	return r.style(tags).String()
+}
+
+// style returns the style of text within the HTML tags in the tag stack.
+func (r Renderer) style(tags []atom.Atom) config.Style {
 	style := config.DefaultStyle()
 	for _, tag := range tags {
 		if s, ok := r.theme[tag.String()]; ok {
@@ -84,7 +180,7 @@ func (r Renderer) tviewStyle(tags []atom.Atom) string {
 		}
 	}
 
-	return style.String()
+	return style
 }
 
 // render walks an html document and renders elements to a writer.
@@ -134,18 +230,21 @@ func (r *Renderer) appendText(text string) error {
 		return nil
 	}
 
-	text = tview.Escape(text)
+	text = fmt.Sprintf("%s%s", r.parser.pendingBreaks(), tview.Escape(text))
+	_, err := io.WriteString(r.parser.writeTarget(), text)
 
-	pendingLines := strings.Repeat("\n", r.parser.newlines)
-	pendingIndents := strings.Repeat(" ", r.parser.indents)
-	text = fmt.Sprintf("%s%s%s", pendingLines, pendingIndents, text)
+	return err
+}
 
-	r.parser.newlines = 0
-	r.parser.indents = 0
+// pendingBreaks returns, and then clears, the pending newlines and indents.
+func (p *parser) pendingBreaks() string {
+	pendingLines := strings.Repeat("\n", p.newlines)
+	pendingIndents := strings.Repeat(" ", p.indents)
 
-	_, err := io.WriteString(r.parser.writeTarget(), text)
+	p.newlines = 0
+	p.indents = 0
 
-	return err
+	return pendingLines + pendingIndents
 }
 
 // handleText appends text elements to the parser buffer. It filters elements
@@ -156,13 +255,13 @@ func (r *Renderer) handleText(token html.Token) error {
 		return nil
 	}
 
-	style := r.tviewStyle(r.parser.tagStack)
-	if _, err := io.WriteString(r.parser.writer, style); err != nil {
+	style := r.style(r.parser.tagStack)
+	if _, err := io.WriteString(r.parser.writer, style.String()); err != nil {
 		return err
 	}
 
 	text := processWhitespace(token.Data)
-	return r.appendText(string(text))
+	return r.appendSource(text, style)
 }
 
 // ensureNewlines ensures that there are at least this many pending newlines.
@@ -217,6 +316,8 @@ func (r *Renderer) handleStartTag(token html.Token) (err error) {
 		r.parser.cellStack = append(r.parser.cellStack, strings.Builder{}) // push cell writer
 	}
 
+	r.parser.recordAnchor(token)
+
 	return err
 }
 
@@ -257,3 +358,17 @@ func (r *Renderer) handleEndTag(token html.Token) (err error) {
 
 	return err
 }
+
+// recordAnchor remembers the line at which an element with an ID is rendered,
+// so that links to it can be followed. Elements within tables are anchored to
+// the start of the table.
+func (p *parser) recordAnchor(token html.Token) {
+	for _, a := range token.Attr {
+		// Legacy documents use named anchors rather than IDs.
+		if a.Key == "id" || (a.Key == "name" && token.DataAtom == atom.A) {
+			if _, exists := p.anchors[a.Val]; !exists {
+				p.anchors[a.Val] = p.writer.lines + p.newlines
+			}
+		}
+	}
+}
@@ -3,7 +3,9 @@ package render
 import (
 	"io"
 	"strings"
+	"unicode"
 
+	"github.com/mattn/go-runewidth"
 	"github.com/rivo/tview"
 )
 
@@ -14,6 +16,21 @@ type wordWrapWriter struct {
 	w      io.Writer
 	width  int
 	buffer strings.Builder
+
+	// lines counts the lines passed to the underlying Writer.
+	lines int
+
+	// spans are the source text in the buffer, and runs record where source
+	// text was placed once its lines were passed to the underlying Writer.
+	spans []sourceSpan
+	runs  []textRun
+}
+
+// sourceSpan is escaped source text within a wordWrapWriter's buffer, between
+// the byte indexes start and end. Offset is the offset of its first character.
+type sourceSpan struct {
+	start, end int
+	offset     int
 }
 
 func newWordWrapWriter(w io.Writer, width int) *wordWrapWriter {
@@ -26,13 +43,16 @@ func newWordWrapWriter(w io.Writer, width int) *wordWrapWriter {
 // Write implements io.Write.
 func (w *wordWrapWriter) Write(p []byte) (n int, err error) {
 	w.buffer.Write(p)
-	lines := tview.WordWrap(w.buffer.String(), w.width)
+	buffer := w.buffer.String()
+	lines := tview.WordWrap(buffer, w.width)
 
+	start := 0
 	for i, line := range lines {
 		if i == len(lines)-1 {
 			// Keep the last line in the buffer
 			w.buffer.Reset()
 			w.buffer.WriteString(line)
+			w.shiftSpans(start)
 			break
 		}
 
@@ -41,15 +61,116 @@ func (w *wordWrapWriter) Write(p []byte) (n int, err error) {
 			return n, err
 		}
 		n += nLine
+
+		w.locateLine(line, start)
+		start += len(line)
+
+		// Lines broken at a newline do not include it.
+		if strings.HasPrefix(buffer[start:], "\r\n") {
+			start += 2
+		} else if strings.HasPrefix(buffer[start:], "\n") || strings.HasPrefix(buffer[start:], "\r") {
+			start++
+		}
+		w.lines++
 	}
 
 	return len(p), nil
 }
 
+// writeSource writes escaped source text, the first character of which is at
+// the given offset, so that where it is placed can be recorded.
+func (w *wordWrapWriter) writeSource(text string, offset int) error {
+	start := w.buffer.Len()
+	w.spans = append(w.spans, sourceSpan{
+		start:  start,
+		end:    start + len(text),
+		offset: offset,
+	})
+
+	_, err := io.WriteString(w, text)
+
+	return err
+}
+
+// locateLine records where the source text within a line, which begins at the
+// given byte index of the buffer, is placed.
+func (w *wordWrapWriter) locateLine(line string, start int) {
+	end := start + len(line)
+	for i := range w.spans {
+		span := &w.spans[i]
+		if span.start >= end || span.end <= start {
+			continue
+		}
+
+		if span.start < start {
+			span.start = start
+		}
+
+		partEnd := span.end
+		if partEnd > end {
+			partEnd = end
+		}
+
+		col := tview.TaggedStringWidth(line[:span.start-start])
+		for _, r := range tview.Unescape(line[span.start-start : partEnd-start]) {
+			width := runewidth.RuneWidth(r)
+			if !unicode.IsSpace(r) {
+				w.addRun(col, span.offset, width)
+				span.offset++
+			}
+			col += width
+		}
+		span.start = partEnd
+	}
+}
+
+// addRun records that the character at offset was placed at a column of the
+// current line, extending the last run if the character continues it.
+func (w *wordWrapWriter) addRun(col, offset, width int) {
+	if n := len(w.runs); n > 0 {
+		last := &w.runs[n-1]
+		if last.line == w.lines && last.width == 1 && width == 1 &&
+			last.col+last.n == col && last.offset+last.n == offset {
+			last.n++
+			return
+		}
+	}
+
+	w.runs = append(w.runs, textRun{
+		line:   w.lines,
+		col:    col,
+		offset: offset,
+		n:      1,
+		width:  width,
+	})
+}
+
+// shiftSpans discards spans that have been passed to the underlying Writer, and
+// moves the remainder to account for the first n bytes leaving the buffer.
+func (w *wordWrapWriter) shiftSpans(n int) {
+	spans := w.spans[:0]
+	for _, span := range w.spans {
+		if span.end <= n {
+			continue
+		}
+
+		span.start -= n
+		span.end -= n
+		if span.start < 0 {
+			span.start = 0
+		}
+		spans = append(spans, span)
+	}
+	w.spans = spans
+}
+
 // Flush writes any lines remaining in the buffer.
 func (w *wordWrapWriter) Flush() error {
 	if w.buffer.Len() > 0 {
-		_, err := w.w.Write([]byte(w.buffer.String()))
+		line := w.buffer.String()
+		_, err := w.w.Write([]byte(line))
+		w.locateLine(line, 0)
+		w.spans = nil
 		w.buffer.Reset()
 
 		return err
@@ -0,0 +1,279 @@
+package state
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// BoltStore is a Store that saves reading progress in a bbolt key-value
+// database in $XDG_STATE_HOME. Unlike FileStore, which rewrites the whole
+// state file whenever progress is saved, it only writes the progress that
+// changed, which suits large libraries.
+//
+// bbolt allows only one process at a time to open a database, so it is opened
+// for each operation rather than held open. Other instances of goreader wait
+// for it to be closed.
+type BoltStore struct{}
+
+var (
+	metaBucket     = []byte("meta")
+	progressBucket = []byte("progress")
+	sessionsBucket = []byte("sessions")
+	aliasesBucket  = []byte("aliases")
+
+	// importedKey is set once progress has been imported from the JSON state
+	// file.
+	importedKey = []byte("imported")
+)
+
+// boltTimeout is how long to wait for another instance of goreader to close
+// the database.
+const boltTimeout = 10 * time.Second
+
+// LoadProgress returns the reading progress for a book.
+func (s BoltStore) LoadProgress(id string) (Progress, error) {
+	var rs Progress
+	err := s.view(func(tx *bolt.Tx) error {
+		data := tx.Bucket(progressBucket).Get([]byte(id))
+		if data == nil {
+			return os.ErrNotExist
+		}
+
+		return decodeBolt(data, &rs)
+	})
+
+	return rs, err
+}
+
+// LoadLibrary returns the reading progress of every book.
+func (s BoltStore) LoadLibrary() (map[string]Progress, error) {
+	library := map[string]Progress{}
+	err := s.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(progressBucket).ForEach(func(k, v []byte) error {
+			var rs Progress
+			if err := decodeBolt(v, &rs); err != nil {
+				return err
+			}
+			library[string(k)] = rs
+
+			return nil
+		})
+	})
+
+	return library, err
+}
+
+// StoreProgress saves the reading progress for a book, merged with any
+// progress already stored for it.
+func (s BoltStore) StoreProgress(id string, rs Progress) error {
+	return s.update(func(tx *bolt.Tx) error {
+		return putProgress(tx, id, rs)
+	})
+}
+
+// RecordSession adds a reading session to the history.
+func (s BoltStore) RecordSession(session Session) error {
+	return s.update(func(tx *bolt.Tx) error {
+		return putSession(tx, session)
+	})
+}
+
+// LoadSessions returns every recorded reading session, in the order in which
+// they started.
+func (s BoltStore) LoadSessions() ([]Session, error) {
+	sessions := []Session{}
+	err := s.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(sessionsBucket).ForEach(func(_, v []byte) error {
+			var session Session
+			if err := decodeBolt(v, &session); err != nil {
+				return err
+			}
+			sessions = append(sessions, session)
+
+			return nil
+		})
+	})
+	sortSessions(sessions)
+
+	return sessions, err
+}
+
+// LoadAliases returns the aliases by which books in the library can be found.
+func (s BoltStore) LoadAliases() (Aliases, error) {
+	aliases := Aliases{}
+	err := s.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(aliasesBucket).ForEach(func(k, v []byte) error {
+			aliases[string(k)] = string(v)
+			return nil
+		})
+	})
+
+	return aliases, err
+}
+
+// StoreAliases adds aliases, keeping any existing aliases for the same keys.
+func (s BoltStore) StoreAliases(aliases Aliases) error {
+	return s.update(func(tx *bolt.Tx) error {
+		return putAliases(tx, aliases)
+	})
+}
+
+// putAliases adds aliases within a transaction.
+func putAliases(tx *bolt.Tx, aliases Aliases) error {
+	b := tx.Bucket(aliasesBucket)
+	for key, id := range aliases {
+		if b.Get([]byte(key)) != nil {
+			continue
+		}
+
+		if err := b.Put([]byte(key), []byte(id)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// putProgress stores progress for a book within a transaction.
+func putProgress(tx *bolt.Tx, id string, rs Progress) error {
+	b := tx.Bucket(progressBucket)
+	if data := b.Get([]byte(id)); data != nil {
+		var stored Progress
+		if err := decodeBolt(data, &stored); err == nil {
+			rs = merge(stored, rs)
+		}
+	}
+
+	data, err := json.Marshal(rs)
+	if err != nil {
+		return err
+	}
+
+	return b.Put([]byte(id), data)
+}
+
+// putSession adds a session to the history within a transaction. Sessions are
+// keyed by the order in which they were recorded.
+func putSession(tx *bolt.Tx, session Session) error {
+	b := tx.Bucket(sessionsBucket)
+	seq, err := b.NextSequence()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+
+	return b.Put(key, data)
+}
+
+// decodeBolt decodes a value stored in the database.
+func decodeBolt(data []byte, v any) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrCorrupt, boltFile, err)
+	}
+
+	return nil
+}
+
+// view runs a read-only transaction.
+func (s BoltStore) view(fn func(tx *bolt.Tx) error) error {
+	db, err := openBolt()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.View(fn)
+}
+
+// update runs a read-write transaction.
+func (s BoltStore) update(fn func(tx *bolt.Tx) error) error {
+	db, err := openBolt()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(fn)
+}
+
+// openBolt opens the database, creating it if necessary. A new database is
+// filled with the progress and sessions saved by FileStore, so that switching
+// backends does not lose them.
+func openBolt() (*bolt.DB, error) {
+	if err := os.MkdirAll(appStateDir, 0700); err != nil {
+		return nil, err
+	}
+
+	db, err := bolt.Open(boltFile, 0644, &bolt.Options{Timeout: boltTimeout})
+	if err != nil {
+		return nil, err
+	}
+
+	var initialized bool
+	err = db.View(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(metaBucket)
+		initialized = meta != nil && meta.Get(importedKey) != nil &&
+			tx.Bucket(aliasesBucket) != nil
+
+		return nil
+	})
+	if err == nil && !initialized {
+		err = db.Update(initBolt)
+	}
+
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// initBolt creates the database's buckets and imports the progress and
+// sessions saved by FileStore, if any. The JSON files are left in place.
+func initBolt(tx *bolt.Tx) error {
+	for _, name := range [][]byte{metaBucket, progressBucket, sessionsBucket, aliasesBucket} {
+		if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+			return err
+		}
+	}
+
+	if tx.Bucket(metaBucket).Get(importedKey) != nil {
+		return nil
+	}
+
+	// A JSON state file that cannot be read is not imported, but is not
+	// reason enough to stop using the database either.
+	library, _ := FileStore{}.LoadLibrary()
+	for id, rs := range library {
+		if err := putProgress(tx, id, rs); err != nil {
+			return err
+		}
+	}
+
+	aliases, _ := FileStore{}.LoadAliases()
+	if err := putAliases(tx, aliases); err != nil {
+		return err
+	}
+
+	sessions, _ := FileStore{}.LoadSessions()
+	for _, session := range sessions {
+		if err := putSession(tx, session); err != nil {
+			return err
+		}
+	}
+
+	return tx.Bucket(metaBucket).Put(importedKey, []byte(time.Now().Format(time.RFC3339)))
+}
@@ -0,0 +1,141 @@
+package state
+
+import (
+	"crypto/md5"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/taylorskalyo/goreader/epub"
+)
+
+// sampleSize is the size of each sample taken by partial MD5 checksums, and
+// samples is the number of samples taken.
+const (
+	sampleSize = 1024
+	samples    = 12
+)
+
+// sampleOffset returns the offset of the nth sample taken by a partial MD5
+// checksum. Offsets grow by a factor of four: 0, 1 KiB, 4 KiB, 16 KiB, and so
+// on. KOReader computes the first offset as lshift(1024, -2), which LuaJIT
+// evaluates as 1024 << 30 truncated to 32 bits: zero.
+func sampleOffset(n int) int64 {
+	if n == 0 {
+		return 0
+	}
+
+	return sampleSize << (2 * uint(n-1))
+}
+
+// DocumentHash returns the hash by which KOReader identifies a book file: a
+// partial MD5 checksum of up to twelve 1 KiB samples of the file. Unlike a
+// checksum of the whole file, it is quick to compute for large files.
+func DocumentHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := md5.New()
+	buf := make([]byte, sampleSize)
+	for i := 0; i < samples; i++ {
+		n, err := f.ReadAt(buf, sampleOffset(i))
+		if n == 0 {
+			if err != nil && err != io.EOF {
+				return "", err
+			}
+			break
+		}
+
+		h.Write(buf[:n])
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// Fingerprint returns a partial MD5 checksum of a book's text: the documents
+// in its spine, read in order as if they were a single file. Unlike
+// DocumentHash, it does not change when the book's metadata is edited or the
+// file is repackaged.
+func Fingerprint(book *epub.Rootfile) (string, error) {
+	r := &spineReader{itemrefs: book.Spine.Itemrefs}
+	defer r.Close()
+
+	h := md5.New()
+	var pos int64
+	for i := 0; i < samples; i++ {
+		skipped, err := io.CopyN(io.Discard, r, sampleOffset(i)-pos)
+		pos += skipped
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return "", err
+		}
+
+		n, err := io.CopyN(h, r, sampleSize)
+		pos += n
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return "", err
+		}
+	}
+
+	if pos == 0 {
+		return "", fmt.Errorf("state: %s has no text", book.Title)
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// spineReader reads the documents in a spine in order, as if they were a
+// single file. Each document is opened when it is reached and closed before
+// the next, so that only one is open at a time.
+type spineReader struct {
+	itemrefs []epub.Itemref
+	current  io.ReadCloser
+}
+
+func (r *spineReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.itemrefs) == 0 {
+				return 0, io.EOF
+			}
+
+			itemref := r.itemrefs[0]
+			r.itemrefs = r.itemrefs[1:]
+			if itemref.Item == nil || itemref.Size() == 0 {
+				continue
+			}
+
+			rc, err := itemref.Open()
+			if err != nil {
+				return 0, err
+			}
+			r.current = rc
+		}
+
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			err = r.Close()
+		}
+		if n > 0 || err != nil {
+			return n, err
+		}
+	}
+}
+
+// Close closes the document being read, if any.
+func (r *spineReader) Close() error {
+	if r.current == nil {
+		return nil
+	}
+
+	err := r.current.Close()
+	r.current = nil
+
+	return err
+}
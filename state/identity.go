@@ -0,0 +1,150 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/taylorskalyo/goreader/epub"
+)
+
+// BookKeys are the ways in which a book can be recognized. No one key is
+// enough: publishers reuse placeholder identifiers, many books lack one, titles
+// collide, and editing a book's metadata changes both.
+type BookKeys struct {
+	// ID is the book's unique identifier (e.g. ISBN), as returned by BookID,
+	// or empty if it has none.
+	ID string
+
+	Title string
+
+	// Fingerprint is a checksum of the book's text, as returned by
+	// Fingerprint, or empty if it could not be computed.
+	Fingerprint string
+}
+
+// Keys returns the keys by which a book can be recognized.
+func Keys(book *epub.Rootfile) BookKeys {
+	keys := BookKeys{Title: book.Title}
+	if id, ok := BookID(book.Metadata); ok {
+		keys.ID = id
+	}
+	keys.Fingerprint, _ = Fingerprint(book)
+
+	return keys
+}
+
+// fingerprintKey returns the alias key for the book's fingerprint, or an empty
+// string if it has none.
+func (keys BookKeys) fingerprintKey() string {
+	if keys.Fingerprint == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("md5:%s", keys.Fingerprint)
+}
+
+// titleKey returns the alias key for the book's title. Before fingerprints,
+// books without an identifier were stored under this key.
+func (keys BookKeys) titleKey() string {
+	return fmt.Sprintf("title:%s", keys.Title)
+}
+
+// List returns the book's keys, from least to most specific: its title, its
+// identifier if it has one, and its fingerprint if it has one.
+func (keys BookKeys) List() []string {
+	list := []string{keys.titleKey()}
+	for _, key := range []string{keys.ID, keys.fingerprintKey()} {
+		if key != "" {
+			list = append(list, key)
+		}
+	}
+
+	return list
+}
+
+// Matches reports whether key is one of the book's keys.
+func (keys BookKeys) Matches(key string) bool {
+	return key != "" && (key == keys.ID || key == keys.fingerprintKey() || key == keys.titleKey())
+}
+
+// Aliases returns aliases from each of the book's keys to a library entry.
+func (keys BookKeys) Aliases(id string) Aliases {
+	aliases := Aliases{}
+	for _, key := range []string{keys.fingerprintKey(), keys.ID, keys.titleKey()} {
+		if key != "" && key != id {
+			aliases[key] = id
+		}
+	}
+
+	return aliases
+}
+
+// Aliases maps the keys by which books are recognized to the identifiers of
+// their entries in the library, so that progress saved under one key can be
+// found by another.
+type Aliases map[string]string
+
+// Resolve returns the library entry for a book. Keys are tried from most to
+// least reliable, each through its alias and then as an identifier in its own
+// right:
+//
+//   - The fingerprint always matches.
+//   - The identifier matches unless the entry has a different fingerprint and
+//     title, which means that another book shares the identifier.
+//   - The title matches only entries without a different fingerprint.
+//
+// If no entry matches, ok is false and id is the identifier under which to
+// store new progress for the book: its unique identifier if no other book is
+// stored under it, and otherwise its fingerprint.
+func (a Aliases) Resolve(keys BookKeys, library map[string]Progress) (id string, ok bool) {
+	sameText := func(rs Progress) bool {
+		return rs.Fingerprint == "" || keys.Fingerprint == "" || rs.Fingerprint == keys.Fingerprint
+	}
+
+	checks := []struct {
+		key   string
+		match func(rs Progress) bool
+	}{
+		{keys.fingerprintKey(), func(Progress) bool { return true }},
+		{keys.ID, func(rs Progress) bool { return sameText(rs) || rs.Title == keys.Title }},
+		{keys.titleKey(), sameText},
+	}
+
+	for _, check := range checks {
+		if check.key == "" {
+			continue
+		}
+
+		for _, id := range []string{a[check.key], check.key} {
+			if rs, ok := library[id]; ok && id != "" && check.match(rs) {
+				return id, true
+			}
+		}
+	}
+
+	if _, taken := library[keys.ID]; keys.ID != "" && !taken {
+		return keys.ID, false
+	} else if keys.Fingerprint != "" {
+		return keys.fingerprintKey(), false
+	}
+
+	return keys.titleKey(), false
+}
+
+// Identify returns the identifier under which a store keeps progress for a
+// book. If the book has not been read before, ok is false and id is the
+// identifier under which to store new progress. See Aliases.Resolve.
+func Identify(store Store, keys BookKeys) (id string, ok bool, err error) {
+	aliases, err := store.LoadAliases()
+	if err != nil {
+		return "", false, err
+	}
+
+	library, err := store.LoadLibrary()
+	if err != nil {
+		return "", false, err
+	}
+
+	id, ok = aliases.Resolve(keys, library)
+
+	return id, ok, nil
+}
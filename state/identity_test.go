@@ -0,0 +1,102 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/taylorskalyo/goreader/epub"
+)
+
+func TestFingerprint(t *testing.T) {
+	rc, err := epub.OpenReader("../epub/_test_files/alice.epub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	book := rc.DefaultRendition()
+	fp, err := Fingerprint(book)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expFormat := "Expected %v, got %v"
+	if len(fp) != 32 {
+		t.Errorf(expFormat, "a 32 digit checksum", fp)
+	}
+
+	// The fingerprint depends only on the text, not the metadata.
+	book.Metadata.Title = "Edited"
+	book.Metadata.Identifier.Content = "placeholder"
+	if again, _ := Fingerprint(book); again != fp {
+		t.Errorf(expFormat, fp, again)
+	}
+
+	if keys := Keys(book); keys.Fingerprint != fp || keys.Title != "Edited" || keys.ID == "" {
+		t.Errorf(expFormat, "keys for the edited book", keys)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	alice := BookKeys{ID: "ISBN:123", Title: "Alice", Fingerprint: "aaa"}
+	library := map[string]Progress{
+		"ISBN:123":  {Title: "Alice", Fingerprint: "aaa", Chapter: 1},
+		"title:Bob": {Title: "Bob", Chapter: 2},
+		"md5:ccc":   {Title: "Carol", Fingerprint: "ccc", Chapter: 3},
+	}
+	aliases := Aliases{"md5:aaa": "ISBN:123", "ISBN:999": "md5:ccc"}
+
+	for _, tc := range []struct {
+		name string
+		keys BookKeys
+		id   string
+		ok   bool
+	}{
+		{"identifier", alice, "ISBN:123", true},
+		{"edited metadata", BookKeys{ID: "ISBN:456", Title: "Alice (edited)", Fingerprint: "aaa"}, "ISBN:123", true},
+		{"new edition", BookKeys{ID: "ISBN:123", Title: "Alice", Fingerprint: "new"}, "ISBN:123", true},
+		{"shared identifier", BookKeys{ID: "ISBN:123", Title: "Dave", Fingerprint: "ddd"}, "md5:ddd", false},
+		{"legacy title", BookKeys{Title: "Bob", Fingerprint: "bbb"}, "title:Bob", true},
+		{"shared title", BookKeys{Title: "Carol", Fingerprint: "eee"}, "md5:eee", false},
+		{"aliased identifier", BookKeys{ID: "ISBN:999", Title: "Carol", Fingerprint: "ccc"}, "md5:ccc", true},
+		{"new book", BookKeys{ID: "ISBN:777", Title: "Eve", Fingerprint: "fff"}, "ISBN:777", false},
+		{"no fingerprint", BookKeys{Title: "Frank"}, "title:Frank", false},
+	} {
+		id, ok := aliases.Resolve(tc.keys, library)
+		if id != tc.id || ok != tc.ok {
+			t.Errorf("%s: Expected %v %v, got %v %v", tc.name, tc.id, tc.ok, id, ok)
+		}
+	}
+}
+
+func TestIdentify(t *testing.T) {
+	store := NewMemoryStore()
+	keys := BookKeys{Title: "Alice", Fingerprint: "aaa"}
+	expFormat := "Expected %v, got %v"
+
+	id, ok, err := Identify(store, keys)
+	if err != nil || ok || id != "md5:aaa" {
+		t.Errorf(expFormat, "md5:aaa", id)
+	}
+
+	store.StoreProgress(id, Progress{Title: "Alice", Fingerprint: "aaa"})
+	store.StoreAliases(keys.Aliases(id))
+
+	// Once the book has gained an identifier, it is still found, and its
+	// identifier becomes an alias.
+	keys.ID = "ISBN:123"
+	if id, ok, _ := Identify(store, keys); !ok || id != "md5:aaa" {
+		t.Errorf(expFormat, "md5:aaa", id)
+	}
+
+	store.StoreAliases(keys.Aliases(id))
+	aliases, _ := store.LoadAliases()
+	if aliases["ISBN:123"] != "md5:aaa" || aliases["title:Alice"] != "md5:aaa" {
+		t.Errorf(expFormat, "aliases to md5:aaa", aliases)
+	}
+
+	// Existing aliases are kept.
+	store.StoreAliases(Aliases{"ISBN:123": "other"})
+	if aliases, _ := store.LoadAliases(); aliases["ISBN:123"] != "md5:aaa" {
+		t.Errorf(expFormat, "md5:aaa", aliases["ISBN:123"])
+	}
+}
@@ -0,0 +1,370 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/taylorskalyo/goreader/epub"
+)
+
+// Index caches metadata about the epub files found within library roots, so
+// that unchanged files do not need to be reopened on every scan.
+type Index struct {
+	// Files maps absolute file paths to the books they contain.
+	Files map[string]IndexEntry
+}
+
+// IndexEntry describes an epub file found while scanning.
+type IndexEntry struct {
+	Path string
+
+	// Size and ModTime are used to detect when a file has changed since it
+	// was last indexed.
+	Size    int64
+	ModTime time.Time
+
+	// Identifier is the book's unique identifier (e.g. ISBN), if it has one.
+	Identifier string
+
+	// Fingerprint is a checksum of the book's text. See Fingerprint.
+	Fingerprint string `json:",omitempty"`
+
+	Title    string
+	Author   string
+	Chapters int
+
+	// Error describes why the file could not be read, if it could not.
+	Error string `json:",omitempty"`
+}
+
+// ScanResult counts the changes made to the index by a scan.
+type ScanResult struct {
+	Added     int
+	Updated   int
+	Removed   int
+	Unchanged int
+
+	// Failed maps the roots that could not be scanned, such as those on a
+	// drive that is not mounted, to the reason. It is nil if every root was
+	// scanned.
+	Failed map[string]error
+}
+
+// Err describes the roots that could not be scanned, or returns nil if every
+// root was scanned.
+func (r ScanResult) Err() error {
+	if len(r.Failed) == 0 {
+		return nil
+	}
+
+	msgs := []string{}
+	for _, err := range r.Failed {
+		msgs = append(msgs, err.Error())
+	}
+	sort.Strings(msgs)
+
+	return errors.New(strings.Join(msgs, "; "))
+}
+
+func newIndex() Index {
+	return Index{
+		Files: map[string]IndexEntry{},
+	}
+}
+
+// LoadIndex opens the index file in $XDG_STATE_HOME. An empty index is
+// returned if no directories have been scanned.
+func LoadIndex() (Index, error) {
+	index := newIndex()
+	data, err := os.ReadFile(indexFile)
+	if os.IsNotExist(err) {
+		return index, nil
+	} else if err == nil {
+		err = json.Unmarshal(data, &index)
+	}
+
+	return index, err
+}
+
+// updateIndex changes the index file in $XDG_STATE_HOME while holding its
+// lock, so that changes made by several instances of goreader are all kept.
+// An unreadable index is replaced, since it is only a cache.
+func updateIndex(fn func(index *Index)) (Index, error) {
+	if err := os.MkdirAll(appStateDir, 0700); err != nil {
+		return Index{}, err
+	}
+
+	lock, err := lockFile(indexLockName)
+	if err != nil {
+		return Index{}, err
+	}
+	defer lock.Unlock()
+
+	index, err := LoadIndex()
+	if err != nil {
+		index = newIndex()
+	}
+
+	fn(&index)
+
+	data, err := json.MarshalIndent(index, "", " ")
+	if err != nil {
+		return index, err
+	}
+
+	return index, writeFileAtomic(indexFile, data, 0644)
+}
+
+// ScanLibrary searches the given directories recursively for epub files and
+// updates the index. Files whose size and modification time are unchanged
+// since the last scan are not reopened. Files that were previously found
+// within the directories but no longer exist are removed from the index.
+//
+// A directory that cannot be scanned is recorded in the result's Failed, and
+// the others are scanned all the same. Books found within it before are kept.
+func ScanLibrary(roots []string) (Index, ScanResult, error) {
+	var result ScanResult
+
+	index, err := LoadIndex()
+	if err != nil {
+		// The index is only a cache; rebuild it from scratch.
+		index = newIndex()
+	}
+
+	dirs, err := resolveDirs(roots)
+	if err != nil {
+		return index, result, err
+	}
+
+	seen := map[string]bool{}
+	changed := map[string]IndexEntry{}
+	scanned := []string{}
+	for i, dir := range dirs {
+		err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if path == dir {
+					return err
+				}
+
+				// Skip unreadable subdirectories rather than giving up.
+				return nil
+			}
+
+			if d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".epub") {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil || !info.Mode().IsRegular() {
+				return nil
+			}
+
+			seen[path] = true
+			// Entries indexed before books were fingerprinted are refreshed.
+			cached, ok := index.Files[path]
+			if ok && cached.Size == info.Size() && cached.ModTime.Equal(info.ModTime()) &&
+				(cached.Fingerprint != "" || cached.Error != "") {
+				result.Unchanged++
+				return nil
+			}
+
+			if ok {
+				result.Updated++
+			} else {
+				result.Added++
+			}
+			changed[path] = readIndexEntry(path, info)
+
+			return nil
+		})
+		if err != nil {
+			if result.Failed == nil {
+				result.Failed = map[string]error{}
+			}
+			result.Failed[roots[i]] = err
+			continue
+		}
+		scanned = append(scanned, dir)
+	}
+
+	// Files are read without holding the lock, which may take a while, and
+	// the changes are then applied to the index as it is now.
+	index, err = updateIndex(func(index *Index) {
+		for path, entry := range changed {
+			index.Files[path] = entry
+		}
+
+		for path := range index.Files {
+			if !seen[path] && withinAny(path, scanned) {
+				delete(index.Files, path)
+				result.Removed++
+			}
+		}
+	})
+
+	return index, result, err
+}
+
+// readIndexEntry opens an epub file and describes its contents.
+func readIndexEntry(path string, info fs.FileInfo) IndexEntry {
+	rc, err := epub.OpenReader(path, epub.Lenient())
+	if err != nil {
+		return IndexEntry{
+			Path:    path,
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+			Error:   err.Error(),
+		}
+	}
+	defer rc.Close()
+
+	return newIndexEntry(path, info, rc.DefaultRendition())
+}
+
+// newIndexEntry describes the contents of an epub file that is already open.
+func newIndexEntry(path string, info fs.FileInfo, book *epub.Rootfile) IndexEntry {
+	entry := IndexEntry{
+		Path:     path,
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+		Title:    book.Title,
+		Author:   book.Creator,
+		Chapters: len(book.Spine.Itemrefs),
+	}
+
+	if id, ok := BookID(book.Metadata); ok {
+		entry.Identifier = id
+	}
+	entry.Fingerprint, _ = Fingerprint(book)
+
+	return entry
+}
+
+// FileKeys returns the keys by which the book in the epub file at path can be
+// recognized, like Keys. If the file is unchanged since it was indexed, its
+// fingerprint is taken from the index rather than computed again; otherwise
+// the file is indexed, so that it need not be the next time it is opened.
+func FileKeys(path string, book *epub.Rootfile) BookKeys {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return Keys(book)
+	}
+
+	index, err := LoadIndex()
+	if err != nil {
+		index = newIndex()
+	}
+
+	entry, ok := index.Files[path]
+	if !ok || entry.Size != info.Size() || !entry.ModTime.Equal(info.ModTime()) || entry.Fingerprint == "" {
+		entry = newIndexEntry(path, info, book)
+
+		// The index is only a cache, so failing to update it is harmless.
+		_, _ = updateIndex(func(index *Index) {
+			index.Files[path] = entry
+		})
+	}
+
+	return BookKeys{
+		ID:          entry.Identifier,
+		Title:       book.Title,
+		Fingerprint: entry.Fingerprint,
+	}
+}
+
+// Keys returns the keys by which the indexed book can be recognized.
+func (entry IndexEntry) Keys() BookKeys {
+	return BookKeys{
+		ID:          entry.Identifier,
+		Title:       entry.Title,
+		Fingerprint: entry.Fingerprint,
+	}
+}
+
+// resolveDirs returns absolute paths for the given directories, with symlinks
+// followed so that WalkDir will descend into them.
+func resolveDirs(dirs []string) ([]string, error) {
+	resolved := make([]string, len(dirs))
+	for i, dir := range dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		if resolved[i], err = filepath.EvalSymlinks(abs); err != nil {
+			resolved[i] = abs
+		}
+	}
+
+	return resolved, nil
+}
+
+// withinAny reports whether path is located within any of the given
+// directories.
+func withinAny(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		if rel, err := filepath.Rel(dir, path); err == nil && rel != ".." &&
+			!strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Entries returns the indexed books, sorted by title and then path. If any
+// directories are given, only books located within them are returned.
+func (index Index) Entries(dirs ...string) []IndexEntry {
+	dirs, _ = resolveDirs(dirs)
+	entries := []IndexEntry{}
+	for _, entry := range index.Files {
+		if len(dirs) == 0 || withinAny(entry.Path, dirs) {
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if !strings.EqualFold(a.Title, b.Title) {
+			return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+		}
+
+		return a.Path < b.Path
+	})
+
+	return entries
+}
+
+// Duplicates groups books that share the same unique identifier. Books without
+// an identifier are never considered duplicates.
+func (index Index) Duplicates() [][]IndexEntry {
+	byIdentifier := map[string][]IndexEntry{}
+	for _, entry := range index.Entries() {
+		if entry.Identifier != "" {
+			byIdentifier[entry.Identifier] = append(byIdentifier[entry.Identifier], entry)
+		}
+	}
+
+	duplicates := [][]IndexEntry{}
+	for _, entries := range byIdentifier {
+		if len(entries) > 1 {
+			duplicates = append(duplicates, entries)
+		}
+	}
+
+	sort.Slice(duplicates, func(i, j int) bool {
+		return duplicates[i][0].Path < duplicates[j][0].Path
+	})
+
+	return duplicates
+}
@@ -0,0 +1,277 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/taylorskalyo/goreader/epub"
+)
+
+const expFormat = "Expected: %v, but got: %v\n"
+
+func copyFile(t *testing.T, src, dst string) {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(dst, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScanLibrary(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	ReloadEnv()
+
+	// Paths in the index have symlinks resolved.
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	copyFile(t, "../epub/_test_files/alice.epub", filepath.Join(root, "alice.epub"))
+	copyFile(t, "../epub/_test_files/alice.epub", filepath.Join(root, "nested", "Copy.EPUB"))
+	if err := os.WriteFile(filepath.Join(root, "broken.epub"), []byte("not a zip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "notes.txt"), []byte("not a book"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	index, result, err := ScanLibrary([]string{root})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exp := (ScanResult{Added: 3}); !reflect.DeepEqual(result, exp) {
+		t.Errorf(expFormat, exp, result)
+	}
+
+	entry := index.Files[filepath.Join(root, "alice.epub")]
+	if exp := "URI:http://www.gutenberg.org/ebooks/28885"; entry.Identifier != exp {
+		t.Errorf(expFormat, exp, entry.Identifier)
+	}
+
+	if entry := index.Files[filepath.Join(root, "broken.epub")]; entry.Error == "" {
+		t.Errorf(expFormat, "an error", entry)
+	}
+
+	duplicates := index.Duplicates()
+	if len(duplicates) != 1 || len(duplicates[0]) != 2 {
+		t.Errorf(expFormat, "one pair of duplicates", duplicates)
+	}
+
+	// Unchanged files are not reopened, and the cached index is reused.
+	index, result, err = ScanLibrary([]string{root})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exp := (ScanResult{Unchanged: 3}); !reflect.DeepEqual(result, exp) {
+		t.Errorf(expFormat, exp, result)
+	}
+
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(root, "alice.epub"), later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Remove(filepath.Join(root, "broken.epub")); err != nil {
+		t.Fatal(err)
+	}
+
+	index, result, err = ScanLibrary([]string{root})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exp := (ScanResult{Updated: 1, Removed: 1, Unchanged: 1}); !reflect.DeepEqual(result, exp) {
+		t.Errorf(expFormat, exp, result)
+	}
+
+	if exp, got := 2, len(index.Entries(root)); got != exp {
+		t.Errorf(expFormat, exp, got)
+	}
+
+	// Books found in other directories are kept.
+	other := t.TempDir()
+	index, _, err = ScanLibrary([]string{other})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exp, got := 2, len(index.Entries()); got != exp {
+		t.Errorf(expFormat, exp, got)
+	}
+
+	if exp, got := 0, len(index.Entries(other)); got != exp {
+		t.Errorf(expFormat, exp, got)
+	}
+}
+
+func TestScanLibraryMissingRoot(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	ReloadEnv()
+
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	drive := filepath.Join(root, "drive")
+	copyFile(t, "../epub/_test_files/alice.epub", filepath.Join(drive, "alice.epub"))
+	if _, _, err := ScanLibrary([]string{drive}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The drive is unmounted, and a book is added to another root.
+	if err := os.RemoveAll(drive); err != nil {
+		t.Fatal(err)
+	}
+	other := filepath.Join(root, "other")
+	copyFile(t, "../epub/_test_files/alice.epub", filepath.Join(other, "alice.epub"))
+
+	index, result, err := ScanLibrary([]string{drive, other})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := result.Failed[drive]; !ok || len(result.Failed) != 1 {
+		t.Errorf(expFormat, drive, result.Failed)
+	}
+
+	if result.Err() == nil {
+		t.Errorf(expFormat, "an error", nil)
+	}
+
+	if result.Added != 1 || result.Removed != 0 {
+		t.Errorf(expFormat, "1 added, 0 removed", result)
+	}
+
+	// Books on the missing drive are kept, and the index is saved.
+	saved, err := LoadIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, index := range []Index{index, saved} {
+		if exp, got := 2, len(index.Entries()); got != exp {
+			t.Errorf(expFormat, exp, got)
+		}
+	}
+}
+
+func TestFileKeys(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	ReloadEnv()
+
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(root, "alice.epub")
+	copyFile(t, "../epub/_test_files/alice.epub", path)
+
+	rc, err := epub.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	book := rc.DefaultRendition()
+
+	// The first time a file is opened, its fingerprint is computed and indexed.
+	keys := FileKeys(path, book)
+	if exp := Keys(book); keys != exp {
+		t.Errorf(expFormat, exp, keys)
+	}
+
+	index, err := LoadIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, ok := index.Files[path]
+	if !ok || entry.Fingerprint != keys.Fingerprint {
+		t.Fatalf(expFormat, keys.Fingerprint, entry.Fingerprint)
+	}
+
+	// While the file is unchanged, the indexed fingerprint is used.
+	entry.Fingerprint = "cached"
+	if _, err := updateIndex(func(index *Index) { index.Files[path] = entry }); err != nil {
+		t.Fatal(err)
+	}
+	if got := FileKeys(path, book).Fingerprint; got != "cached" {
+		t.Errorf(expFormat, "cached", got)
+	}
+
+	// Once the file changes, the fingerprint is computed again.
+	modified := entry.ModTime.Add(time.Hour)
+	if err := os.Chtimes(path, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+	if got := FileKeys(path, book).Fingerprint; got != keys.Fingerprint {
+		t.Errorf(expFormat, keys.Fingerprint, got)
+	}
+}
+
+func TestFileKeysDuringScan(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	ReloadEnv()
+
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	copyFile(t, "../epub/_test_files/alice.epub", filepath.Join(root, "alice.epub"))
+
+	rc, err := epub.OpenReader("../epub/_test_files/alice.epub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	book := rc.DefaultRendition()
+
+	// Books opened from outside the library are indexed while it is scanned.
+	other, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := []string{}
+	for _, name := range []string{"a.epub", "b.epub", "c.epub"} {
+		path := filepath.Join(other, name)
+		copyFile(t, "../epub/_test_files/alice.epub", path)
+		paths = append(paths, path)
+	}
+
+	done := make(chan struct{})
+	for _, path := range paths {
+		go func(path string) {
+			FileKeys(path, book)
+			done <- struct{}{}
+		}(path)
+	}
+	if _, _, err := ScanLibrary([]string{root}); err != nil {
+		t.Fatal(err)
+	}
+	for range paths {
+		<-done
+	}
+
+	index, err := LoadIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range append(paths, filepath.Join(root, "alice.epub")) {
+		if _, ok := index.Files[path]; !ok {
+			t.Errorf("Expected %s to be indexed", path)
+		}
+	}
+}
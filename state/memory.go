@@ -0,0 +1,106 @@
+package state
+
+import (
+	"os"
+	"sync"
+)
+
+// MemoryStore is a Store that keeps reading progress in memory. Nothing is
+// saved once the program exits, which makes it useful for tests. Progress is
+// copied in and out of the store, so that callers cannot modify it in place.
+type MemoryStore struct {
+	mu       sync.Mutex
+	library  map[string]Progress
+	sessions []Session
+	aliases  Aliases
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		library: map[string]Progress{},
+		aliases: Aliases{},
+	}
+}
+
+// LoadProgress returns the reading progress for a book.
+func (m *MemoryStore) LoadProgress(id string) (Progress, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if rs, ok := m.library[id]; ok {
+		return rs.clone(), nil
+	}
+
+	return Progress{}, os.ErrNotExist
+}
+
+// LoadLibrary returns a copy of the reading progress of every book.
+func (m *MemoryStore) LoadLibrary() (map[string]Progress, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	library := make(map[string]Progress, len(m.library))
+	for id, rs := range m.library {
+		library[id] = rs.clone()
+	}
+
+	return library, nil
+}
+
+// StoreProgress saves the reading progress for a book, merged with any
+// progress already held for it.
+func (m *MemoryStore) StoreProgress(id string, rs Progress) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if stored, ok := m.library[id]; ok {
+		rs = merge(stored, rs)
+	}
+	m.library[id] = rs.clone()
+
+	return nil
+}
+
+// RecordSession adds a reading session to the history.
+func (m *MemoryStore) RecordSession(s Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sessions = append(m.sessions, s)
+
+	return nil
+}
+
+// LoadSessions returns a copy of the recorded reading sessions, in the order
+// in which they started.
+func (m *MemoryStore) LoadSessions() ([]Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sessions := append([]Session{}, m.sessions...)
+	sortSessions(sessions)
+
+	return sessions, nil
+}
+
+// LoadAliases returns a copy of the aliases held.
+func (m *MemoryStore) LoadAliases() (Aliases, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	aliases := make(Aliases, len(m.aliases))
+	addAliases(aliases, m.aliases)
+
+	return aliases, nil
+}
+
+// StoreAliases adds aliases, keeping any existing aliases for the same keys.
+func (m *MemoryStore) StoreAliases(aliases Aliases) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	addAliases(m.aliases, aliases)
+
+	return nil
+}
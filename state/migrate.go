@@ -0,0 +1,85 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Version is the current version of the state file schema. It must be
+// incremented, and a migration added, whenever the schema changes in a way
+// that older files cannot simply be read into.
+const Version = 1
+
+// ErrNewerVersion is returned when the state file was written by a newer
+// version of goreader. Such files are neither read nor overwritten.
+var ErrNewerVersion = errors.New("state: state file was written by a newer version of goreader")
+
+// document is a state file decoded only as far as its top-level fields, so
+// that migrations can rewrite fields that no longer match State.
+type document map[string]json.RawMessage
+
+// migrations upgrade a state file from one schema version to the next:
+// migrations[n] upgrades version n to version n+1. The version field is
+// updated after each migration.
+var migrations = []func(doc document) error{
+	migrateV0,
+}
+
+// migrateV0 upgrades files written before the schema was versioned. Over time,
+// fields were only ever added to Progress, and every one of them may be
+// missing, so there is nothing to change besides the version.
+func migrateV0(doc document) error {
+	if _, ok := doc["Library"]; !ok {
+		return errors.New("missing Library")
+	}
+
+	return nil
+}
+
+// migrate upgrades the contents of a state file to the current version. It
+// returns the upgraded contents, along with the version the file was at.
+func migrate(data []byte) ([]byte, int, error) {
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, 0, err
+	}
+
+	version := 0
+	if raw, ok := doc["Version"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return nil, 0, fmt.Errorf("version: %w", err)
+		}
+	}
+
+	if version > Version {
+		return nil, version, fmt.Errorf("%w (version %d)", ErrNewerVersion, version)
+	} else if version == Version {
+		return data, version, nil
+	}
+
+	for v := version; v < Version; v++ {
+		if err := migrations[v](doc); err != nil {
+			return nil, version, fmt.Errorf("migrate from version %d: %w", v, err)
+		}
+
+		doc["Version"] = json.RawMessage(fmt.Sprint(v + 1))
+	}
+
+	data, err := json.Marshal(doc)
+
+	return data, version, err
+}
+
+// backupVersion keeps a copy of a state file from before it was upgraded from
+// the given version. An existing backup of the same version is kept, since it
+// is the older of the two.
+func backupVersion(data []byte, version int) error {
+	backup := fmt.Sprintf("%s.v%d.bak", stateFile, version)
+	if _, err := os.Stat(backup); err == nil {
+		return nil
+	}
+
+	return os.WriteFile(backup, data, 0644)
+}
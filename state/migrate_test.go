@@ -0,0 +1,120 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const aliceID = "URI:urn:uuid:7f1e0a4e-6b33-4a35-9c59-4b1d1c0e5f2a"
+
+func TestMigrate(t *testing.T) {
+	alice := Progress{
+		Title:      "Alice's Adventures in Wonderland",
+		Author:     "Lewis Carroll",
+		Path:       "/home/reader/Books/alice.epub",
+		Chapter:    3,
+		Chapters:   23,
+		Position:   0.25,
+		LastOpened: time.Date(2025, 3, 1, 20, 15, 0, 0, time.UTC),
+		Modified:   time.Date(2025, 3, 1, 20, 10, 0, 0, time.UTC),
+		ReadLines:  1800,
+		ReadTime:   10 * time.Minute,
+		Bookmarks:  []Bookmark{{Name: "rabbit hole", Chapter: 3, Position: 0.1}},
+	}
+
+	for _, tc := range []struct {
+		fixture string
+		version int
+		library map[string]Progress
+	}{
+		{
+			// Written by the first releases, before books were listed in a
+			// library view.
+			"progress-v0-baseline.json",
+			0,
+			map[string]Progress{
+				aliceID:          {Title: alice.Title, Chapter: 3, Position: 0.25},
+				"title:Untitled": {Title: "Untitled"},
+			},
+		},
+		{
+			// Unversioned, but with every field added before versioning.
+			"progress-v0.json",
+			0,
+			map[string]Progress{aliceID: alice},
+		},
+		{
+			"progress-v1.json",
+			1,
+			map[string]Progress{aliceID: alice},
+		},
+	} {
+		t.Run(tc.fixture, func(t *testing.T) {
+			t.Setenv("XDG_STATE_HOME", t.TempDir())
+			ReloadEnv()
+
+			copyFile(t, filepath.Join("_test_files", tc.fixture), stateFile)
+			original, _ := os.ReadFile(stateFile)
+
+			library, err := files.LoadLibrary()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(tc.library, library) {
+				t.Errorf(expFormat, tc.library, library)
+			}
+
+			// The file is upgraded in place.
+			data, _ := os.ReadFile(stateFile)
+			var state State
+			if err := json.Unmarshal(data, &state); err != nil {
+				t.Fatal(err)
+			}
+
+			if state.Version != Version {
+				t.Errorf(expFormat, Version, state.Version)
+			}
+
+			// A backup is kept of files that were upgraded.
+			backups, _ := filepath.Glob(stateFile + ".v*.bak")
+			if tc.version == Version {
+				if len(backups) != 0 {
+					t.Errorf(expFormat, []string{}, backups)
+				}
+				return
+			}
+
+			backup, _ := os.ReadFile(stateFile + ".v0.bak")
+			if string(backup) != string(original) {
+				t.Errorf(expFormat, string(original), string(backup))
+			}
+		})
+	}
+}
+
+func TestMigrateNewerVersion(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	ReloadEnv()
+
+	copyFile(t, filepath.Join("_test_files", "progress-v99.json"), stateFile)
+	original, _ := os.ReadFile(stateFile)
+
+	if _, err := files.LoadLibrary(); !errors.Is(err, ErrNewerVersion) {
+		t.Errorf(expFormat, ErrNewerVersion, err)
+	}
+
+	// Files from newer versions are left alone.
+	if err := files.StoreProgress(aliceID, Progress{}); !errors.Is(err, ErrNewerVersion) {
+		t.Errorf(expFormat, ErrNewerVersion, err)
+	}
+
+	if data, _ := os.ReadFile(stateFile); string(data) != string(original) {
+		t.Errorf(expFormat, string(original), string(data))
+	}
+}
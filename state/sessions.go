@@ -0,0 +1,259 @@
+package state
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"sort"
+	"time"
+)
+
+// Session is a period spent reading a book. A session ends when the book is
+// closed, or when the reader has been idle for long enough that they are
+// assumed to have stopped reading.
+type Session struct {
+	// ID is the key under which reading progress for the book is stored.
+	ID    string
+	Title string
+
+	Start time.Time
+	End   time.Time
+
+	// FirstChapter and LastChapter are the chapters in which the session
+	// started and ended.
+	FirstChapter int
+	LastChapter  int
+
+	// Lines is how many lines were read, and Pages how many screens of text
+	// they made up.
+	Lines int
+	Pages float64
+}
+
+// Duration returns how long the session lasted.
+func (s Session) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// RecordSession appends a reading session to the session history in
+// $XDG_STATE_HOME. The history is only ever appended to, so that sessions
+// recorded by several instances of goreader are all kept.
+func (FileStore) RecordSession(s Session) error {
+	if err := os.MkdirAll(appStateDir, 0700); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	lock, err := lockFile(sessionsLockName)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	f, err := os.OpenFile(sessionsFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// LoadSessions returns every recorded reading session, in the order in which
+// they started. Lines of the history that cannot be parsed (e.g. because a
+// write was interrupted) are skipped.
+func (FileStore) LoadSessions() ([]Session, error) {
+	sessions := []Session{}
+	data, err := os.ReadFile(sessionsFile)
+	if os.IsNotExist(err) {
+		return sessions, nil
+	} else if err != nil {
+		return sessions, err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		var s Session
+		if err := json.Unmarshal(scanner.Bytes(), &s); err == nil && !s.Start.IsZero() {
+			sessions = append(sessions, s)
+		}
+	}
+
+	sortSessions(sessions)
+
+	return sessions, scanner.Err()
+}
+
+// sortSessions sorts sessions in the order in which they started.
+func sortSessions(sessions []Session) {
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].Start.Before(sessions[j].Start)
+	})
+}
+
+// Stats summarizes reading sessions.
+type Stats struct {
+	Sessions int
+	Time     time.Duration
+	Lines    int
+	Pages    float64
+
+	// Finished is the number of books in the library that have been
+	// finished.
+	Finished int
+
+	// Streak is the number of consecutive days, up to today or yesterday, on
+	// which some reading was done. LongestStreak is the longest such run
+	// ever.
+	Streak        int
+	LongestStreak int
+
+	// Books summarizes the sessions for each book, most recently read first.
+	Books []BookStats
+}
+
+// BookStats summarizes the reading sessions for one book.
+type BookStats struct {
+	ID    string
+	Title string
+
+	Sessions int
+	Time     time.Duration
+	Lines    int
+	Pages    float64
+
+	FirstRead time.Time
+	LastRead  time.Time
+
+	// Finished is when the end of the book was first reached, or zero if it
+	// has not been.
+	Finished time.Time
+}
+
+// PagesPerHour returns the average reading speed over all sessions.
+func (s Stats) PagesPerHour() float64 {
+	return pagesPerHour(s.Pages, s.Time)
+}
+
+// PagesPerHour returns the average reading speed over the book's sessions.
+func (b BookStats) PagesPerHour() float64 {
+	return pagesPerHour(b.Pages, b.Time)
+}
+
+func pagesPerHour(pages float64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+
+	return pages / d.Hours()
+}
+
+// Summarize totals reading sessions by book and measures reading streaks as
+// of now, using now's time zone to tell days apart. Completion dates are taken
+// from the library, which may be nil.
+func Summarize(sessions []Session, library map[string]Progress, now time.Time) Stats {
+	var stats Stats
+	books := map[string]*BookStats{}
+	days := map[time.Time]bool{}
+
+	for _, s := range sessions {
+		b, ok := books[s.ID]
+		if !ok {
+			b = &BookStats{ID: s.ID, FirstRead: s.Start}
+			books[s.ID] = b
+		}
+
+		if s.Title != "" {
+			b.Title = s.Title
+		}
+		b.Sessions++
+		b.Time += s.Duration()
+		b.Lines += s.Lines
+		b.Pages += s.Pages
+		if s.End.After(b.LastRead) {
+			b.LastRead = s.End
+		}
+
+		stats.Sessions++
+		stats.Time += s.Duration()
+		stats.Lines += s.Lines
+		stats.Pages += s.Pages
+		days[day(s.Start.In(now.Location()))] = true
+	}
+
+	for _, progress := range library {
+		if !progress.Finished.IsZero() {
+			stats.Finished++
+		}
+	}
+
+	for id, b := range books {
+		if progress, ok := library[id]; ok {
+			b.Finished = progress.Finished
+			if b.Title == "" {
+				b.Title = progress.Title
+			}
+		}
+
+		stats.Books = append(stats.Books, *b)
+	}
+
+	sort.Slice(stats.Books, func(i, j int) bool {
+		return stats.Books[i].LastRead.After(stats.Books[j].LastRead)
+	})
+
+	stats.Streak, stats.LongestStreak = streaks(days, day(now))
+
+	return stats
+}
+
+// day returns midnight at the start of the day containing t.
+func day(t time.Time) time.Time {
+	y, m, d := t.Date()
+
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// streaks returns the current and longest runs of consecutive days. The
+// current streak is not broken until a whole day passes with no reading.
+func streaks(days map[time.Time]bool, today time.Time) (current, longest int) {
+	sorted := make([]time.Time, 0, len(days))
+	for d := range days {
+		sorted = append(sorted, d)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Before(sorted[j])
+	})
+
+	run := 0
+	for i, d := range sorted {
+		if i > 0 && d.Equal(sorted[i-1].AddDate(0, 0, 1)) {
+			run++
+		} else {
+			run = 1
+		}
+
+		if run > longest {
+			longest = run
+		}
+	}
+
+	if n := len(sorted); n > 0 {
+		last := sorted[n-1]
+		if last.Equal(today) || last.Equal(today.AddDate(0, 0, -1)) {
+			current = run
+		}
+	}
+
+	return current, longest
+}
@@ -0,0 +1,103 @@
+package state
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRecordSession(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	ReloadEnv()
+
+	start := time.Date(2024, 3, 1, 20, 0, 0, 0, time.UTC)
+	later := Session{ID: "a", Start: start.Add(time.Hour), End: start.Add(2 * time.Hour)}
+	earlier := Session{ID: "b", Start: start, End: start.Add(30 * time.Minute), Lines: 90}
+	for _, s := range []Session{later, earlier} {
+		if err := files.RecordSession(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// An interrupted write leaves a partial line, which is skipped.
+	f, err := os.OpenFile(sessionsFile, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"ID":"c","Sta`)
+	f.Close()
+
+	sessions, err := files.LoadSessions()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expFormat := "Expected %v, got %v"
+	if len(sessions) != 2 {
+		t.Fatalf(expFormat, 2, len(sessions))
+	}
+
+	if sessions[0].ID != "b" || sessions[1].ID != "a" {
+		t.Errorf(expFormat, "sessions b, a", []string{sessions[0].ID, sessions[1].ID})
+	}
+
+	if sessions[0].Lines != 90 {
+		t.Errorf(expFormat, 90, sessions[0].Lines)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	day := func(d, hour int) time.Time {
+		return time.Date(2024, 3, d, hour, 0, 0, 0, time.UTC)
+	}
+
+	sessions := []Session{
+		{ID: "a", Title: "A", Start: day(1, 20), End: day(1, 21), Pages: 30},
+		{ID: "a", Title: "A", Start: day(2, 20), End: day(2, 21), Pages: 50},
+		{ID: "a", Title: "A", Start: day(3, 20), End: day(3, 21), Pages: 40},
+		{ID: "b", Title: "B", Start: day(6, 8), End: day(6, 8).Add(30 * time.Minute), Pages: 10},
+		{ID: "b", Title: "B", Start: day(7, 23), End: day(8, 1), Pages: 20},
+	}
+	library := map[string]Progress{
+		"a": {Title: "A", Finished: day(3, 21)},
+		"b": {Title: "B"},
+	}
+
+	stats := Summarize(sessions, library, day(8, 12))
+
+	expFormat := "Expected %v, got %v"
+	if stats.Time != 5*time.Hour+30*time.Minute {
+		t.Errorf(expFormat, 5*time.Hour+30*time.Minute, stats.Time)
+	}
+
+	if stats.PagesPerHour() != 150/5.5 {
+		t.Errorf(expFormat, 150/5.5, stats.PagesPerHour())
+	}
+
+	if stats.Finished != 1 {
+		t.Errorf(expFormat, 1, stats.Finished)
+	}
+
+	// Sessions count towards the day on which they started.
+	if stats.Streak != 2 || stats.LongestStreak != 3 {
+		t.Errorf(expFormat, "streaks of 2 and 3", []int{stats.Streak, stats.LongestStreak})
+	}
+
+	if len(stats.Books) != 2 {
+		t.Fatalf(expFormat, 2, len(stats.Books))
+	}
+
+	b, a := stats.Books[0], stats.Books[1]
+	if b.ID != "b" || b.Sessions != 2 || b.Time != 150*time.Minute || !b.Finished.IsZero() {
+		t.Errorf(expFormat, "book b read first", b)
+	}
+
+	if a.PagesPerHour() != 40 || !a.Finished.Equal(day(3, 21)) || !a.FirstRead.Equal(day(1, 20)) {
+		t.Errorf(expFormat, "book a finished on day 3", a)
+	}
+
+	// The streak is broken once a whole day passes without reading.
+	if stats := Summarize(sessions, library, day(9, 12)); stats.Streak != 0 {
+		t.Errorf(expFormat, 0, stats.Streak)
+	}
+}
@@ -2,18 +2,36 @@ package state
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"sort"
+	"time"
 
 	"github.com/adrg/xdg"
+	"github.com/taylorskalyo/goreader/epub"
 )
 
 var (
-	stateDir    string
-	appStateDir string
-	stateFile   string
+	stateDir     string
+	appStateDir  string
+	stateFile    string
+	lockFileName string
+
+	indexFile     string
+	indexLockName string
+
+	sessionsFile     string
+	sessionsLockName string
+	boltFile         string
 )
 
+// ErrCorrupt is returned when the state file cannot be parsed. The file is
+// backed up and replaced the next time progress is saved.
+var ErrCorrupt = errors.New("state: state file is corrupt")
+
 func init() {
 	ReloadEnv()
 }
@@ -24,71 +42,482 @@ type Progress struct {
 	// someone tries to manually modify their state file.
 	Title string
 
+	// Author is the book's creator, shown when browsing the library.
+	Author string
+
+	// Path is the location of the book's epub file, so that it can be
+	// reopened from the library.
+	Path string
+
 	// Chapter represents the current chapter being read.
 	Chapter int
 
+	// Chapters is the number of chapters in the book.
+	Chapters int
+
 	// Position represents the current position within a chapter.
 	Position float64
+
+	// LastOpened is when the book was last read.
+	LastOpened time.Time
+
+	// Modified is when reading progress last changed. When several instances
+	// of goreader save progress in the same book, the position in the most
+	// recently modified progress is kept, and their bookmarks and highlights
+	// are merged.
+	Modified time.Time
+
+	// ReadLines and ReadTime measure how many lines of the book have been
+	// read and how long reading them took, for estimating reading speed.
+	ReadLines int
+	ReadTime  time.Duration
+
+	// Finished is when the end of the book was first reached, or zero if it
+	// has not been.
+	Finished time.Time
+
+	// Fingerprint is a checksum of the book's text, for telling apart books
+	// that share an identifier.
+	Fingerprint string `json:",omitempty"`
+
+	// Settings are config settings changed while reading the book, which
+	// apply only to it. They are keyed as in the config file.
+	Settings map[string]interface{} `json:",omitempty"`
+
+	// Bookmarks are named positions within the book.
+	Bookmarks []Bookmark `json:",omitempty"`
+
+	// Highlights are highlighted passages of the book, in the order in which
+	// they appear.
+	Highlights []Highlight `json:",omitempty"`
+
+	// Removed records when bookmarks and highlights were removed, keyed by
+	// bookmarkKey and highlightKey, so that merging progress saved by another
+	// instance of goreader does not bring them back.
+	Removed map[string]time.Time `json:",omitempty"`
+}
+
+// Bookmark is a named position within a book.
+type Bookmark struct {
+	Name     string
+	Chapter  int
+	Position float64
+	Created  time.Time
+}
+
+// Bookmark returns the bookmark with the given name.
+func (p Progress) Bookmark(name string) (Bookmark, bool) {
+	for _, b := range p.Bookmarks {
+		if b.Name == name {
+			return b, true
+		}
+	}
+
+	return Bookmark{}, false
+}
+
+// SetBookmark adds a bookmark, replacing any existing bookmark with the same
+// name.
+func (p *Progress) SetBookmark(b Bookmark) {
+	delete(p.Removed, bookmarkKey(b.Name))
+	p.removeBookmark(b.Name)
+	p.Bookmarks = append(p.Bookmarks, b)
+}
+
+// RemoveBookmark removes the bookmark with the given name, and reports whether
+// it existed.
+func (p *Progress) RemoveBookmark(name string) bool {
+	if !p.removeBookmark(name) {
+		return false
+	}
+
+	p.markRemoved(bookmarkKey(name))
+
+	return true
+}
+
+// removeBookmark removes the bookmark with the given name without recording
+// its removal, and reports whether it existed.
+func (p *Progress) removeBookmark(name string) bool {
+	for i, b := range p.Bookmarks {
+		if b.Name == name {
+			p.Bookmarks = append(p.Bookmarks[:i], p.Bookmarks[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// bookmarkKey identifies a bookmark in Removed.
+func bookmarkKey(name string) string {
+	return fmt.Sprintf("bookmark:%s", name)
+}
+
+// Highlight is a highlighted passage of a book, along with an optional note.
+type Highlight struct {
+	Chapter int
+
+	// Start and End locate the passage within its chapter, by the number of
+	// characters that precede its first and follow its last, not counting
+	// whitespace. Unlike positions, they are unaffected by how text is
+	// wrapped.
+	Start, End int
+
+	// Text is the highlighted passage, for listing highlights without
+	// rendering the book.
+	Text string
+
+	Note  string `json:",omitempty"`
+	Color string `json:",omitempty"`
+
+	Created time.Time
+}
+
+// AddHighlight adds a highlight, keeping highlights in the order in which they
+// appear.
+func (p *Progress) AddHighlight(h Highlight) {
+	delete(p.Removed, highlightKey(h))
+	p.insertHighlight(h)
+}
+
+// insertHighlight adds a highlight in the order in which highlights appear.
+func (p *Progress) insertHighlight(h Highlight) {
+	i := sort.Search(len(p.Highlights), func(i int) bool {
+		other := p.Highlights[i]
+		if other.Chapter != h.Chapter {
+			return other.Chapter > h.Chapter
+		}
+
+		return other.Start > h.Start
+	})
+
+	p.Highlights = append(p.Highlights, Highlight{})
+	copy(p.Highlights[i+1:], p.Highlights[i:])
+	p.Highlights[i] = h
+}
+
+// RemoveHighlight removes the highlight at an index of Highlights.
+func (p *Progress) RemoveHighlight(i int) {
+	if i >= 0 && i < len(p.Highlights) {
+		p.markRemoved(highlightKey(p.Highlights[i]))
+		p.Highlights = append(p.Highlights[:i], p.Highlights[i+1:]...)
+	}
+}
+
+// hasHighlight reports whether a highlight covers the same passage as h.
+func (p Progress) hasHighlight(h Highlight) bool {
+	for _, other := range p.Highlights {
+		if highlightKey(other) == highlightKey(h) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// highlightKey identifies a highlight by the passage it covers, in Removed.
+func highlightKey(h Highlight) string {
+	return fmt.Sprintf("highlight:%d:%d:%d", h.Chapter, h.Start, h.End)
+}
+
+// markRemoved records that the bookmark or highlight with the given key was
+// removed.
+func (p *Progress) markRemoved(key string) {
+	if p.Removed == nil {
+		p.Removed = map[string]time.Time{}
+	}
+	p.Removed[key] = time.Now()
+}
+
+// removed reports whether the bookmark or highlight with the given key, made
+// at created, has since been removed.
+func (p Progress) removed(key string, created time.Time) bool {
+	t, ok := p.Removed[key]
+
+	return ok && !t.Before(created)
+}
+
+// clone returns a copy of p that shares no bookmarks, highlights, or settings
+// with it.
+func (p Progress) clone() Progress {
+	p.Bookmarks = append([]Bookmark(nil), p.Bookmarks...)
+	p.Highlights = append([]Highlight(nil), p.Highlights...)
+	if p.Settings != nil {
+		settings := make(map[string]interface{}, len(p.Settings))
+		for k, v := range p.Settings {
+			settings[k] = v
+		}
+		p.Settings = settings
+	}
+	if p.Removed != nil {
+		removed := make(map[string]time.Time, len(p.Removed))
+		for k, v := range p.Removed {
+			removed[k] = v
+		}
+		p.Removed = removed
+	}
+
+	return p
+}
+
+// Percent estimates how much of the book has been read, from 0 to 100. Each
+// chapter is assumed to be the same length.
+func (p Progress) Percent() float64 {
+	if p.Chapters < 1 {
+		return 0
+	}
+
+	percent := (float64(p.Chapter) + p.Position) / float64(p.Chapters) * 100
+	if percent < 0 || math.IsNaN(percent) {
+		return 0
+	} else if percent > 100 {
+		return 100
+	}
+
+	return percent
 }
 
 // State represents the entire state file.
 type State struct {
+	// Version is the schema version of the state file.
+	Version int
+
 	// Library is a collection of reading progress states.
 	Library map[string]Progress
+
+	// Aliases map other keys by which books are recognized to entries in
+	// Library.
+	Aliases Aliases `json:",omitempty"`
 }
 
 func newState() State {
 	return State{
+		Version: Version,
 		Library: map[string]Progress{},
 	}
 }
 
+// BookID returns the key under which reading progress for a book is stored.
+// Books are identified by their unique identifier (e.g. ISBN). If a book is
+// missing an identifier, its title is used instead and ok is false.
+//
+// > The EPUB creator MUST provide an identifier that is unique to one and only
+// > one EPUB publication [1]
+//
+// [1]: https://www.w3.org/TR/epub/#dfn-dc-identifier
+func BookID(m epub.Metadata) (id string, ok bool) {
+	if m.Identifier.Content != "" {
+		return fmt.Sprintf("%s:%s", m.Identifier.Scheme, m.Identifier.Content), true
+	}
+
+	return fmt.Sprintf("title:%s", m.Title), false
+}
+
+// Recent returns the identifiers of the n books in a library that were opened
+// most recently, most recent first. Books that have never been opened are left
+// out. If n is zero or less, every book that has been opened is returned.
+func Recent(library map[string]Progress, n int) []string {
+	ids := []string{}
+	for id, rs := range library {
+		if !rs.LastOpened.IsZero() {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := library[ids[i]].LastOpened, library[ids[j]].LastOpened
+		if !a.Equal(b) {
+			return a.After(b)
+		}
+
+		return ids[i] < ids[j]
+	})
+
+	if n > 0 && len(ids) > n {
+		ids = ids[:n]
+	}
+
+	return ids
+}
+
+// FileStore is a Store that saves reading progress to a JSON state file, and
+// reading sessions to a history file, in $XDG_STATE_HOME.
+type FileStore struct{}
+
 // LoadProgress opens the state file in $XDG_STATE_HOME and looks for the given
 // book identifier. If not present, or if an error occurs, it returns an empty
-// state.
-func LoadProgress(id string) (Progress, error) {
+// state. An error satisfying os.IsNotExist is returned if the book has not
+// been read before.
+func (FileStore) LoadProgress(id string) (Progress, error) {
 	state, err := loadState()
 	if err == nil {
 		if rs, exists := state.Library[id]; exists {
 			return rs, nil
 		}
+
+		err = os.ErrNotExist
 	}
 
 	return Progress{}, err
 }
 
-// loadState will open a state file.
+// LoadLibrary returns the reading progress of every book, keyed by book
+// identifier. An empty library is returned if no books have been read.
+func (FileStore) LoadLibrary() (map[string]Progress, error) {
+	state, err := loadState()
+	if os.IsNotExist(err) {
+		err = nil
+	}
+
+	return state.Library, err
+}
+
+// LoadAliases returns the aliases saved in the state file.
+func (FileStore) LoadAliases() (Aliases, error) {
+	state, err := loadState()
+	if os.IsNotExist(err) {
+		err = nil
+	}
+
+	if state.Aliases == nil {
+		state.Aliases = Aliases{}
+	}
+
+	return state.Aliases, err
+}
+
+// StoreAliases adds aliases to the state file, keeping any existing aliases
+// for the same keys.
+func (FileStore) StoreAliases(aliases Aliases) error {
+	return updateState(func(state *State) {
+		if state.Aliases == nil {
+			state.Aliases = Aliases{}
+		}
+		addAliases(state.Aliases, aliases)
+	})
+}
+
+// loadState will open a state file. A state file from an older version of
+// goreader is upgraded in place, and a backup of it is kept. An error wrapping
+// ErrCorrupt is returned if the file cannot be parsed.
 func loadState() (State, error) {
+	state, version, err := readState()
+	if err != nil || version == Version {
+		return state, err
+	}
+
+	lock, err := lockFile(lockFileName)
+	if err != nil {
+		return state, err
+	}
+	defer lock.Unlock()
+
+	// Another instance may have upgraded the file in the meantime.
+	state, version, err = readState()
+	if err != nil || version == Version {
+		return state, err
+	}
+
+	return state, writeState(state)
+}
+
+// readState reads and parses a state file, upgrading it to the current
+// version in memory. It returns the version the file was at. If the file was
+// upgraded, a backup of the original is kept.
+func readState() (State, int, error) {
 	state := newState()
 	data, err := os.ReadFile(stateFile)
-	if err == nil {
-		err = json.Unmarshal(data, &state)
+	if err != nil {
+		return state, Version, err
+	}
+
+	migrated, version, err := migrate(data)
+	if errors.Is(err, ErrNewerVersion) {
+		return state, version, err
+	} else if err == nil {
+		err = json.Unmarshal(migrated, &state)
+	}
+
+	if err != nil {
+		return newState(), version, fmt.Errorf("%w: %s: %v", ErrCorrupt, stateFile, err)
+	}
+
+	if state.Library == nil {
+		state.Library = map[string]Progress{}
+	}
+
+	if version != Version {
+		if err := backupVersion(data, version); err != nil {
+			return state, version, err
+		}
+	}
+
+	return state, version, nil
+}
+
+// writeState saves a state file.
+func writeState(state State) error {
+	data, err := json.MarshalIndent(state, "", " ")
+	if err != nil {
+		return err
 	}
 
-	return state, err
+	return writeFileAtomic(stateFile, data, 0644)
 }
 
 // StoreProgress saves the identifier of the current book (as a key) and the
 // current progress (as a value) to a state file in $XDG_STATE_HOME, either
 // creating or updating it.
-func StoreProgress(id string, rs Progress) error {
+//
+// The state file is locked while it is updated, so that other instances of
+// goreader do not lose their changes. Progress already held for the book is
+// merged with rs, keeping the position of whichever was modified more recently
+// and the bookmarks and highlights of both. If the state file is corrupt, it
+// is backed up before being replaced, and if it is from an older version of
+// goreader, it is upgraded.
+func (FileStore) StoreProgress(id string, rs Progress) error {
+	return updateState(func(state *State) {
+		if stored, ok := state.Library[id]; ok {
+			rs = merge(stored, rs)
+		}
+		state.Library[id] = rs
+	})
+}
+
+// updateState changes the state file while holding its lock, creating the file
+// if necessary. A corrupt state file is backed up before being replaced.
+func updateState(fn func(state *State)) error {
 	if err := os.MkdirAll(appStateDir, 0700); err != nil {
 		return err
 	}
 
-	state, err := loadState()
-	if err != nil && !os.IsNotExist(err) {
+	lock, err := lockFile(lockFileName)
+	if err != nil {
 		return err
 	}
+	defer lock.Unlock()
 
-	state.Library[id] = rs
-
-	data, err := json.MarshalIndent(state, "", " ")
-	if err != nil {
+	state, _, err := readState()
+	if errors.Is(err, ErrCorrupt) {
+		if err := backupCorrupt(); err != nil {
+			return err
+		}
+	} else if err != nil && !os.IsNotExist(err) {
 		return err
 	}
 
-	return os.WriteFile(stateFile, data, 0644)
+	fn(&state)
+
+	return writeState(state)
+}
+
+// backupCorrupt renames a corrupt state file, so that it can be recovered by
+// hand. Backups are named after the time at which they were made.
+func backupCorrupt() error {
+	backup := fmt.Sprintf("%s.corrupt-%s", stateFile, time.Now().Format("20060102T150405"))
+
+	return os.Rename(stateFile, backup)
 }
 
 // ReloadEnv re-reads XDG settings from environment variables and then
@@ -98,4 +527,10 @@ func ReloadEnv() {
 	stateDir = xdg.StateHome
 	appStateDir = filepath.Join(stateDir, "goreader")
 	stateFile = filepath.Join(appStateDir, "progress.json")
+	lockFileName = filepath.Join(appStateDir, "progress.json.lock")
+	indexFile = filepath.Join(appStateDir, "index.json")
+	indexLockName = filepath.Join(appStateDir, "index.json.lock")
+	sessionsFile = filepath.Join(appStateDir, "sessions.jsonl")
+	sessionsLockName = filepath.Join(appStateDir, "sessions.jsonl.lock")
+	boltFile = filepath.Join(appStateDir, "progress.db")
 }
@@ -0,0 +1,168 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// files is the FileStore under test.
+var files FileStore
+
+func TestStoreProgressConcurrent(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	ReloadEnv()
+
+	// Each writer reads, modifies, and writes the whole state file. Without
+	// locking, writers would overwrite each other's books.
+	const writers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- files.StoreProgress(fmt.Sprintf("book-%d", i), Progress{Chapter: i})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	library, err := files.LoadLibrary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(library) != writers {
+		t.Errorf(expFormat, writers, len(library))
+	}
+
+	// Temporary files are renamed into place or removed.
+	matches, _ := filepath.Glob(filepath.Join(appStateDir, "*.tmp"))
+	if len(matches) != 0 {
+		t.Errorf(expFormat, []string{}, matches)
+	}
+}
+
+func TestStoreProgressMerge(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	ReloadEnv()
+
+	earlier := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := earlier.Add(time.Hour)
+
+	for _, rs := range []Progress{
+		{Chapter: 1, Modified: earlier},
+		{Chapter: 3, Modified: later},
+		// Progress modified before the stored progress does not replace it.
+		{Chapter: 2, Modified: earlier},
+	} {
+		if err := files.StoreProgress("book", rs); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rs, err := files.LoadProgress("book")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rs.Chapter != 3 {
+		t.Errorf(expFormat, 3, rs.Chapter)
+	}
+}
+
+func TestStoreProgressCorrupt(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	ReloadEnv()
+
+	corrupt := []byte(`{"Library": {"book": {"Chap`)
+	if err := os.MkdirAll(appStateDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(stateFile, corrupt, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := files.LoadProgress("book"); !errors.Is(err, ErrCorrupt) {
+		t.Errorf(expFormat, ErrCorrupt, err)
+	}
+
+	if err := files.StoreProgress("book", Progress{Chapter: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	if rs, err := files.LoadProgress("book"); err != nil || rs.Chapter != 2 {
+		t.Errorf(expFormat, 2, rs.Chapter)
+	}
+
+	// The corrupt file is kept as a backup.
+	backups, _ := filepath.Glob(stateFile + ".corrupt-*")
+	if len(backups) != 1 {
+		t.Fatalf(expFormat, 1, len(backups))
+	}
+
+	if data, _ := os.ReadFile(backups[0]); string(data) != string(corrupt) {
+		t.Errorf(expFormat, string(corrupt), string(data))
+	}
+}
+
+func TestAddHighlight(t *testing.T) {
+	var p Progress
+	for _, h := range []Highlight{
+		{Chapter: 2, Start: 10, Text: "c"},
+		{Chapter: 1, Start: 50, Text: "b"},
+		{Chapter: 1, Start: 5, Text: "a"},
+		{Chapter: 3, Start: 0, Text: "d"},
+	} {
+		p.AddHighlight(h)
+	}
+
+	got := ""
+	for _, h := range p.Highlights {
+		got += h.Text
+	}
+	if got != "abcd" {
+		t.Errorf(expFormat, "abcd", got)
+	}
+
+	p.RemoveHighlight(1)
+	p.RemoveHighlight(10)
+	got = ""
+	for _, h := range p.Highlights {
+		got += h.Text
+	}
+	if got != "acd" {
+		t.Errorf(expFormat, "acd", got)
+	}
+}
+
+func TestRecent(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	library := map[string]Progress{
+		"a":      {LastOpened: day},
+		"b":      {LastOpened: day.Add(48 * time.Hour)},
+		"c":      {LastOpened: day.Add(24 * time.Hour)},
+		"unread": {},
+	}
+
+	expFormat := "Expected %v, got %v"
+	if ids := Recent(library, 2); !reflect.DeepEqual(ids, []string{"b", "c"}) {
+		t.Errorf(expFormat, []string{"b", "c"}, ids)
+	}
+
+	if ids := Recent(library, 0); !reflect.DeepEqual(ids, []string{"b", "c", "a"}) {
+		t.Errorf(expFormat, []string{"b", "c", "a"}, ids)
+	}
+}
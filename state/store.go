@@ -0,0 +1,113 @@
+package state
+
+import (
+	"fmt"
+	"time"
+)
+
+// Store saves reading progress, including bookmarks and highlights, and the
+// history of reading sessions.
+type Store interface {
+	// LoadProgress returns the reading progress for a book. An error
+	// satisfying os.IsNotExist is returned if the book has not been read
+	// before.
+	LoadProgress(id string) (Progress, error)
+
+	// LoadLibrary returns the reading progress of every book, keyed by book
+	// identifier.
+	LoadLibrary() (map[string]Progress, error)
+
+	// StoreProgress saves the reading progress for a book. If the store
+	// already holds progress for the book, the two are merged: the position
+	// modified more recently is kept, along with the bookmarks and
+	// highlights of both.
+	StoreProgress(id string, rs Progress) error
+
+	// RecordSession adds a reading session to the history.
+	RecordSession(s Session) error
+
+	// LoadSessions returns every recorded reading session, in the order in
+	// which they started.
+	LoadSessions() ([]Session, error)
+
+	// LoadAliases returns the aliases by which books in the library can be
+	// found.
+	LoadAliases() (Aliases, error)
+
+	// StoreAliases adds aliases for books in the library. Keys that already
+	// have an alias keep it.
+	StoreAliases(aliases Aliases) error
+}
+
+// Store backends that can be chosen in the config file.
+const (
+	BackendJSON   = "json"
+	BackendBolt   = "bolt"
+	BackendMemory = "memory"
+)
+
+// Open returns the store for a backend. The JSON file backend is used if
+// backend is empty.
+func Open(backend string) (Store, error) {
+	switch backend {
+	case "", BackendJSON:
+		return FileStore{}, nil
+	case BackendBolt:
+		return BoltStore{}, nil
+	case BackendMemory:
+		return NewMemoryStore(), nil
+	}
+
+	return nil, fmt.Errorf("state: unknown backend %q", backend)
+}
+
+// addAliases adds aliases to an existing set, keeping any existing aliases.
+func addAliases(existing, aliases Aliases) {
+	for key, id := range aliases {
+		if _, ok := existing[key]; !ok {
+			existing[key] = id
+		}
+	}
+}
+
+// merge combines two copies of a book's progress. The reading position, and
+// everything else but bookmarks and highlights, is taken from whichever copy
+// was modified more recently, preferring rs if neither was. Bookmarks and
+// highlights saved in either copy are kept, unless either copy removed them
+// after they were made, so that those added by another instance of goreader
+// are not lost. A bookmark or highlight found in both is taken from the more
+// recent copy.
+func merge(stored, rs Progress) Progress {
+	newer, older := rs, stored
+	if stored.Modified.After(rs.Modified) {
+		newer, older = stored, rs
+	}
+
+	merged := newer.clone()
+	for key, t := range older.Removed {
+		if merged.Removed == nil {
+			merged.Removed = map[string]time.Time{}
+		}
+		if t.After(merged.Removed[key]) {
+			merged.Removed[key] = t
+		}
+	}
+
+	merged.Bookmarks = nil
+	merged.Highlights = nil
+	for _, p := range []Progress{newer, older} {
+		for _, b := range p.Bookmarks {
+			if _, ok := merged.Bookmark(b.Name); !ok && !merged.removed(bookmarkKey(b.Name), b.Created) {
+				merged.Bookmarks = append(merged.Bookmarks, b)
+			}
+		}
+
+		for _, h := range p.Highlights {
+			if !merged.hasHighlight(h) && !merged.removed(highlightKey(h), h.Created) {
+				merged.insertHighlight(h)
+			}
+		}
+	}
+
+	return merged
+}
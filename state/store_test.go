@@ -0,0 +1,237 @@
+package state
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStores(t *testing.T) {
+	for _, backend := range []string{BackendJSON, BackendBolt, BackendMemory} {
+		t.Run(backend, func(t *testing.T) {
+			t.Setenv("XDG_STATE_HOME", t.TempDir())
+			ReloadEnv()
+
+			store, err := Open(backend)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expFormat := "Expected %v, got %v"
+			if _, err := store.LoadProgress("book"); !os.IsNotExist(err) {
+				t.Errorf(expFormat, os.ErrNotExist, err)
+			}
+
+			earlier := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			later := earlier.Add(time.Hour)
+			stored := Progress{
+				Chapter:   3,
+				Modified:  later,
+				Bookmarks: []Bookmark{{Name: "a", Chapter: 1}, {Name: "b", Chapter: 2}},
+			}
+			for _, rs := range []Progress{
+				{Chapter: 1, Modified: earlier},
+				stored,
+				// Progress modified before the stored progress does not
+				// replace it.
+				{Chapter: 2, Modified: earlier},
+			} {
+				if err := store.StoreProgress("book", rs); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := store.StoreProgress("other", Progress{Chapter: 7}); err != nil {
+				t.Fatal(err)
+			}
+
+			rs, err := store.LoadProgress("book")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(rs, stored) {
+				t.Errorf(expFormat, stored, rs)
+			}
+
+			// Changing loaded progress does not change the stored progress.
+			rs.RemoveBookmark("a")
+			if rs, _ := store.LoadProgress("book"); len(rs.Bookmarks) != 2 || rs.Bookmarks[0].Name != "a" {
+				t.Errorf(expFormat, stored.Bookmarks, rs.Bookmarks)
+			}
+
+			library, err := store.LoadLibrary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(library) != 2 || library["other"].Chapter != 7 {
+				t.Errorf(expFormat, "two books", library)
+			}
+
+			for _, s := range []Session{
+				{ID: "book", Start: later, End: later.Add(time.Minute)},
+				{ID: "other", Start: earlier, End: earlier.Add(time.Minute)},
+			} {
+				if err := store.RecordSession(s); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			sessions, err := store.LoadSessions()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(sessions) != 2 || sessions[0].ID != "other" || sessions[1].ID != "book" {
+				t.Errorf(expFormat, "sessions in the order they started", sessions)
+			}
+
+			for _, aliases := range []Aliases{
+				{"md5:abc": "book", "title:Book": "book"},
+				{"md5:abc": "other", "ISBN:123": "other"},
+			} {
+				if err := store.StoreAliases(aliases); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			aliases, err := store.LoadAliases()
+			if err != nil {
+				t.Fatal(err)
+			}
+			exp := Aliases{"md5:abc": "book", "title:Book": "book", "ISBN:123": "other"}
+			if !reflect.DeepEqual(aliases, exp) {
+				t.Errorf(expFormat, exp, aliases)
+			}
+		})
+	}
+
+	if _, err := Open("sqlite"); err == nil {
+		t.Error("Expected an error for an unknown backend")
+	}
+}
+
+func TestBoltStoreImport(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	ReloadEnv()
+
+	if err := files.StoreProgress("book", Progress{Chapter: 4}); err != nil {
+		t.Fatal(err)
+	}
+	if err := files.RecordSession(Session{ID: "book", Start: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	var store BoltStore
+	expFormat := "Expected %v, got %v"
+
+	// Progress saved by FileStore is imported when the database is created.
+	rs, err := store.LoadProgress("book")
+	if err != nil || rs.Chapter != 4 {
+		t.Errorf(expFormat, 4, rs.Chapter)
+	}
+
+	// It is only imported once.
+	if err := store.StoreProgress("book", Progress{Chapter: 5, Modified: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if rs, _ := store.LoadProgress("book"); rs.Chapter != 5 {
+		t.Errorf(expFormat, 5, rs.Chapter)
+	}
+
+	if sessions, _ := store.LoadSessions(); len(sessions) != 1 {
+		t.Errorf(expFormat, 1, len(sessions))
+	}
+}
+
+func TestStoresMerge(t *testing.T) {
+	for _, backend := range []string{BackendJSON, BackendBolt, BackendMemory} {
+		t.Run(backend, func(t *testing.T) {
+			t.Setenv("XDG_STATE_HOME", t.TempDir())
+			ReloadEnv()
+
+			store, err := Open(backend)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			opened := time.Now().Add(-time.Hour)
+			base := Progress{
+				Chapter:   1,
+				Modified:  opened,
+				Bookmarks: []Bookmark{{Name: "old", Created: opened}},
+			}
+			if err := store.StoreProgress("book", base); err != nil {
+				t.Fatal(err)
+			}
+
+			// Two instances of goreader open the book, and each adds a
+			// highlight. One also removes the bookmark, and the other
+			// moves on to another chapter.
+			first, second := base.clone(), base.clone()
+			first.AddHighlight(Highlight{Chapter: 3, Start: 5, End: 9, Text: "Alice", Created: opened.Add(time.Minute)})
+			first.RemoveBookmark("old")
+			first.Modified = opened.Add(2 * time.Minute)
+
+			second.AddHighlight(Highlight{Chapter: 1, Start: 0, End: 4, Text: "Down", Created: opened.Add(3 * time.Minute)})
+			second.Chapter = 4
+			second.Modified = opened.Add(4 * time.Minute)
+
+			for _, rs := range []Progress{first, second} {
+				if err := store.StoreProgress("book", rs); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			rs, err := store.LoadProgress("book")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expFormat := "Expected %v, got %v"
+			if rs.Chapter != 4 {
+				t.Errorf(expFormat, 4, rs.Chapter)
+			}
+
+			got := []string{}
+			for _, h := range rs.Highlights {
+				got = append(got, h.Text)
+			}
+			if exp := []string{"Down", "Alice"}; !reflect.DeepEqual(got, exp) {
+				t.Errorf(expFormat, exp, got)
+			}
+
+			if len(rs.Bookmarks) != 0 {
+				t.Errorf(expFormat, "the removed bookmark to stay removed", rs.Bookmarks)
+			}
+
+			// A highlight removed by one instance stays removed when the other
+			// saves again, but may be made anew.
+			for i, h := range rs.Highlights {
+				if h.Text == "Alice" {
+					rs.RemoveHighlight(i)
+				}
+			}
+			rs.Modified = time.Now()
+			first.Modified = rs.Modified.Add(time.Minute)
+			for _, rs := range []Progress{rs, first} {
+				if err := store.StoreProgress("book", rs); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if rs, _ := store.LoadProgress("book"); len(rs.Highlights) != 1 || rs.Highlights[0].Text != "Down" {
+				t.Errorf(expFormat, "only the Down highlight", rs.Highlights)
+			}
+
+			second.SetBookmark(Bookmark{Name: "old", Created: time.Now().Add(time.Hour)})
+			second.Modified = first.Modified.Add(time.Minute)
+			if err := store.StoreProgress("book", second); err != nil {
+				t.Fatal(err)
+			}
+
+			if rs, _ := store.LoadProgress("book"); len(rs.Bookmarks) != 1 {
+				t.Errorf(expFormat, "the bookmark to be made anew", rs.Bookmarks)
+			}
+		})
+	}
+}
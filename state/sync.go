@@ -0,0 +1,265 @@
+package state
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnauthorized is returned when the sync server rejects the configured
+// credentials.
+var ErrUnauthorized = errors.New("sync: unauthorized")
+
+// syncMediaType is the media type of requests to, and responses from, a
+// KOReader sync server.
+const syncMediaType = "application/vnd.koreader.v1+json"
+
+// SyncClient pushes and pulls reading progress using the HTTP API of a
+// KOReader sync server, so that progress can be shared with KOReader devices.
+type SyncClient struct {
+	// Server is the base URL of the sync server.
+	Server string
+
+	// Username and Key authenticate the user. Key is the MD5 hash of the
+	// user's password.
+	Username string
+	Key      string
+
+	// Device and DeviceID identify this device to the server.
+	Device   string
+	DeviceID string
+
+	HTTPClient *http.Client
+}
+
+// RemoteProgress is reading progress as stored by a sync server. Books are
+// identified by their DocumentHash.
+type RemoteProgress struct {
+	Document string `json:"document"`
+
+	// Progress locates the position within the book as an XPointer, e.g.
+	// "/body/DocFragment[3]/body/p[5]/text().0". Percentage is how much of
+	// the book has been read, from 0 to 1.
+	Progress   string  `json:"progress"`
+	Percentage float64 `json:"percentage"`
+
+	Device   string `json:"device"`
+	DeviceID string `json:"device_id"`
+
+	// Timestamp is when the progress was pushed, in seconds since the Unix
+	// epoch. It is set by the server.
+	Timestamp int64 `json:"timestamp,omitempty"`
+}
+
+// Register creates an account on the sync server.
+func (c *SyncClient) Register(ctx context.Context) error {
+	body := map[string]string{"username": c.Username, "password": c.Key}
+
+	return c.do(ctx, http.MethodPost, "/users/create", body, nil)
+}
+
+// Authorize checks the user's credentials with the sync server.
+func (c *SyncClient) Authorize(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/users/auth", nil, nil)
+}
+
+// Pull fetches the progress stored for a document. It reports false if the
+// server has no progress for the document.
+func (c *SyncClient) Pull(ctx context.Context, document string) (RemoteProgress, bool, error) {
+	var remote RemoteProgress
+	err := c.do(ctx, http.MethodGet, "/syncs/progress/"+url.PathEscape(document), nil, &remote)
+	if err != nil {
+		return remote, false, err
+	}
+
+	return remote, remote.Document != "", nil
+}
+
+// Push stores progress for a document, as having been made on this device.
+func (c *SyncClient) Push(ctx context.Context, remote RemoteProgress) error {
+	remote.Device = c.Device
+	remote.DeviceID = c.DeviceID
+	remote.Timestamp = 0
+
+	return c.do(ctx, http.MethodPut, "/syncs/progress", remote, nil)
+}
+
+// do sends an authenticated request to the sync server, encoding body (if not
+// nil) and decoding the response into out (if not nil).
+func (c *SyncClient) do(ctx context.Context, method, path string, body, out any) error {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.Server, "/")+path, r)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", syncMediaType)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("X-Auth-User", c.Username)
+	req.Header.Set("X-Auth-Key", c.Key)
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return ErrUnauthorized
+	} else if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var e struct {
+			Message string `json:"message"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&e) == nil && e.Message != "" {
+			return fmt.Errorf("sync: %s: %s", resp.Status, e.Message)
+		}
+
+		return fmt.Errorf("sync: %s", resp.Status)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// docFragment matches the chapter in which an XPointer is located. Chapters
+// are numbered from 1, in spine order.
+var docFragment = regexp.MustCompile(`^/body/DocFragment\[(\d+)\]`)
+
+// NewRemoteProgress describes reading progress for a sync server. Positions
+// within chapters cannot be located precisely, so the XPointer only points to
+// the start of the chapter; the position within it is conveyed by the
+// percentage.
+func NewRemoteProgress(document string, p Progress) RemoteProgress {
+	return RemoteProgress{
+		Document:   document,
+		Progress:   fmt.Sprintf("/body/DocFragment[%d]/body", p.Chapter+1),
+		Percentage: p.Percent() / 100,
+	}
+}
+
+// Newer reports whether remote progress was pushed more recently than local
+// progress was modified.
+func (r RemoteProgress) Newer(p Progress) bool {
+	return r.Timestamp > p.Modified.Unix()
+}
+
+// Apply returns local progress moved to the remote position. The chapter is
+// taken from the XPointer if it can be parsed, and otherwise estimated from
+// the percentage, as is the position within the chapter.
+func (r RemoteProgress) Apply(p Progress) Progress {
+	fraction := r.Percentage * float64(p.Chapters)
+	chapter := int(fraction)
+	if m := docFragment.FindStringSubmatch(r.Progress); m != nil {
+		if n, err := strconv.Atoi(m[1]); err == nil && n > 0 {
+			chapter = n - 1
+		}
+	}
+
+	if p.Chapters > 0 && chapter >= p.Chapters {
+		chapter = p.Chapters - 1
+	}
+
+	position := fraction - float64(chapter)
+	if position < 0 || position >= 1 {
+		position = 0
+	}
+
+	p.Chapter = chapter
+	p.Position = position
+	p.Modified = time.Unix(r.Timestamp, 0)
+
+	return p
+}
+
+// SyncResult describes what SyncProgress did.
+type SyncResult int
+
+const (
+	// SyncUnchanged means the local and remote progress were equally recent.
+	SyncUnchanged SyncResult = iota
+
+	// SyncPulled means the remote progress was newer, and was applied.
+	SyncPulled
+
+	// SyncPushed means the local progress was newer, and was pushed.
+	SyncPushed
+)
+
+// SyncProgress reconciles local progress in a book with the progress stored by
+// the sync server, keeping whichever was made more recently. It returns the
+// resulting local progress. Progress that has never been modified is not
+// pushed. Progress last pushed from this device is never
+// pulled, since local progress is at least as recent.
+func (c *SyncClient) SyncProgress(ctx context.Context, document string, p Progress) (Progress, RemoteProgress, SyncResult, error) {
+	remote, ok, err := c.Pull(ctx, document)
+	if err != nil {
+		return p, remote, SyncUnchanged, err
+	}
+
+	if ok && remote.DeviceID != c.DeviceID && remote.Newer(p) {
+		return remote.Apply(p), remote, SyncPulled, nil
+	}
+
+	if ok && remote.Timestamp >= p.Modified.Unix() || p.Modified.IsZero() {
+		return p, remote, SyncUnchanged, nil
+	}
+
+	remote = NewRemoteProgress(document, p)
+	if err := c.Push(ctx, remote); err != nil {
+		return p, remote, SyncUnchanged, err
+	}
+
+	return p, remote, SyncPushed, nil
+}
+
+// DeviceID returns an identifier for this device, generating and saving one in
+// $XDG_STATE_HOME the first time it is needed.
+func DeviceID() (string, error) {
+	name := filepath.Join(appStateDir, "device_id")
+	if data, err := os.ReadFile(name); err == nil && len(bytes.TrimSpace(data)) > 0 {
+		return string(bytes.TrimSpace(data)), nil
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(appStateDir, 0700); err != nil {
+		return "", err
+	}
+
+	hexID := strings.ToUpper(fmt.Sprintf("%x", id))
+
+	return hexID, writeFileAtomic(name, []byte(hexID+"\n"), 0644)
+}
@@ -0,0 +1,279 @@
+package state
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncServer is a stand-in for a KOReader sync server.
+type syncServer struct {
+	mu       sync.Mutex
+	users    map[string]string
+	progress map[string]RemoteProgress
+}
+
+func newSyncServer(t *testing.T) *httptest.Server {
+	s := &syncServer{
+		users:    map[string]string{},
+		progress: map[string]RemoteProgress{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/create", s.create)
+	mux.HandleFunc("/users/auth", s.auth(func(http.ResponseWriter, *http.Request, string) {}))
+	mux.HandleFunc("/syncs/progress", s.auth(s.update))
+	mux.HandleFunc("/syncs/progress/", s.auth(s.get))
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func (s *syncServer) create(w http.ResponseWriter, r *http.Request) {
+	var body struct{ Username, Password string }
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Username == "" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[body.Username]; ok {
+		w.WriteHeader(http.StatusPaymentRequired)
+		fmt.Fprint(w, `{"code":2002,"message":"Username is already registered."}`)
+		return
+	}
+	s.users[body.Username] = body.Password
+
+	w.WriteHeader(http.StatusCreated)
+	fmt.Fprintf(w, `{"username":%q}`, body.Username)
+}
+
+func (s *syncServer) auth(next func(http.ResponseWriter, *http.Request, string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != syncMediaType {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+
+		user := r.Header.Get("X-Auth-User")
+		s.mu.Lock()
+		key, ok := s.users[user]
+		s.mu.Unlock()
+		if !ok || key != r.Header.Get("X-Auth-Key") {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"code":2001,"message":"Unauthorized"}`)
+			return
+		}
+
+		next(w, r, user)
+	}
+}
+
+func (s *syncServer) update(w http.ResponseWriter, r *http.Request, user string) {
+	var p RemoteProgress
+	if r.Method != http.MethodPut || json.NewDecoder(r.Body).Decode(&p) != nil {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	p.Timestamp = time.Now().Unix()
+	s.mu.Lock()
+	s.progress[user+"/"+p.Document] = p
+	s.mu.Unlock()
+
+	fmt.Fprintf(w, `{"document":%q,"timestamp":%d}`, p.Document, p.Timestamp)
+}
+
+func (s *syncServer) get(w http.ResponseWriter, r *http.Request, user string) {
+	document := strings.TrimPrefix(r.URL.Path, "/syncs/progress/")
+	s.mu.Lock()
+	p, ok := s.progress[user+"/"+document]
+	s.mu.Unlock()
+
+	if !ok {
+		fmt.Fprint(w, `{}`)
+		return
+	}
+
+	json.NewEncoder(w).Encode(p)
+}
+
+func TestDocumentHash(t *testing.T) {
+	dir := t.TempDir()
+	expFormat := "Expected %v, got %v"
+
+	// Small files are hashed whole.
+	small := filepath.Join(dir, "small.epub")
+	os.WriteFile(small, []byte("hello"), 0644)
+	hash, err := DocumentHash(small)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := fmt.Sprintf("%x", md5.Sum([]byte("hello"))); hash != exp {
+		t.Errorf(expFormat, exp, hash)
+	}
+
+	// Larger files are sampled at offsets 0, 1 KiB, 4 KiB, 16 KiB, and so on.
+	data := make([]byte, 32*1024)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	large := filepath.Join(dir, "large.epub")
+	os.WriteFile(large, data, 0644)
+	base, _ := DocumentHash(large)
+
+	for _, tc := range []struct {
+		offset  int
+		changed bool
+	}{
+		{100, true},
+		{2000, true},
+		{3000, false},
+		{4500, true},
+		{10000, false},
+		{16384, true},
+	} {
+		modified := append([]byte(nil), data...)
+		modified[tc.offset]++
+		os.WriteFile(large, modified, 0644)
+
+		hash, _ := DocumentHash(large)
+		if changed := hash != base; changed != tc.changed {
+			t.Errorf("offset %d: "+expFormat, tc.offset, tc.changed, changed)
+		}
+	}
+}
+
+func TestSyncProgress(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	ReloadEnv()
+
+	server := newSyncServer(t)
+	ctx := context.Background()
+	expFormat := "Expected %v, got %v"
+
+	key := fmt.Sprintf("%x", md5.Sum([]byte("secret")))
+	goreader := &SyncClient{Server: server.URL, Username: "alice", Key: key, Device: "goreader", DeviceID: "A"}
+	kobo := &SyncClient{Server: server.URL + "/", Username: "alice", Key: key, Device: "kobo", DeviceID: "B"}
+
+	if err := goreader.Register(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := kobo.Register(ctx); err == nil || !strings.Contains(err.Error(), "already registered") {
+		t.Errorf(expFormat, "already registered", err)
+	}
+
+	if err := goreader.Authorize(ctx); err != nil {
+		t.Error(err)
+	}
+
+	wrong := *goreader
+	wrong.Key = "wrong"
+	if _, _, err := wrong.Pull(ctx, "book"); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf(expFormat, ErrUnauthorized, err)
+	}
+
+	// Unread books are not pushed.
+	local := Progress{Chapters: 10}
+	if _, _, result, err := goreader.SyncProgress(ctx, "book", local); err != nil || result != SyncUnchanged {
+		t.Errorf(expFormat, SyncUnchanged, result)
+	}
+
+	// Local progress is pushed if the server has none.
+	local = Progress{Chapter: 3, Position: 0.5, Chapters: 10, Modified: time.Now().Add(-time.Hour)}
+	if _, _, result, err := goreader.SyncProgress(ctx, "book", local); err != nil || result != SyncPushed {
+		t.Fatalf(expFormat, SyncPushed, result)
+	}
+
+	remote, ok, err := kobo.Pull(ctx, "book")
+	if err != nil || !ok {
+		t.Fatal(ok, err)
+	}
+	if remote.Progress != "/body/DocFragment[4]/body" || math.Abs(remote.Percentage-0.35) > 1e-9 || remote.Device != "goreader" {
+		t.Errorf(expFormat, "chapter 4 at 35%", remote)
+	}
+
+	// Progress pushed from this device is not pulled back.
+	if _, _, result, _ := goreader.SyncProgress(ctx, "book", local); result != SyncUnchanged {
+		t.Errorf(expFormat, SyncUnchanged, result)
+	}
+
+	// More recent progress from another device is pulled.
+	err = kobo.Push(ctx, RemoteProgress{
+		Document:   "book",
+		Progress:   "/body/DocFragment[7]/body/div/p[12]/text().42",
+		Percentage: 0.625,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	synced, remote, result, err := goreader.SyncProgress(ctx, "book", local)
+	if err != nil || result != SyncPulled {
+		t.Fatalf(expFormat, SyncPulled, result)
+	}
+	if synced.Chapter != 6 || math.Abs(synced.Position-0.25) > 1e-9 || remote.Device != "kobo" {
+		t.Errorf(expFormat, "chapter 6 at 0.25", synced)
+	}
+	if !synced.Modified.Equal(time.Unix(remote.Timestamp, 0)) {
+		t.Errorf(expFormat, time.Unix(remote.Timestamp, 0), synced.Modified)
+	}
+
+	// More recent local progress wins over the other device's.
+	local.Modified = time.Now().Add(time.Hour)
+	if _, _, result, _ := goreader.SyncProgress(ctx, "book", local); result != SyncPushed {
+		t.Errorf(expFormat, SyncPushed, result)
+	}
+}
+
+func TestRemoteProgressApply(t *testing.T) {
+	expFormat := "Expected %v, got %v"
+	local := Progress{Chapters: 4}
+
+	// Without a chapter in the XPointer, the percentage is used.
+	p := RemoteProgress{Progress: "#_doc_fragment_2", Percentage: 0.6}.Apply(local)
+	if p.Chapter != 2 || math.Abs(p.Position-0.4) > 1e-9 {
+		t.Errorf(expFormat, "chapter 2 at 0.4", p)
+	}
+
+	// A position that disagrees with the chapter is dropped.
+	p = RemoteProgress{Progress: "/body/DocFragment[2]/body", Percentage: 0.9}.Apply(local)
+	if p.Chapter != 1 || p.Position != 0 {
+		t.Errorf(expFormat, "chapter 1 at 0", p)
+	}
+
+	p = RemoteProgress{Progress: "/body/DocFragment[9]/body", Percentage: 1}.Apply(local)
+	if p.Chapter != 3 {
+		t.Errorf(expFormat, 3, p.Chapter)
+	}
+}
+
+func TestDeviceID(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	ReloadEnv()
+
+	id, err := DeviceID()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	again, _ := DeviceID()
+	if len(id) != 32 || again != id {
+		t.Errorf("Expected a stable 32 digit ID, got %q and %q", id, again)
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/taylorskalyo/goreader/display"
+	"github.com/taylorskalyo/goreader/epub"
+	"github.com/taylorskalyo/goreader/state"
+)
+
+// statsReport summarizes recorded reading sessions.
+type statsReport struct {
+	Sessions      int              `json:"sessions"`
+	Minutes       float64          `json:"minutes"`
+	Pages         float64          `json:"pages"`
+	PagesPerHour  float64          `json:"pagesPerHour"`
+	Finished      int              `json:"finished"`
+	Streak        int              `json:"streak"`
+	LongestStreak int              `json:"longestStreak"`
+	Books         []bookStatsEntry `json:"books"`
+}
+
+// bookStatsEntry summarizes the reading sessions for one book.
+type bookStatsEntry struct {
+	ID           string     `json:"id"`
+	Title        string     `json:"title"`
+	Sessions     int        `json:"sessions"`
+	Minutes      float64    `json:"minutes"`
+	Pages        float64    `json:"pages"`
+	PagesPerHour float64    `json:"pagesPerHour"`
+	FirstRead    time.Time  `json:"firstRead"`
+	LastRead     time.Time  `json:"lastRead"`
+	Finished     *time.Time `json:"finished,omitempty"`
+}
+
+// runStats prints statistics about reading sessions, either for every book or
+// for the given epub files.
+func runStats(args []string) error {
+	flags := flag.NewFlagSet("stats", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print statistics as JSON")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: goreader stats [-json] [epub file...]")
+		fmt.Fprintln(flags.Output(), "")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	store, err := openStore()
+	if err != nil {
+		return err
+	}
+
+	sessions, err := store.LoadSessions()
+	if err != nil {
+		return fmt.Errorf("load sessions: %w", err)
+	}
+
+	library, err := store.LoadLibrary()
+	if err != nil {
+		return fmt.Errorf("load progress: %w", err)
+	}
+
+	if flags.NArg() > 0 {
+		ids := map[string]bool{}
+		for _, name := range flags.Args() {
+			id, err := readBookID(store, name)
+			if err != nil {
+				return err
+			}
+			ids[id] = true
+		}
+
+		sessions, library = filterStats(sessions, library, ids)
+	}
+
+	report := newStatsReport(state.Summarize(sessions, library, time.Now()))
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(report)
+	}
+
+	return printStats(os.Stdout, report)
+}
+
+// readBookID returns the key under which store keeps reading progress for an
+// epub file.
+func readBookID(store state.Store, name string) (string, error) {
+	rc, err := epub.OpenReader(name, epub.Lenient())
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	id, _, err := state.Identify(store, state.Keys(rc.DefaultRendition()))
+
+	return id, err
+}
+
+// filterStats keeps only the sessions and progress of the books with the
+// given identifiers.
+func filterStats(sessions []state.Session, library map[string]state.Progress, ids map[string]bool) ([]state.Session, map[string]state.Progress) {
+	filtered := []state.Session{}
+	for _, s := range sessions {
+		if ids[s.ID] {
+			filtered = append(filtered, s)
+		}
+	}
+
+	progress := map[string]state.Progress{}
+	for id := range ids {
+		if p, ok := library[id]; ok {
+			progress[id] = p
+		}
+	}
+
+	return filtered, progress
+}
+
+// newStatsReport converts statistics into a report.
+func newStatsReport(stats state.Stats) statsReport {
+	report := statsReport{
+		Sessions:      stats.Sessions,
+		Minutes:       stats.Time.Minutes(),
+		Pages:         stats.Pages,
+		PagesPerHour:  stats.PagesPerHour(),
+		Finished:      stats.Finished,
+		Streak:        stats.Streak,
+		LongestStreak: stats.LongestStreak,
+		Books:         []bookStatsEntry{},
+	}
+
+	for _, b := range stats.Books {
+		entry := bookStatsEntry{
+			ID:           b.ID,
+			Title:        b.Title,
+			Sessions:     b.Sessions,
+			Minutes:      b.Time.Minutes(),
+			Pages:        b.Pages,
+			PagesPerHour: b.PagesPerHour(),
+			FirstRead:    b.FirstRead,
+			LastRead:     b.LastRead,
+		}
+
+		if !b.Finished.IsZero() {
+			finished := b.Finished
+			entry.Finished = &finished
+		}
+
+		report.Books = append(report.Books, entry)
+	}
+
+	return report
+}
+
+// printStats writes a report as text.
+func printStats(w io.Writer, report statsReport) error {
+	minutes := func(m float64) time.Duration {
+		return time.Duration(m * float64(time.Minute))
+	}
+
+	fmt.Fprintf(w, "Time read:       %s in %s\n", display.Duration(minutes(report.Minutes)), display.Plural(report.Sessions, "session"))
+	fmt.Fprintf(w, "Pages read:      %.0f, %.0f per hour\n", report.Pages, report.PagesPerHour)
+	fmt.Fprintf(w, "Books finished:  %d\n", report.Finished)
+	fmt.Fprintf(w, "Streak:          %s (longest %s)\n", display.Plural(report.Streak, "day"), display.Plural(report.LongestStreak, "day"))
+
+	if len(report.Books) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TITLE\tSESSIONS\tTIME\tPAGES\tPAGES/HOUR\tLAST READ\tFINISHED")
+	for _, b := range report.Books {
+		finished := "-"
+		if b.Finished != nil {
+			finished = b.Finished.Format("2006-01-02")
+		}
+
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%.0f\t%.0f\t%s\t%s\n",
+			display.OneLine(b.Title), b.Sessions, display.Duration(minutes(b.Minutes)),
+			b.Pages, b.PagesPerHour, b.LastRead.Format("2006-01-02"), finished)
+	}
+
+	return tw.Flush()
+}
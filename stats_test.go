@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taylorskalyo/goreader/state"
+)
+
+func TestFilterStats(t *testing.T) {
+	sessions := []state.Session{{ID: "a", Lines: 1}, {ID: "b", Lines: 2}, {ID: "a", Lines: 3}, {ID: "c", Lines: 4}}
+	library := map[string]state.Progress{"a": {Title: "A"}, "b": {Title: "B"}}
+
+	for _, tc := range []struct {
+		name     string
+		ids      map[string]bool
+		sessions []state.Session
+		library  map[string]state.Progress
+	}{
+		{
+			name:     "one book",
+			ids:      map[string]bool{"a": true},
+			sessions: []state.Session{{ID: "a", Lines: 1}, {ID: "a", Lines: 3}},
+			library:  map[string]state.Progress{"a": {Title: "A"}},
+		},
+		{
+			name:     "book without progress",
+			ids:      map[string]bool{"b": true, "c": true},
+			sessions: []state.Session{{ID: "b", Lines: 2}, {ID: "c", Lines: 4}},
+			library:  map[string]state.Progress{"b": {Title: "B"}},
+		},
+		{
+			name:     "unread book",
+			ids:      map[string]bool{"d": true},
+			sessions: []state.Session{},
+			library:  map[string]state.Progress{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			filtered, progress := filterStats(sessions, library, tc.ids)
+			assert.Equal(t, tc.sessions, filtered)
+			assert.Equal(t, tc.library, progress)
+		})
+	}
+}
+
+func TestPrintStats(t *testing.T) {
+	lastRead := time.Date(2024, 3, 2, 21, 0, 0, 0, time.Local)
+	finished := time.Date(2024, 3, 3, 22, 0, 0, 0, time.Local)
+
+	for _, tc := range []struct {
+		name     string
+		report   statsReport
+		expected string
+	}{
+		{
+			name: "no sessions",
+			report: statsReport{
+				Books: []bookStatsEntry{},
+			},
+			expected: "Time read:       <1 min in 0 sessions\n" +
+				"Pages read:      0, 0 per hour\n" +
+				"Books finished:  0\n" +
+				"Streak:          0 days (longest 0 days)\n",
+		},
+		{
+			name: "books",
+			report: statsReport{
+				Sessions:      3,
+				Minutes:       90,
+				Pages:         60,
+				PagesPerHour:  40,
+				Finished:      1,
+				Streak:        1,
+				LongestStreak: 2,
+				Books: []bookStatsEntry{
+					{Title: "Alice's\nAdventures", Sessions: 2, Minutes: 75, Pages: 50, PagesPerHour: 40, LastRead: lastRead, Finished: &finished},
+					{Title: "Bob", Sessions: 1, Minutes: 15, Pages: 10, PagesPerHour: 40, LastRead: lastRead},
+				},
+			},
+			expected: "Time read:       1 h 30 min in 3 sessions\n" +
+				"Pages read:      60, 40 per hour\n" +
+				"Books finished:  1\n" +
+				"Streak:          1 day (longest 2 days)\n" +
+				"\n" +
+				"TITLE               SESSIONS  TIME        PAGES  PAGES/HOUR  LAST READ   FINISHED\n" +
+				"Alice's Adventures  2         1 h 15 min  50     40          2024-03-02  2024-03-03\n" +
+				"Bob                 1         15 min      10     40          2024-03-02  -\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var b bytes.Buffer
+			require.NoError(t, printStats(&b, tc.report))
+			assert.Equal(t, tc.expected, b.String())
+		})
+	}
+}
+
+func TestStats(t *testing.T) {
+	useTempState(t)
+	broken := writeBroken(t)
+
+	store, err := openStore()
+	require.NoError(t, err)
+
+	const id = "URI:http://www.gutenberg.org/ebooks/28885"
+	start := time.Now().Add(-2 * time.Hour)
+	for _, s := range []state.Session{
+		{ID: id, Title: "Alice", Start: start, End: start.Add(30 * time.Minute), Pages: 20},
+		{ID: "other", Title: "Other", Start: start.Add(time.Hour), End: start.Add(90 * time.Minute), Pages: 10},
+	} {
+		require.NoError(t, store.RecordSession(s))
+	}
+
+	for _, tc := range []struct {
+		name   string
+		args   []string
+		err    bool
+		stdout []string
+	}{
+		{
+			name: "every book",
+			stdout: []string{
+				"Time read:       1 h in 2 sessions\n",
+				"Pages read:      30, 30 per hour\n",
+				"\nAlice ",
+				"\nOther ",
+			},
+		},
+		{
+			name: "one book",
+			args: []string{alice},
+			stdout: []string{
+				"Time read:       30 min in 1 session\n",
+				"\nAlice ",
+			},
+		},
+		{
+			name: "unreadable",
+			args: []string{broken},
+			err:  true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			stdout, _, err := runCommand(t, runStats, tc.args...)
+			if tc.err {
+				assert.Error(t, err)
+				assert.NotEqual(t, errUsage, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			for _, s := range tc.stdout {
+				assert.Contains(t, stdout, s)
+			}
+		})
+	}
+
+	stdout, _, err := runCommand(t, runStats, "-json", alice)
+	require.NoError(t, err)
+
+	var report statsReport
+	require.NoError(t, json.Unmarshal([]byte(stdout), &report))
+	assert.Equal(t, 1, report.Sessions)
+	assert.Equal(t, 30.0, report.Minutes)
+	assert.Equal(t, 20.0, report.Pages)
+	if assert.Len(t, report.Books, 1) {
+		assert.Equal(t, id, report.Books[0].ID)
+		assert.Equal(t, "Alice", report.Books[0].Title)
+		assert.Nil(t, report.Books[0].Finished)
+	}
+}
+
+func TestStatsEmpty(t *testing.T) {
+	useTempState(t)
+
+	stdout, _, err := runCommand(t, runStats)
+	require.NoError(t, err)
+	assert.Equal(t, "Time read:       <1 min in 0 sessions\n"+
+		"Pages read:      0, 0 per hour\n"+
+		"Books finished:  0\n"+
+		"Streak:          0 days (longest 0 days)\n", stdout)
+
+	stdout, _, err = runCommand(t, runStats, "-json", alice)
+	require.NoError(t, err)
+
+	var report statsReport
+	require.NoError(t, json.Unmarshal([]byte(stdout), &report))
+	assert.Zero(t, report.Sessions)
+	assert.NotNil(t, report.Books)
+	assert.Empty(t, report.Books)
+}
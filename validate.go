@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/taylorskalyo/goreader/epub"
+)
+
+// validation is the result of validating a single file.
+type validation struct {
+	File  string `json:"file"`
+	Valid bool   `json:"valid"`
+	epub.Report
+}
+
+// runValidate checks epub files for conformance problems. It fails if any file
+// has errors.
+func runValidate(args []string) error {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: goreader validate [-json] [epub file...]")
+		fmt.Fprintln(flags.Output(), "")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	if flags.NArg() < 1 {
+		flags.Usage()
+		return errUsage
+	}
+
+	results := make([]validation, flags.NArg())
+	valid := true
+	for i, name := range flags.Args() {
+		results[i] = validateFile(name)
+		valid = valid && results[i].Valid
+	}
+
+	var err error
+	if *asJSON {
+		err = printValidationJSON(os.Stdout, results)
+	} else {
+		err = printValidation(os.Stdout, results)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !valid {
+		return errFailed
+	}
+
+	return nil
+}
+
+// validateFile validates the epub file specified by name.
+func validateFile(name string) validation {
+	v := validation{File: name}
+
+	if f, err := os.Open(name); err != nil {
+		v.Issues = []epub.Issue{epub.NewIssue(epub.SeverityError, err)}
+	} else {
+		defer f.Close()
+
+		if fi, err := f.Stat(); err != nil {
+			v.Issues = []epub.Issue{epub.NewIssue(epub.SeverityError, err)}
+		} else {
+			v.Report = epub.Validate(f, fi.Size())
+		}
+	}
+
+	v.Valid = v.Errors() == 0
+	if v.Issues == nil {
+		v.Issues = []epub.Issue{}
+	}
+
+	return v
+}
+
+// printValidation prints validation results in a human-readable format.
+func printValidation(w io.Writer, results []validation) error {
+	for _, v := range results {
+		if _, err := fmt.Fprintf(w, "%s: %d error(s), %d warning(s)\n", v.File, v.Errors(), v.Warnings()); err != nil {
+			return err
+		}
+
+		for _, issue := range v.Issues {
+			location := issue.Path
+			if issue.Line > 0 {
+				location = fmt.Sprintf("%s:%d", location, issue.Line)
+			}
+			if location != "" {
+				location += ": "
+			}
+
+			message := issue.Message
+			if issue.ID != "" {
+				message = fmt.Sprintf("%s (id \"%s\")", message, issue.ID)
+			}
+
+			if _, err := fmt.Fprintf(w, "  %-7s %s%s\n", issue.Severity, location, message); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// printValidationJSON prints validation results as JSON.
+func printValidationJSON(w io.Writer, results []validation) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(results)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	useTempState(t)
+	broken := writeBroken(t)
+
+	for _, tc := range []struct {
+		name   string
+		args   []string
+		err    error
+		stdout string
+	}{
+		{
+			name:   "valid",
+			args:   []string{alice},
+			stdout: alice + ": 0 error(s), 0 warning(s)\n",
+		},
+		{
+			name:   "invalid",
+			args:   []string{alice, broken},
+			err:    errFailed,
+			stdout: broken + ": 1 error(s), 0 warning(s)\n  error   zip: not a valid zip file\n",
+		},
+		{
+			name:   "missing",
+			args:   []string{"missing.epub"},
+			err:    errFailed,
+			stdout: "missing.epub: 1 error(s), 0 warning(s)\n  error   open missing.epub: no such file or directory\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			stdout, _, err := runCommand(t, runValidate, tc.args...)
+			assert.Equal(t, tc.err, err)
+			assert.Contains(t, stdout, tc.stdout)
+		})
+	}
+}
+
+func TestValidateJSON(t *testing.T) {
+	useTempState(t)
+	broken := writeBroken(t)
+
+	stdout, _, err := runCommand(t, runValidate, "-json", alice, broken)
+	assert.Equal(t, errFailed, err)
+
+	var results []struct {
+		File   string `json:"file"`
+		Valid  bool   `json:"valid"`
+		Issues []struct {
+			Severity string `json:"severity"`
+			Message  string `json:"message"`
+		} `json:"issues"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &results))
+	require.Len(t, results, 2)
+
+	assert.Equal(t, alice, results[0].File)
+	assert.True(t, results[0].Valid)
+	assert.NotNil(t, results[0].Issues)
+	assert.Empty(t, results[0].Issues)
+
+	assert.Equal(t, broken, results[1].File)
+	assert.False(t, results[1].Valid)
+	if assert.Len(t, results[1].Issues, 1) {
+		assert.Equal(t, "error", results[1].Issues[0].Severity)
+		assert.Equal(t, "zip: not a valid zip file", results[1].Issues[0].Message)
+	}
+}
@@ -0,0 +1,118 @@
+package views
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// annotations is a view listing the highlights in the open book.
+type annotations struct {
+	panel
+
+	table *tview.Table
+}
+
+// Annotations displays the highlights in the open book, along with their
+// notes. Selecting a highlight navigates to it.
+func (app *Application) Annotations() {
+	if app.book == nil {
+		return
+	}
+
+	if len(app.progress.Highlights) == 0 {
+		app.setStatus("No highlights")
+		return
+	}
+
+	panel := app.newAnnotations()
+	panel.refresh(app)
+
+	app.root.AddAndSwitchToPage(pageAnnotations, panel, true)
+	app.SetFocus(panel.table)
+}
+
+// newAnnotations builds an empty annotations view.
+func (app *Application) newAnnotations() *annotations {
+	panel := &annotations{table: tview.NewTable()}
+
+	panel.table.
+		SetSelectable(true, false).
+		SetFixed(1, 0).
+		SetSelectedFunc(func(row, _ int) {
+			if row > 0 && row <= len(app.progress.Highlights) {
+				app.closeAnnotations()
+				app.gotoHighlight(row - 1)
+			}
+		})
+
+	panel.panel = app.newPanel(panel.table, app.closeAnnotations, func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Rune() != 'd' {
+			return event
+		}
+
+		row, _ := panel.table.GetSelection()
+		app.progress.RemoveHighlight(row - 1)
+		app.rerender()
+		if len(app.progress.Highlights) == 0 {
+			app.closeAnnotations()
+			return nil
+		}
+		panel.refresh(app)
+
+		return nil
+	})
+	panel.footer.SetText(app.panelHints("Enter go to", "d delete", "Esc back"))
+
+	return panel
+}
+
+// closeAnnotations returns to the open book.
+func (app *Application) closeAnnotations() {
+	app.root.RemovePage(pageAnnotations)
+	app.SetFocus(app.text)
+}
+
+// gotoHighlight navigates to the highlight at an index of the open book's
+// highlights.
+func (app *Application) gotoHighlight(i int) {
+	h := app.progress.Highlights[i]
+	app.gotoChapter(h.Chapter)
+	app.text.ScrollToBeginning()
+
+	if line, _, ok := app.renderer.Locate(h.Start); ok {
+		app.text.ScrollTo(line, 0)
+	}
+}
+
+// refresh lists the open book's highlights, keeping the selected row where
+// possible.
+func (panel *annotations) refresh(app *Application) {
+	row, _ := panel.table.GetSelection()
+	highlights := app.progress.Highlights
+	theme := app.theme()
+
+	panel.table.Clear()
+	for col, heading := range []string{"Chapter", "Highlight", "Note"} {
+		panel.table.SetCell(0, col, tview.NewTableCell(heading).
+			SetAttributes(tcell.AttrBold).
+			SetSelectable(false))
+	}
+
+	for i, h := range highlights {
+		style := theme.HighlightStyle(h.Color)
+		panel.table.SetCell(i+1, 0, tview.NewTableCell(fmt.Sprintf("%d", h.Chapter+1)).SetAlign(tview.AlignRight))
+		panel.table.SetCell(i+1, 1, tview.NewTableCell(style.String()+tview.Escape(h.Text)).SetMaxWidth(50))
+		panel.table.SetCell(i+1, 2, tview.NewTableCell(tview.Escape(h.Note)).SetExpansion(1))
+	}
+
+	if row < 1 {
+		row = 1
+	} else if row > len(highlights) {
+		row = len(highlights)
+	}
+	panel.table.Select(row, 0)
+
+	panel.header.SetText(tview.Escape(fmt.Sprintf("Annotations • %s • %d highlights", truncate(app.book.Title, 40), len(highlights))))
+}
@@ -1,47 +1,136 @@
 package views
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
 	"github.com/rivo/tview"
 	"github.com/taylorskalyo/goreader/config"
+	"github.com/taylorskalyo/goreader/display"
 	"github.com/taylorskalyo/goreader/epub"
 	"github.com/taylorskalyo/goreader/render"
 	"github.com/taylorskalyo/goreader/state"
 )
 
+// Page names.
+const (
+	pageReader      = "reader"
+	pageWarnings    = "warnings"
+	pageLibrary     = "library"
+	pageAnnotations = "annotations"
+	pageStats       = "stats"
+)
+
 // Application represents the application view.
 type Application struct {
 	*tview.Application
 
-	config  *config.Config
-	actions actions
+	// config holds the settings in use, and userConfig holds those loaded
+	// from the config file, before any settings for the open book are
+	// applied.
+	config     *config.Config
+	userConfig *config.Config
+
+	actions  actions
+	commands commands
+	cmdline  commandLine
+
+	// keys is built from the configured keybindings, and pending holds key
+	// presses that have not yet triggered an action.
+	keys    *keyTrie
+	pending pendingKeys
+
+	// visual holds the text selected in visual mode.
+	visual visualMode
+
+	// store saves reading progress and sessions.
+	store state.Store
 
 	progress state.Progress
-	book     *epub.Rootfile
+	rc       *epub.ReadCloser
+
+	// stored is the progress as it was last loaded or saved, for telling
+	// whether it has since been modified.
+	stored state.Progress
+
+	path  string
+	book  *epub.Rootfile
+	pages []printPage
+
+	// id is the key under which progress in the open book is stored, and
+	// bookKeys are the ways in which the book can be recognized. aliased
+	// reports whether bookKeys have been saved as aliases for id.
+	id       string
+	bookKeys state.BookKeys
+	aliased  bool
 
 	linecount int
 	renderer  render.Renderer
 
+	// length is the rendered length of the open book. It is nil until the
+	// book has been measured in the background.
+	length        *bookLength
+	cancelMeasure context.CancelFunc
+
+	// lastRead is where the viewport last moved, for measuring reading speed.
+	lastRead readingMark
+
+	// session is the reading session in progress, if any.
+	session state.Session
+
+	// sync is the client for the configured sync server, or nil if there is
+	// none. document is the hash by which the server identifies the open
+	// book, and synced is when its progress was last modified as of the last
+	// sync. pushes tracks progress still being sent to the server.
+	sync     *state.SyncClient
+	document string
+	synced   time.Time
+	pushes   *pushes
+
 	text      *tview.TextView
 	header    *tview.TextView
 	footer    *tview.TextView
+	input     *tview.InputField
 	container *tview.Flex
+	reader    *tview.Flex
+	root      *tview.Pages
+
+	// headerBar and footerBar are parsed from the layout config.
+	headerBar *barTemplates
+	footerBar *barTemplates
+
+	// status is a transient message shown in the footer until the next key
+	// press.
+	status string
 }
 
 // NewApplication returns an empty application.
 func NewApplication() *Application {
 	app := &Application{
 		Application: tview.NewApplication(),
+		store:       state.FileStore{},
+		pushes:      &pushes{},
 	}
 	app.initActions()
+	app.initCommands()
 
 	config := config.Default()
 	app.config = &config
+	app.userConfig = &config
+	app.keys = newKeyTrie(app.config.Keybindings)
+	if err := app.parseLayout(); err != nil {
+		panic(err)
+	}
 
 	app.text = tview.NewTextView().
 		SetDynamicColors(true).
@@ -50,15 +139,15 @@ func NewApplication() *Application {
 			app.Draw()
 		})
 	app.SetInputCapture(app.inputHandler)
+	app.text.SetMouseCapture(app.mouseHandler)
 	app.SetBeforeDrawFunc(app.beforeDraw)
+	app.SetAfterDrawFunc(app.drawSelection)
 
 	app.header = tview.NewTextView().
-		SetTextAlign(tview.AlignCenter).
 		SetWrap(false).
 		SetScrollable(false)
 
 	app.footer = tview.NewTextView().
-		SetTextAlign(tview.AlignCenter).
 		SetWrap(false).
 		SetScrollable(false)
 
@@ -70,29 +159,175 @@ func NewApplication() *Application {
 		AddItem(app.text, 0, 1, true).
 		AddItem(app.footer, 2, 0, false)
 
-	root := tview.NewFlex().
+	app.reader = tview.NewFlex().
 		AddItem(nil, 0, 1, false).
-		AddItem(app.container, 80, 0, false).
+		AddItem(app.container, app.config.Width, 0, false).
 		AddItem(nil, 0, 1, false)
 
-	app.SetRoot(root, true).SetFocus(app.text).EnableMouse(true)
+	app.root = tview.NewPages().AddPage(pageReader, app.reader, true, true)
+
+	app.SetRoot(app.root, true).SetFocus(app.text).EnableMouse(true)
 
 	return app
 }
 
+// OpenFile opens the epub file with the given name and shows its default
+// rendition. Progress in any previously opened book is saved first.
+func (app *Application) OpenFile(name string) error {
+	path, err := filepath.Abs(name)
+	if err != nil {
+		return err
+	}
+
+	rc, err := epub.OpenReader(path, epub.Lenient())
+	if err != nil {
+		return err
+	}
+
+	app.closeBook()
+	app.rc = rc
+	app.path = path
+	if app.sync != nil {
+		if app.document, err = state.DocumentHash(path); err != nil {
+			app.error("hash book for syncing", err)
+		}
+	}
+
+	app.root.RemovePage(pageLibrary)
+	app.SetFocus(app.text)
+	app.OpenBook(rc.DefaultRendition())
+	app.ShowWarnings(rc.Warnings)
+	app.pullProgress()
+
+	return nil
+}
+
+// closeBook saves progress in the open book and then closes its file.
+func (app *Application) closeBook() {
+	app.endSession(time.Now())
+	app.saveProgress()
+	app.pushProgress()
+	app.document = ""
+
+	if app.cancelMeasure != nil {
+		app.cancelMeasure()
+		app.cancelMeasure = nil
+	}
+
+	if app.rc != nil {
+		app.rc.Close()
+		app.rc = nil
+	}
+}
+
 // OpenBook loads the book contents into the application and navigates to the
 // last-open page. It loads the first page if the book has not previously been
 // read.
 func (app *Application) OpenBook(book *epub.Rootfile) {
 	app.book = book
-	app.renderer = render.New(&app.book.Package)
-	app.renderer.SetTheme(app.config.Theme)
-	app.footer.SetText(app.book.Title)
-	app.loadProgress()
+	app.identify()
+	found := app.loadProgress()
+	app.applySettings()
+
+	app.renderer = app.newRenderer()
+	app.pages = locatePages(app.book)
+	app.lastRead = readingMark{}
+	app.measure()
+
+	if !found {
+		// Skip over front matter when opening a book for the first time.
+		if landmark, ok := app.book.StartOfText(); ok && app.gotoHref(landmark.Href) {
+			return
+		}
+	}
+
 	app.gotoChapter(app.progress.Chapter)
 	app.setPosition(app.progress.Position)
 }
 
+// measure counts the lines of the open book in the background, so that
+// progress through the whole book can be shown.
+func (app *Application) measure() {
+	if app.cancelMeasure != nil {
+		app.cancelMeasure()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	app.cancelMeasure = cancel
+	app.length = nil
+
+	book, renderer := app.book, app.newRenderer()
+	go func() {
+		length, err := measureBook(ctx, book, renderer)
+		if err != nil {
+			return
+		}
+
+		app.QueueUpdateDraw(func() {
+			if app.book == book {
+				app.length = length
+			}
+		})
+	}()
+}
+
+// theme returns the theme that text is rendered with.
+func (app *Application) theme() config.Theme {
+	if theme, ok := app.config.Themes[app.config.ThemeName]; ok {
+		return theme
+	}
+
+	return app.config.Theme
+}
+
+// rerender renders the open chapter again, keeping the position within it,
+// after a change to how text is rendered.
+func (app *Application) rerender() {
+	if app.book == nil {
+		return
+	}
+
+	app.renderer.SetTheme(app.theme())
+	app.renderer.SetWidth(app.config.Width)
+	app.renderer.SetImages(app.config.Images)
+
+	pos := app.getPosition()
+	app.gotoChapter(app.progress.Chapter)
+	app.setPosition(pos)
+	app.measure()
+}
+
+// ShowWarnings displays problems that were found while loading a book. The
+// book is shown again once the user dismisses them.
+func (app *Application) ShowWarnings(warnings []error) {
+	if len(warnings) == 0 {
+		return
+	}
+
+	const maxShown = 10
+	var b strings.Builder
+	fmt.Fprintf(&b, "This book has problems and may not display correctly.\n\n")
+	for i, warning := range warnings {
+		if i == maxShown {
+			fmt.Fprintf(&b, "…and %d more\n", len(warnings)-maxShown)
+			break
+		}
+
+		fmt.Fprintf(&b, "%s\n", warning)
+	}
+
+	modal := tview.NewModal().
+		SetText(b.String()).
+		AddButtons([]string{"OK"}).
+		SetDoneFunc(func(int, string) {
+			app.root.RemovePage(pageWarnings)
+			app.SetFocus(app.text)
+		})
+
+	app.root.AddPage(pageWarnings, modal, true, true)
+	app.SetFocus(modal)
+}
+
 // printHelp prints the configured keybindings to stderr.
 func (app Application) PrintHelp() {
 	fmt.Fprintf(os.Stderr, "Configured keybindings:\n\n%s\n", app.config.Keybindings)
@@ -101,30 +336,176 @@ func (app Application) PrintHelp() {
 // printUsage prints application usage to stderr.
 func (app Application) PrintUsage() {
 	fmt.Fprintln(os.Stderr, "Usage: goreader [epub file]")
+	fmt.Fprintln(os.Stderr, "       goreader library")
+	fmt.Fprintln(os.Stderr, "       goreader --recent[=n]")
+	fmt.Fprintln(os.Stderr, "       goreader <command> [arguments]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Without arguments, the most recently read book is reopened.")
 	fmt.Fprintln(os.Stderr, "")
 	fmt.Fprintln(os.Stderr, "-h             print keybindings")
+	fmt.Fprintln(os.Stderr, "--recent[=n]   list the n most recently read books (default 10)")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Commands:")
+	fmt.Fprintln(os.Stderr, "annotations    export or import bookmarks and highlights")
+	fmt.Fprintln(os.Stderr, "cat, export    write book text to stdout or a file")
+	fmt.Fprintln(os.Stderr, "info           print book metadata")
+	fmt.Fprintln(os.Stderr, "list           scan library directories and list books")
+	fmt.Fprintln(os.Stderr, "stats          print reading statistics")
+	fmt.Fprintln(os.Stderr, "validate       check epub files for problems")
 }
 
 // Stop wraps tview.Application.Stop(). It saves reading progress then causes
 // Run() to return.
 func (app *Application) Stop() {
+	app.stopPushes()
+	app.closeBook()
+	app.Application.Stop()
+}
+
+// Run wraps tview.Application.Run(). It also redraws the screen every minute,
+// so that clocks and time estimates shown in the header and footer stay
+// current, and reloads the config whenever the config file changes. Before
+// returning, it waits briefly for progress to reach the sync server.
+func (app *Application) Run() error {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	// The goroutines below are waited for, so that none outlives the
+	// application.
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	defer func() {
+		close(done)
+		wg.Wait()
+	}()
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+
+		for {
+			select {
+			case <-ticker.C:
+				app.queueUpdateDraw(done, func() {})
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		app.watchConfig(config.ConfigFile, done)
+	}()
+
+	err := app.Application.Run()
+	app.waitPushes()
+
+	return err
+}
+
+// queueUpdateDraw works like QueueUpdateDraw, but stops waiting for f once
+// done is closed. Updates queued after the application stops never run, so
+// QueueUpdateDraw would wait forever.
+func (app *Application) queueUpdateDraw(done <-chan struct{}, f func()) {
+	queued := make(chan struct{})
+	go func() {
+		app.QueueUpdateDraw(f)
+		close(queued)
+	}()
+
+	select {
+	case <-queued:
+	case <-done:
+	}
+}
+
+// saveProgress stores the reading progress of the open book, if any.
+func (app *Application) saveProgress() {
+	if app.book == nil {
+		return
+	}
+
 	app.progress.Position = app.getPosition()
 	app.progress.Title = app.book.Title
+	app.progress.Author = app.book.Creator
+	app.progress.Chapters = len(app.book.Spine.Itemrefs)
+	if app.bookKeys.Fingerprint != "" {
+		app.progress.Fingerprint = app.bookKeys.Fingerprint
+	}
+	app.progress.LastOpened = time.Now()
+	if app.path != "" {
+		app.progress.Path = app.path
+	}
+	if app.progressChanged() {
+		app.progress.Modified = app.progress.LastOpened
+	}
 
-	if err := state.StoreProgress(app.bookID(), app.progress); err != nil {
+	if err := app.store.StoreProgress(app.bookID(), app.progress); err != nil {
 		app.error("save progress", err)
+		return
 	}
+	app.stored = app.progress
 
-	app.Application.Stop()
+	if !app.aliased {
+		if err := app.store.StoreAliases(app.bookKeys.Aliases(app.bookID())); err != nil {
+			app.error("save progress", err)
+			return
+		}
+		app.aliased = true
+	}
+}
+
+// progressChanged reports whether reading progress has changed since it was
+// last loaded or saved. Positions are compared by line, since converting
+// between positions and lines is not exact.
+func (app *Application) progressChanged() bool {
+	a, b := app.stored, app.progress
+	line := func(pos float64) int {
+		return int(pos * float64(app.linecount))
+	}
+
+	return a.Chapter != b.Chapter ||
+		line(a.Position) != line(b.Position) ||
+		a.ReadLines != b.ReadLines ||
+		!a.Finished.Equal(b.Finished) ||
+		!reflect.DeepEqual(a.Bookmarks, b.Bookmarks) ||
+		!reflect.DeepEqual(a.Highlights, b.Highlights) ||
+		!reflect.DeepEqual(a.Settings, b.Settings)
 }
 
 // configure loads the application configuration from a file. If the file does
-// not exist or an error occurs, the default configuration will be used.
+// not exist or an error occurs, the default configuration will be used. Errors
+// in the file are shown in the footer once the application starts, rather than
+// printed before the screen is cleared.
 func (app *Application) Configure() error {
-	var err error
-	if app.config, err = config.Load(); err != nil {
-		app.error("load config", err)
-		app.warn("Using default config.")
+	cfg, err := config.Load()
+	if err != nil {
+		app.setStatus(fmt.Sprintf("Could not load config, using defaults: %s", display.OneLine(err.Error())))
+		defaults := config.Default()
+		cfg = &defaults
+	} else {
+		if err := cfg.Validate(); err != nil {
+			app.setStatus(fmt.Sprintf("Invalid config, using default width: %s", err))
+			cfg.Width = config.Default().Width
+		}
+
+		if err := cfg.CheckBooks(); err != nil {
+			app.setStatus(fmt.Sprintf("Invalid config, ignoring settings: %s", err))
+		}
+	}
+	app.userConfig = cfg
+	app.setConfig(cfg)
+
+	if app.store, err = state.Open(app.config.Storage.Backend); err != nil {
+		app.error("open storage", err)
+		app.warn("Saving progress to a JSON file.")
+		app.store = state.FileStore{}
+	}
+
+	if err := app.configureSync(); err != nil {
+		app.error("configure sync", err)
 	}
 
 	// Rather than running with no way to stop, exit with an error when Exit
@@ -139,8 +520,8 @@ func (app *Application) Configure() error {
 
 // hasAction checks to see if the application has an action configured.
 func (app Application) hasAction(target config.Action) bool {
-	for _, action := range app.config.Keybindings {
-		if action == target {
+	for _, binding := range app.config.Keybindings {
+		if binding.Action == target {
 			return true
 		}
 	}
@@ -148,14 +529,18 @@ func (app Application) hasAction(target config.Action) bool {
 	return false
 }
 
-// loadProgress loads the reading progress for the currently opened book.
-func (app *Application) loadProgress() {
+// loadProgress loads the reading progress for the currently opened book. It
+// reports whether any progress was found.
+func (app *Application) loadProgress() bool {
 	var err error
-	app.progress, err = state.LoadProgress(app.bookID())
+	app.progress, err = app.store.LoadProgress(app.bookID())
+	app.stored = app.progress
 
 	if err != nil && !os.IsNotExist(err) {
 		app.error("load progress", err)
 	}
+
+	return err == nil
 }
 
 // setPosition seeks to a given position within the open chapter.
@@ -173,7 +558,12 @@ func (app *Application) getPosition() float64 {
 // beforeDraw is executed before every Draw() call of the application.
 func (app *Application) beforeDraw(s tcell.Screen) bool {
 	if app.book != nil {
+		now := time.Now()
+		app.trackReading(now)
+		app.checkFinished(now)
+		app.resizeBars()
 		app.updateHeader()
+		app.updateFooter()
 	}
 
 	return false
@@ -181,42 +571,221 @@ func (app *Application) beforeDraw(s tcell.Screen) bool {
 
 // updateHeader populates the application's header window.
 func (app *Application) updateHeader() {
+	app.updateBar(app.header, app.headerBar, app.barData())
+}
+
+// viewport returns the first line of the open chapter that is visible, and the
+// number of visible lines.
+func (app *Application) viewport() (line, height int) {
 	r, _ := app.text.GetScrollOffset()
-	_, _, _, height := app.text.GetRect()
+	_, _, _, height = app.text.GetRect()
 
 	// tview.TextView.Draw() keeps the vertical offset within these bounds.
 	// However, updateHeader gets called before tview.TextView.Draw().
+	if r > app.linecount-height {
+		r = app.linecount - height
+	}
 	if r < 0 {
 		r = 0
-	} else if r > app.linecount-height {
-		r = app.linecount - height
 	}
 
-	pages := (height + app.linecount - 1) / height
-	cur := (height+r-1)/height + 1
+	return r, height
+}
 
-	// Try to find chapter title.
+// bookPercent returns how much of the open book has been read, up to the bottom
+// of the viewport. It is not known until the book has been measured.
+func (app *Application) bookPercent() (float64, bool) {
+	if app.length == nil || app.length.total == 0 {
+		return 0, false
+	}
+
+	read := app.linesRead()
+	percent := float64(read) / float64(app.length.total) * 100
+	if percent > 100 {
+		percent = 100
+	}
+
+	return percent, true
+}
+
+// linesRead returns the number of lines of the open book that precede the
+// bottom of the viewport.
+func (app *Application) linesRead() int {
+	r, height := app.viewport()
+	bottom := r + height
+	if bottom > app.linecount {
+		bottom = app.linecount
+	}
+
+	return app.length.before(app.progress.Chapter) + bottom
+}
+
+// updateFooter populates the application's footer window.
+func (app *Application) updateFooter() {
+	if app.status != "" {
+		app.footer.SetTextAlign(tview.AlignCenter)
+		app.footer.SetText(app.status)
+		return
+	}
+
+	app.updateBar(app.footer, app.footerBar, app.barData())
+}
+
+// updateBar renders a bar's templates into a header or footer window.
+func (app *Application) updateBar(view *tview.TextView, bar *barTemplates, data barData) {
+	_, _, width, _ := view.GetRect()
+	text, err := bar.render(data, width)
+	if err != nil {
+		text = err.Error()
+	}
+
+	view.SetTextAlign(tview.AlignLeft)
+	view.SetText(text)
+}
+
+// barData collects the variables available to header and footer templates.
+func (app *Application) barData() barData {
+	r, height := app.viewport()
 	ref := app.book.Spine.Itemrefs[app.progress.Chapter]
-	if title := app.book.ItemName(ref.HREF); title != "" {
-		app.header.SetText(fmt.Sprintf("%s • %d OF %d", title, cur, pages))
+
+	data := barData{
+		Title:        app.book.Title,
+		Author:       app.book.Creator,
+		ChapterTitle: app.book.ItemName(ref.HREF),
+		Chapter:      app.progress.Chapter + 1,
+		Chapters:     len(app.book.Spine.Itemrefs),
+		Page:         (height+r-1)/height + 1,
+		Pages:        (height + app.linecount - 1) / height,
+		PrintPage:    app.currentPage(r),
+		Clock:        clock(time.Now()),
+	}
+
+	if left, ok := app.timeLeft(app.linecount - r - height); ok {
+		data.ChapterTimeLeft = display.Duration(left)
+	}
+
+	if percent, ok := app.bookPercent(); ok {
+		data.percent = percent
+		data.BookPercent = fmt.Sprintf("%.0f%%", percent)
+
+		if left, ok := app.timeLeft(app.length.total - app.linesRead()); ok {
+			data.TimeLeft = display.Duration(left)
+		}
+	}
+
+	return data
+}
+
+// parseLayout parses the header and footer templates from the config, and
+// shows or hides the header and footer accordingly.
+func (app *Application) parseLayout() error {
+	header, err := parseBar("header", app.config.Layout.Header)
+	if err != nil {
+		return err
+	}
+
+	footer, err := parseBar("footer", app.config.Layout.Footer)
+	if err != nil {
+		return err
+	}
+
+	app.headerBar, app.footerBar = header, footer
+
+	return nil
+}
+
+// resizeBars hides the header and footer if configured to. The footer is
+// still shown while there is a status message.
+func (app *Application) resizeBars() {
+	if app.headerBar.hidden {
+		app.container.ResizeItem(app.header, 0, 0)
 	} else {
-		app.header.SetText(fmt.Sprintf("%d OF %d", cur, pages))
+		app.container.ResizeItem(app.header, 2, 0)
 	}
+
+	if app.footerBar.hidden && app.status == "" {
+		app.container.ResizeItem(app.footer, 0, 0)
+	} else {
+		app.container.ResizeItem(app.footer, 2, 0)
+	}
+}
+
+// printPage is a page of the print edition, along with the chapter and
+// fragment its href refers to. Chapter is -1 if the href is not in the spine.
+type printPage struct {
+	epub.Page
+	chapter  int
+	fragment string
+}
+
+// locatePages resolves the hrefs of a book's print edition pages, so that they
+// need not be resolved again each time the screen is drawn.
+func locatePages(book *epub.Rootfile) []printPage {
+	pages := []printPage{}
+	for _, page := range book.Pages() {
+		chapter, fragment := book.SpineIndex(page.Href)
+		pages = append(pages, printPage{Page: page, chapter: chapter, fragment: fragment})
+	}
+
+	return pages
+}
+
+// currentPage returns the label of the print edition page that contains the
+// given line of the open chapter.
+func (app *Application) currentPage(line int) string {
+	label := ""
+	for _, page := range app.pages {
+		if page.chapter > app.progress.Chapter {
+			break
+		}
+
+		if page.chapter == app.progress.Chapter {
+			if l, ok := app.renderer.Anchor(page.fragment); ok && l > line {
+				break
+			}
+		}
+
+		if page.chapter >= 0 {
+			label = page.Label
+		}
+	}
+
+	return label
+}
+
+// truncate shortens text to fit within the given number of cells, marking
+// removed text with an ellipsis.
+func truncate(text string, width int) string {
+	if runewidth.StringWidth(text) <= width {
+		return text
+	}
+
+	return runewidth.Truncate(text, width, "…")
+}
+
+// setStatus shows a message in the footer until the next key press.
+func (app *Application) setStatus(msg string) {
+	app.status = msg
 }
 
 // inputHandler intercepts input events. If the application has an action
-// configured for an event, it will be triggered here.
+// configured for a sequence of events, it will be triggered here.
 func (app *Application) inputHandler(event *tcell.EventKey) *tcell.EventKey {
 	if event == nil {
 		return nil
 	}
 
-	chord := config.KeyChordFromEvent(*event)
-	if action, ok := app.config.Keybindings[chord]; ok {
-		if fn, ok := app.actions[action]; ok {
-			fn()
-		}
+	// Let prompts and dialogs handle their own input.
+	if app.GetFocus() != app.text {
+		return event
+	}
+
+	app.status = ""
+	if app.visual.active {
+		app.visualKey(event)
+		return nil
 	}
+	app.handleKey(config.KeyChordFromEvent(*event))
 
 	// Ignore unhandled bindings.
 	return nil
@@ -247,17 +816,27 @@ func (app Application) suspend(fn func()) {
 	}
 }
 
-// > The EPUB creator MUST provide an identifier that is unique to one and only
-// > one EPUB publication [1]
-//
-// [1]: https://www.w3.org/TR/epub/#dfn-dc-identifier
+// bookID returns the key under which reading progress for the open book is
+// stored.
 func (app Application) bookID() string {
-	id := app.book.Identifier
-	if id.Content != "" {
-		return fmt.Sprintf("%s:%s", id.Scheme, id.Content)
-	}
+	return app.id
+}
 
-	app.warn("Book is missing identifier (e.g. ISBN); loading and saving reading progress may not work as expected.", "scheme", id.Scheme, "content", id.Content)
+// identify finds the key under which reading progress for the open book is
+// stored. Books are recognized by their identifier, title, or the checksum of
+// their text, so that books sharing an identifier are told apart and progress
+// survives edits to a book's metadata.
+func (app *Application) identify() {
+	if app.path != "" {
+		app.bookKeys = state.FileKeys(app.path, app.book)
+	} else {
+		app.bookKeys = state.Keys(app.book)
+	}
+	app.aliased = false
 
-	return fmt.Sprintf("title:%s", app.book.Title)
+	var err error
+	if app.id, _, err = state.Identify(app.store, app.bookKeys); err != nil {
+		app.error("load progress", err)
+		app.id, _ = state.Aliases{}.Resolve(app.bookKeys, nil)
+	}
 }
@@ -3,24 +3,41 @@ package views
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/taylorskalyo/goreader/config"
+	"github.com/taylorskalyo/goreader/state"
 )
 
-type actions map[config.Action]func()
+// actions maps each configurable action to a function. Functions are passed
+// the action's argument, which has been validated against config.ActionParams,
+// or an empty string if there is none.
+type actions map[config.Action]func(arg string)
 
 // initActions initializes configurable actions.
 func (app *Application) initActions() {
 	app.actions = actions{
-		config.ActionExit:            app.Stop,
-		config.ActionUp:              app.Up,
-		config.ActionDown:            app.Down,
-		config.ActionBackward:        app.Backward,
-		config.ActionForward:         app.Forward,
-		config.ActionTop:             app.Top,
-		config.ActionBottom:          app.Bottom,
-		config.ActionChapterNext:     app.ChapterNext,
-		config.ActionChapterPrevious: app.ChapterPrevious,
+		config.ActionExit:            once(app.Stop),
+		config.ActionUp:              repeat(app.Up),
+		config.ActionDown:            repeat(app.Down),
+		config.ActionBackward:        repeat(app.Backward),
+		config.ActionForward:         repeat(app.Forward),
+		config.ActionTop:             counted(app.top),
+		config.ActionBottom:          once(app.Bottom),
+		config.ActionChapterNext:     counted(app.chapterNext),
+		config.ActionChapterPrevious: counted(app.chapterPrevious),
+		config.ActionGotoPage:        app.gotoPageLabel,
+		config.ActionLibrary:         once(app.ShowLibrary),
+		config.ActionGotoPercent:     counted(app.gotoPercentCount),
+		config.ActionCommand:         once(app.Command),
+		config.ActionScrollLines:     counted(app.scrollLines),
+		config.ActionBookmark:        app.bookmark,
+		config.ActionVisual:          once(app.Visual),
+		config.ActionAnnotations:     once(app.Annotations),
+		config.ActionStats:           once(app.Stats),
+		config.ActionReload:          once(app.Reload),
 	}
 
 	// Sanity check to make sure we handle all of the configurable actions.
@@ -31,6 +48,32 @@ func (app *Application) initActions() {
 	}
 }
 
+// once adapts a function for an action that takes no argument.
+func once(fn func()) func(string) {
+	return func(string) {
+		fn()
+	}
+}
+
+// counted adapts a function for an action that takes a number. The function is
+// passed zero if no number was given.
+func counted(fn func(int)) func(string) {
+	return func(arg string) {
+		n, _ := strconv.Atoi(arg)
+		fn(n)
+	}
+}
+
+// repeat adapts a function for an action that is repeated a given number of
+// times.
+func repeat(fn func()) func(string) {
+	return counted(func(count int) {
+		for i := 0; i < atLeast(count, 1); i++ {
+			fn()
+		}
+	})
+}
+
 // Up scrolls the application viewport up by one line.
 func (app *Application) Up() {
 	r, c := app.text.GetScrollOffset()
@@ -85,6 +128,23 @@ func (app *Application) Forward() {
 	}
 }
 
+// scrollLines scrolls the viewport by a number of lines, up if negative,
+// staying within the current chapter.
+func (app *Application) scrollLines(n int) {
+	r, c := app.text.GetScrollOffset()
+	_, _, _, height := app.text.GetRect()
+
+	r += n
+	if r > app.linecount-height {
+		r = app.linecount - height
+	}
+	if r < 0 {
+		r = 0
+	}
+
+	app.text.ScrollTo(r, c)
+}
+
 // Bottom navigates to the bottom of the current chapter.
 func (app *Application) Bottom() {
 	_, _, _, height := app.text.GetRect()
@@ -96,16 +156,58 @@ func (app *Application) Top() {
 	app.text.ScrollToBeginning()
 }
 
+// top navigates to the top of the current chapter or, given a count, to the
+// top of that chapter.
+func (app *Application) top(count int) {
+	if count == 0 {
+		app.Top()
+		return
+	}
+
+	if count > len(app.book.Spine.Itemrefs) {
+		app.setStatus(fmt.Sprintf("Chapter not found: %d", count))
+		return
+	}
+
+	app.gotoChapter(count - 1)
+	app.text.ScrollToBeginning()
+}
+
 // ChapterNext navigates to the next chapter.
 func (app *Application) ChapterNext() {
-	app.gotoChapter(app.progress.Chapter + 1)
-	app.text.ScrollToBeginning()
+	app.chapterNext(1)
 }
 
 // ChapterPrevious navigates to the previous chapter.
 func (app *Application) ChapterPrevious() {
-	app.gotoChapter(app.progress.Chapter - 1)
-	app.text.ScrollToBeginning()
+	app.chapterPrevious(1)
+}
+
+// chapterNext navigates count chapters forward, stopping at the last chapter.
+func (app *Application) chapterNext(count int) {
+	n := app.progress.Chapter + atLeast(count, 1)
+	if last := len(app.book.Spine.Itemrefs) - 1; n > last {
+		n = last
+	}
+
+	if n != app.progress.Chapter {
+		app.gotoChapter(n)
+		app.text.ScrollToBeginning()
+	}
+}
+
+// chapterPrevious navigates count chapters backward, stopping at the first
+// chapter.
+func (app *Application) chapterPrevious(count int) {
+	n := app.progress.Chapter - atLeast(count, 1)
+	if n < 0 {
+		n = 0
+	}
+
+	if n != app.progress.Chapter {
+		app.gotoChapter(n)
+		app.text.ScrollToBeginning()
+	}
 }
 
 // gotoChapter navigates to a specific chapter.
@@ -117,6 +219,8 @@ func (app *Application) gotoChapter(n int) {
 
 	app.text.SetText("")
 	app.progress.Chapter = n
+	app.visual.active = false
+	app.renderer.SetHighlights(app.highlights())
 
 	err := app.renderer.RenderChapter(context.TODO(), n, app.text)
 	if err != nil {
@@ -126,3 +230,139 @@ func (app *Application) gotoChapter(n int) {
 
 	app.linecount = app.text.GetOriginalLineCount()
 }
+
+// GotoPage prompts for a page number of the print edition and navigates to it.
+func (app *Application) GotoPage() {
+	if len(app.pages) == 0 {
+		app.setStatus("Book does not have page numbers")
+		return
+	}
+
+	app.prompt("Go to page: ", func(text string) {
+		if !app.gotoPage(text) {
+			app.setStatus(fmt.Sprintf("Page not found: %s", text))
+		}
+	})
+}
+
+// gotoPageLabel navigates to the print edition page with the given label, or
+// prompts for a page if there is no label.
+func (app *Application) gotoPageLabel(label string) {
+	if label == "" {
+		app.GotoPage()
+		return
+	}
+
+	if !app.gotoPage(label) {
+		app.setStatus(fmt.Sprintf("Page not found: %s", label))
+	}
+}
+
+// gotoPercentCount navigates to the percentage of the book given as a count,
+// or to the start of the book if there is no count.
+func (app *Application) gotoPercentCount(count int) {
+	if count > 100 {
+		count = 100
+	}
+
+	app.gotoPercent(float64(count))
+}
+
+// gotoPercent navigates to a percentage of the way through the book. Once the
+// book has been measured, the percentage is of its lines; before then, each
+// chapter is assumed to be the same length.
+func (app *Application) gotoPercent(percent float64) {
+	total := len(app.book.Spine.Itemrefs)
+	if total == 0 {
+		return
+	}
+
+	if app.length == nil || app.length.total == 0 {
+		pos := percent / 100 * float64(total)
+		n := int(pos)
+		if n >= total {
+			n = total - 1
+		}
+
+		app.gotoChapter(n)
+		app.setPosition(pos - float64(n))
+		return
+	}
+
+	line := int(percent / 100 * float64(app.length.total))
+	for n, lines := range app.length.chapters {
+		if line < lines || n == total-1 {
+			app.gotoChapter(n)
+			app.text.ScrollTo(line, 0)
+			return
+		}
+		line -= lines
+	}
+}
+
+// gotoPage navigates to the print edition page with the given label.
+func (app *Application) gotoPage(label string) bool {
+	label = trimPageLabel(label)
+	for _, page := range app.pages {
+		if strings.EqualFold(trimPageLabel(page.Label), label) {
+			return app.gotoHref(page.Href)
+		}
+	}
+
+	return false
+}
+
+// trimPageLabel removes decorations that some publishers add to page labels
+// (e.g. "[12]").
+func trimPageLabel(label string) string {
+	return strings.Trim(strings.TrimSpace(label), "[]()")
+}
+
+// gotoHref navigates to the spine item referenced by href. If href contains a
+// fragment, the viewport is scrolled to the referenced element.
+func (app *Application) gotoHref(href string) bool {
+	n, fragment := app.book.SpineIndex(href)
+	if n < 0 {
+		return false
+	}
+
+	app.gotoChapter(n)
+	app.text.ScrollToBeginning()
+
+	if fragment != "" {
+		if line, ok := app.renderer.Anchor(fragment); ok {
+			app.text.ScrollTo(line, 0)
+		}
+	}
+
+	return true
+}
+
+// bookmark bookmarks the current position with the given name, or prompts for
+// a name if there is none.
+func (app *Application) bookmark(name string) {
+	if name != "" {
+		app.addBookmark(name)
+		return
+	}
+
+	app.prompt("Bookmark name: ", func(text string) {
+		app.addBookmark(strings.TrimSpace(text))
+	})
+}
+
+// addBookmark bookmarks the current position. If name is empty, the bookmark
+// is named after the position.
+func (app *Application) addBookmark(name string) {
+	if name == "" {
+		name = fmt.Sprintf("Chapter %d, %.0f%%", app.progress.Chapter+1, app.getPosition()*100)
+	}
+
+	app.progress.SetBookmark(state.Bookmark{
+		Name:     name,
+		Chapter:  app.progress.Chapter,
+		Position: app.getPosition(),
+		Created:  time.Now(),
+	})
+	app.setStatus(fmt.Sprintf("Bookmarked %s", name))
+}
@@ -1,6 +1,7 @@
 package views
 
 import (
+	"regexp"
 	"strings"
 	"testing"
 	"time"
@@ -45,9 +46,34 @@ func newTestScreen(t *testing.T) testScreen {
 
 func newTestApp(t *testing.T) *Application {
 	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
 	state.ReloadEnv()
+	config.ReloadEnv()
 
-	return NewApplication()
+	app := NewApplication()
+	app.store = state.NewMemoryStore()
+
+	return app
+}
+
+// assertScreen redraws the screen until it matches a pattern, since simulated
+// key presses are handled asynchronously. The test fails if the screen does
+// not match within twenty seconds, which leaves room for the race detector.
+func assertScreen(t *testing.T, app *Application, ts testScreen, pattern string) {
+	t.Helper()
+
+	re := regexp.MustCompile(pattern)
+	var screen string
+	for deadline := time.Now().Add(20 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		app.QueueUpdateDraw(func() {})
+		app.QueueUpdate(func() { screen = ts.String() })
+		if re.MatchString(screen) {
+			return
+		}
+	}
+
+	t.Logf("Simulated screen state:\n%s", screen)
+	assert.Regexp(t, pattern, screen)
 }
 
 func TestNavigation(t *testing.T) {
@@ -108,11 +134,154 @@ func TestNavigation(t *testing.T) {
 		if tc.event != nil {
 			t.Logf("Simulating keypress: %s\n", config.KeyChordFromEvent(*tc.event))
 			ts.InjectKey(tc.event.Key(), tc.event.Rune(), tc.event.Modifiers())
+		}
+
+		// Wait for app to process the queued event and force it to re-draw the
+		// screen. The header is drawn before the layout adapts to a new screen
+		// size, so the first draw after resizing may be stale too.
+		//
+		// TODO: Is there a better way to do this?
+		time.Sleep(50 * time.Millisecond)
+		app.QueueUpdateDraw(func() {})
+
+		app.QueueUpdate(func() {
+			t.Logf("Simulated screen state:\n%s", ts.String())
+			assert.Regexp(t, tc.search, ts.String())
+		})
+	}
+
+	ts.InjectKey(tcell.KeyRune, 'q', tcell.ModNone)
+	assert.NoError(t, eg.Wait())
+}
+
+func TestGotoPage(t *testing.T) {
+	eg := new(errgroup.Group)
+
+	ts := newTestScreen(t)
+	app := newTestApp(t)
+	app.SetScreen(ts)
+
+	rc, _ := epub.OpenReader("../epub/_test_files/alice.epub")
+	defer rc.Close()
+
+	eg.Go(app.Run)
+
+	app.QueueUpdateDraw(func() {
+		ts.SetSize(80, 20)
+		app.OpenBook(rc.DefaultRendition())
+	})
+
+	for _, tc := range []struct {
+		keys   string
+		search string
+	}{
+		{"", "(?s)1 OF 4.*Cover"},
+		{"P", "Go to page:"},
+		{"5\r", `(?s)Presently she began again.*p\. \[5\]`},
+		{"P", "Go to page:"},
+		{"999\r", "Page not found: 999"},
+	} {
+		for _, r := range tc.keys {
+			if r == '\r' {
+				ts.InjectKey(tcell.KeyEnter, 0, tcell.ModNone)
+			} else {
+				ts.InjectKey(tcell.KeyRune, r, tcell.ModNone)
+			}
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		app.QueueUpdateDraw(func() {})
+
+		app.QueueUpdate(func() {
+			t.Logf("Simulated screen state:\n%s", ts.String())
+			assert.Regexp(t, tc.search, ts.String())
+		})
+	}
+
+	ts.InjectKey(tcell.KeyRune, 'q', tcell.ModNone)
+	assert.NoError(t, eg.Wait())
+}
+
+func TestCountsAndSequences(t *testing.T) {
+	eg := new(errgroup.Group)
+
+	ts := newTestScreen(t)
+	app := newTestApp(t)
+	app.SetScreen(ts)
+
+	rc, _ := epub.OpenReader("../epub/_test_files/alice.epub")
+	defer rc.Close()
+
+	eg.Go(app.Run)
+
+	app.QueueUpdateDraw(func() {
+		ts.SetSize(80, 20)
+		app.OpenBook(rc.DefaultRendition())
+	})
+
+	for _, tc := range []struct {
+		keys   string
+		search string
+	}{
+		{"", "(?s)1 OF 4.*Cover"},
+		{"3f", "(?s)4 OF 4.*Alt text: Cover"},
+		{"2", `(?s)4 OF 4.*\s2\s*$`},
+		{"b", "2 OF 4"},
+		{"3g", "(?s)1 OF 17.*CHAPTER I"},
+		{"99g", "Chapter not found: 99"},
+		{"]", `(?s)1 OF 17.*\s\]\s*$`},
+		{"]", "(?s)1 OF 22.*CHAPTER II"},
+		{"2[[", "(?s)1 OF 23.*Project Gutenberg"},
+		{"5j", "(?s)2 OF 23.*Title: Alice's Adventures"},
+		{"[\x1b", "2 OF 23"},
+		{"0%", "(?s)1 OF 4.*Cover"},
+	} {
+		for _, r := range tc.keys {
+			if r == '\x1b' {
+				ts.InjectKey(tcell.KeyEscape, 0, tcell.ModNone)
+			} else {
+				ts.InjectKey(tcell.KeyRune, r, tcell.ModNone)
+			}
+		}
+
+		assertScreen(t, app, ts, tc.search)
+	}
+
+	ts.InjectKey(tcell.KeyRune, 'q', tcell.ModNone)
+	assert.NoError(t, eg.Wait())
+}
+
+func TestShowWarnings(t *testing.T) {
+	eg := new(errgroup.Group)
+
+	ts := newTestScreen(t)
+	app := newTestApp(t)
+	app.SetScreen(ts)
+
+	rc, _ := epub.OpenReader("../epub/_test_files/alice.epub")
+	defer rc.Close()
+
+	eg.Go(app.Run)
+
+	app.QueueUpdateDraw(func() {
+		ts.SetSize(80, 20)
+		app.OpenBook(rc.DefaultRendition())
+		app.ShowWarnings([]error{
+			&epub.Error{Path: "OEBPS/content.opf", ID: "item99", Line: 7, Err: epub.ErrBadItemref},
+		})
+	})
 
-			// Wait for app to process the queued event and force it to re-draw the
-			// screen.
-			//
-			// TODO: Is there a better way to do this?
+	for _, tc := range []struct {
+		key    tcell.Key
+		search string
+	}{
+		{tcell.KeyNUL, `(?s)problems.*"item99"`},
+		// Keys are handled by the dialog until it is dismissed.
+		{tcell.KeyEnter, "(?s)1 OF 4.*Cover"},
+		{tcell.KeyPgDn, "2 OF 4"},
+	} {
+		if tc.key != tcell.KeyNUL {
+			ts.InjectKey(tc.key, 0, tcell.ModNone)
 			time.Sleep(50 * time.Millisecond)
 			app.QueueUpdateDraw(func() {})
 		}
@@ -126,3 +295,107 @@ func TestNavigation(t *testing.T) {
 	ts.InjectKey(tcell.KeyRune, 'q', tcell.ModNone)
 	assert.NoError(t, eg.Wait())
 }
+
+func TestBookProgress(t *testing.T) {
+	eg := new(errgroup.Group)
+
+	ts := newTestScreen(t)
+	app := newTestApp(t)
+	app.SetScreen(ts)
+
+	rc, _ := epub.OpenReader("../epub/_test_files/alice.epub")
+	defer rc.Close()
+
+	eg.Go(app.Run)
+
+	app.QueueUpdateDraw(func() {
+		ts.SetSize(80, 20)
+		app.OpenBook(rc.DefaultRendition())
+	})
+
+	// Wait for the book to be measured in the background.
+	measured := func() (done bool) {
+		app.QueueUpdate(func() { done = app.length != nil })
+		return done
+	}
+	for i := 0; i < 100 && !measured(); i++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	app.QueueUpdateDraw(func() {})
+	app.QueueUpdate(func() {
+		t.Logf("Simulated screen state:\n%s", ts.String())
+		assert.Regexp(t, `█*░+ 0%`, ts.String())
+		assert.NotRegexp(t, "left", ts.String())
+
+		// Reading a page in ten seconds counts; jumping ahead or idling does not.
+		start := time.Now()
+		app.lastRead = readingMark{}
+		app.trackReading(start)
+		app.Forward()
+		app.trackReading(start.Add(10 * time.Second))
+		app.ChapterNext()
+		app.trackReading(start.Add(20 * time.Second))
+		app.Forward()
+		app.trackReading(start.Add(time.Hour))
+		assert.Equal(t, 16, app.progress.ReadLines)
+		assert.Equal(t, 10*time.Second, app.progress.ReadTime)
+
+		app.progress.ReadLines = 1800
+		app.progress.ReadTime = 10 * time.Minute
+	})
+
+	app.QueueUpdateDraw(func() {})
+	app.QueueUpdate(func() {
+		t.Logf("Simulated screen state:\n%s", ts.String())
+		assert.Regexp(t, `2 OF 23 • \d+ min left in chapter`, ts.String())
+		assert.Regexp(t, `█*░+ \d+% • \d+ min left`, ts.String())
+	})
+
+	ts.InjectKey(tcell.KeyRune, 'q', tcell.ModNone)
+	assert.NoError(t, eg.Wait())
+}
+
+func TestBookIdentity(t *testing.T) {
+	eg := new(errgroup.Group)
+
+	ts := newTestScreen(t)
+	app := newTestApp(t)
+	app.SetScreen(ts)
+
+	rc, _ := epub.OpenReader("../epub/_test_files/alice.epub")
+	defer rc.Close()
+
+	eg.Go(app.Run)
+
+	book := rc.DefaultRendition()
+	var chapter int
+	app.QueueUpdateDraw(func() {
+		ts.SetSize(80, 20)
+		app.OpenBook(book)
+		app.ChapterNext()
+		app.saveProgress()
+		chapter = app.progress.Chapter
+	})
+
+	// Progress is found after the book's metadata is edited.
+	app.QueueUpdateDraw(func() {
+		book.Metadata.Title = "Alice (edited)"
+		book.Metadata.Identifier.Content = "placeholder"
+		app.OpenBook(book)
+	})
+
+	app.QueueUpdate(func() {
+		assert.Equal(t, chapter, app.progress.Chapter)
+
+		library, _ := app.store.LoadLibrary()
+		assert.Len(t, library, 1)
+		assert.NotEmpty(t, library[app.bookID()].Fingerprint)
+
+		aliases, _ := app.store.LoadAliases()
+		assert.Equal(t, app.bookID(), aliases["md5:"+library[app.bookID()].Fingerprint])
+	})
+
+	ts.InjectKey(tcell.KeyRune, 'q', tcell.ModNone)
+	assert.NoError(t, eg.Wait())
+}
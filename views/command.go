@@ -0,0 +1,485 @@
+package views
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/taylorskalyo/goreader/config"
+	"github.com/taylorskalyo/goreader/render"
+	"gopkg.in/yaml.v3"
+)
+
+// maxHistory is the number of command lines that are remembered.
+const maxHistory = 100
+
+// command is a command that can be entered at the command line.
+type command struct {
+	usage string
+
+	// run executes the command with its arguments.
+	run func(args []string) error
+
+	// complete returns candidates for the last argument, given the arguments
+	// typed so far.
+	complete func(args []string) []string
+}
+
+// commands maps command names to commands.
+type commands map[string]command
+
+// commandLine holds the state of the command line between prompts.
+type commandLine struct {
+	history []string
+
+	// completions holds the candidates being cycled through by repeated tab
+	// presses, and completed is the index of the one shown.
+	completions []string
+	completed   int
+}
+
+// initCommands initializes commands. Actions can also be entered as commands,
+// by name.
+func (app *Application) initCommands() {
+	app.commands = commands{
+		"chapter": {
+			usage: "chapter <number>",
+			run:   app.chapterCommand,
+		},
+		"goto": {
+			usage: "goto <percent>% | <page>",
+			run:   app.gotoCommand,
+		},
+		"bookmark": {
+			usage:    "bookmark add|go|remove <name> | bookmark list",
+			run:      app.bookmarkCommand,
+			complete: app.completeBookmark,
+		},
+		"set": {
+			usage:    "set [<setting>=<value>|default ...]",
+			run:      app.setCommand,
+			complete: app.completeSet,
+		},
+		"theme": {
+			usage:    "theme <name>",
+			run:      app.themeCommand,
+			complete: app.completeTheme,
+		},
+		"export": {
+			usage:    "export <format> <file>",
+			run:      app.exportCommand,
+			complete: completeFormat,
+		},
+		"quit": {
+			usage: "quit",
+			run: func([]string) error {
+				app.Stop()
+				return nil
+			},
+		},
+	}
+	app.commands["q"] = app.commands["quit"]
+}
+
+// Command prompts for a command line and executes it.
+func (app *Application) Command() {
+	input := app.prompt(":", func(text string) {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return
+		}
+
+		app.remember(text)
+		if err := app.execute(text); err != nil {
+			app.setStatus(err.Error())
+		}
+	})
+	if input == nil {
+		return
+	}
+
+	app.cmdline.completions = nil
+	history := len(app.cmdline.history)
+	input.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyTab:
+			input.SetText(app.complete(input.GetText()))
+			return nil
+		case tcell.KeyUp:
+			if history > 0 {
+				history--
+				input.SetText(app.cmdline.history[history])
+			}
+			return nil
+		case tcell.KeyDown:
+			if history < len(app.cmdline.history)-1 {
+				history++
+				input.SetText(app.cmdline.history[history])
+			} else {
+				history = len(app.cmdline.history)
+				input.SetText("")
+			}
+			return nil
+		}
+
+		app.cmdline.completions = nil
+
+		return event
+	})
+}
+
+// remember adds a command line to the history.
+func (app *Application) remember(text string) {
+	history := app.cmdline.history
+	if n := len(history); n > 0 && history[n-1] == text {
+		return
+	}
+
+	history = append(history, text)
+	if len(history) > maxHistory {
+		history = history[len(history)-maxHistory:]
+	}
+	app.cmdline.history = history
+}
+
+// execute runs a command line. The first word names either a command or an
+// action; an action may be followed by its argument.
+func (app *Application) execute(text string) error {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	name, args := fields[0], fields[1:]
+	if cmd, ok := app.commands[strings.ToLower(name)]; ok {
+		return cmd.run(args)
+	}
+
+	binding, err := config.ParseBinding(text)
+	if _, ok := config.LookupAction(name); !ok {
+		return fmt.Errorf("Unknown command: %s", name)
+	} else if err != nil {
+		return err
+	}
+
+	app.run(binding)
+
+	return nil
+}
+
+// complete completes the last word of a command line. The word is completed as
+// far as all candidates agree; if that adds nothing, repeated calls cycle
+// through the candidates.
+func (app *Application) complete(text string) string {
+	cl := &app.cmdline
+	if len(cl.completions) > 0 {
+		cl.completed = (cl.completed + 1) % len(cl.completions)
+		return cl.completions[cl.completed]
+	}
+
+	fields := strings.Fields(text)
+	if len(fields) == 0 || strings.HasSuffix(text, " ") {
+		fields = append(fields, "")
+	}
+
+	prefix := strings.Join(fields[:len(fields)-1], " ")
+	if prefix != "" {
+		prefix += " "
+	}
+	word := fields[len(fields)-1]
+
+	var candidates []string
+	for _, candidate := range app.candidates(fields) {
+		if strings.HasPrefix(strings.ToLower(candidate), strings.ToLower(word)) {
+			candidates = append(candidates, candidate)
+		}
+	}
+	sort.Strings(candidates)
+
+	if len(candidates) == 0 {
+		return text
+	} else if len(candidates) == 1 {
+		return prefix + candidates[0] + " "
+	}
+
+	if common := commonPrefix(candidates); len(common) > len(word) {
+		return prefix + common
+	}
+
+	for _, candidate := range candidates {
+		cl.completions = append(cl.completions, prefix+candidate+" ")
+	}
+	cl.completed = 0
+
+	return cl.completions[0]
+}
+
+// candidates returns possible values for the last of the given fields.
+func (app *Application) candidates(fields []string) []string {
+	if len(fields) == 1 {
+		names := []string{}
+		for name := range app.commands {
+			names = append(names, name)
+		}
+		for _, name := range config.ActionNames {
+			names = append(names, name)
+		}
+
+		return names
+	}
+
+	cmd, ok := app.commands[strings.ToLower(fields[0])]
+	if !ok || cmd.complete == nil {
+		return nil
+	}
+
+	return cmd.complete(fields[1:])
+}
+
+// commonPrefix returns the longest prefix shared by all strings.
+func commonPrefix(strs []string) string {
+	prefix := strs[0]
+	for _, s := range strs[1:] {
+		for !strings.HasPrefix(s, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+
+	return prefix
+}
+
+// usage returns an error describing how to use the named command.
+func (app *Application) usage(name string) error {
+	return fmt.Errorf("Usage: %s", app.commands[name].usage)
+}
+
+// chapterCommand navigates to a chapter by number.
+func (app *Application) chapterCommand(args []string) error {
+	if len(args) != 1 {
+		return app.usage("chapter")
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		return fmt.Errorf("Chapter not found: %s", args[0])
+	}
+
+	app.top(n)
+
+	return nil
+}
+
+// gotoCommand navigates to a percentage of the book, or to a page of the print
+// edition.
+func (app *Application) gotoCommand(args []string) error {
+	if len(args) != 1 {
+		return app.usage("goto")
+	}
+
+	if text := strings.TrimSuffix(args[0], "%"); text != args[0] {
+		percent, err := strconv.ParseFloat(text, 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return fmt.Errorf("Invalid percentage: %s", args[0])
+		}
+
+		app.gotoPercent(percent)
+		return nil
+	}
+
+	if !app.gotoPage(args[0]) {
+		return fmt.Errorf("Page not found: %s", args[0])
+	}
+
+	return nil
+}
+
+// bookmarkCommand adds, visits, removes, or lists bookmarks in the open book.
+func (app *Application) bookmarkCommand(args []string) error {
+	if len(args) == 0 {
+		return app.usage("bookmark")
+	}
+
+	name := strings.Join(args[1:], " ")
+	switch args[0] {
+	case "add":
+		app.addBookmark(name)
+	case "go":
+		b, ok := app.progress.Bookmark(name)
+		if !ok {
+			return fmt.Errorf("Bookmark not found: %s", name)
+		}
+
+		app.gotoChapter(b.Chapter)
+		app.setPosition(b.Position)
+	case "remove":
+		if !app.progress.RemoveBookmark(name) {
+			return fmt.Errorf("Bookmark not found: %s", name)
+		}
+		app.setStatus(fmt.Sprintf("Removed bookmark %s", name))
+	case "list":
+		if len(app.progress.Bookmarks) == 0 {
+			app.setStatus("No bookmarks")
+			return nil
+		}
+
+		names := make([]string, len(app.progress.Bookmarks))
+		for i, b := range app.progress.Bookmarks {
+			names[i] = b.Name
+		}
+		app.setStatus("Bookmarks: " + strings.Join(names, ", "))
+	default:
+		return app.usage("bookmark")
+	}
+
+	return nil
+}
+
+// completeBookmark completes bookmark subcommands and names.
+func (app *Application) completeBookmark(args []string) []string {
+	if len(args) == 1 {
+		return []string{"add", "go", "remove", "list"}
+	} else if len(args) == 2 && (args[0] == "go" || args[0] == "remove") {
+		names := []string{}
+		for _, b := range app.progress.Bookmarks {
+			if !strings.Contains(b.Name, " ") {
+				names = append(names, b.Name)
+			}
+		}
+
+		return names
+	}
+
+	return nil
+}
+
+// setCommand changes settings for the open book only, given as name=value.
+// Values are written as in the config file, and "default" reverts to the
+// config file's setting. Without arguments, it lists the settings changed for
+// the book.
+func (app *Application) setCommand(args []string) error {
+	if len(args) == 0 {
+		app.setStatus(formatSettings(app.progress.Settings))
+		return nil
+	}
+
+	for _, arg := range args {
+		name, text, ok := strings.Cut(arg, "=")
+		if !ok || name == "" {
+			return app.usage("set")
+		}
+
+		var value interface{}
+		if text != "default" {
+			if err := yaml.Unmarshal([]byte(text), &value); err != nil || value == nil {
+				return fmt.Errorf("Invalid %s: %s", name, text)
+			}
+		}
+
+		if err := app.changeSetting(name, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// completeSet completes setting names.
+func (app *Application) completeSet(args []string) []string {
+	names := config.SettingNames()
+	for i, name := range names {
+		names[i] = name + "="
+	}
+
+	return names
+}
+
+// themeCommand switches to a named theme. The theme from the config file is
+// named "default".
+func (app *Application) themeCommand(args []string) error {
+	if len(args) != 1 {
+		return app.usage("theme")
+	}
+
+	name := args[0]
+	if _, ok := app.config.Themes[name]; !ok && name != "default" {
+		return fmt.Errorf("Theme not found: %s", name)
+	}
+
+	return app.changeSetting("theme-name", name)
+}
+
+// completeTheme completes theme names.
+func (app *Application) completeTheme(args []string) []string {
+	if len(args) != 1 {
+		return nil
+	}
+
+	names := []string{"default"}
+	for name := range app.config.Themes {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// exportCommand writes the whole book to a file.
+func (app *Application) exportCommand(args []string) error {
+	if len(args) != 2 {
+		return app.usage("export")
+	}
+
+	format, err := render.ParseFormat(args[0])
+	if err != nil || format == render.FormatTview {
+		return fmt.Errorf("Unrecognized format: %s", args[0])
+	}
+
+	if err := app.export(format, args[1]); err != nil {
+		return fmt.Errorf("Export failed: %s", err)
+	}
+	app.setStatus(fmt.Sprintf("Exported to %s", args[1]))
+
+	return nil
+}
+
+// export renders the open book to a file in the given format.
+func (app *Application) export(format render.Format, name string) error {
+	if app.book == nil {
+		return errors.New("no book is open")
+	}
+
+	renderer := app.newRenderer()
+	renderer.SetFormat(format)
+
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	last := len(app.book.Spine.Itemrefs) - 1
+	if err := renderer.RenderChapters(context.Background(), 0, last, w); err != nil {
+		return err
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+// completeFormat completes export format names.
+func completeFormat(args []string) []string {
+	if len(args) != 1 {
+		return nil
+	}
+
+	return []string{"plain", "ansi", "markdown", "md"}
+}
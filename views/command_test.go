@@ -0,0 +1,84 @@
+package views
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/taylorskalyo/goreader/epub"
+	"golang.org/x/sync/errgroup"
+)
+
+func TestCommand(t *testing.T) {
+	eg := new(errgroup.Group)
+
+	ts := newTestScreen(t)
+	app := newTestApp(t)
+	app.SetScreen(ts)
+
+	rc, _ := epub.OpenReader("../epub/_test_files/alice.epub")
+	defer rc.Close()
+
+	eg.Go(app.Run)
+
+	app.QueueUpdateDraw(func() {
+		ts.SetSize(80, 20)
+		app.OpenBook(rc.DefaultRendition())
+	})
+
+	export := filepath.Join(t.TempDir(), "alice.md")
+	for _, tc := range []struct {
+		keys   string
+		search string
+	}{
+		{"", "(?s)1 OF 4.*Cover"},
+		{":", "(?m)^:"},
+		{"chapter 3\r", "(?s)1 OF 17.*CHAPTER I"},
+		{":nope\r", "Unknown command: nope"},
+		{":Forward 2\r", "3 OF 17"},
+		{":bookmark add rabbit hole\r", "Bookmarked rabbit hole"},
+		{":goto 0%\r", "(?s)1 OF 4.*Cover"},
+		{":bookmark go rabbit hole\r", "3 OF 17"},
+		{":book\tli\t\r", "Bookmarks: rabbit hole"},
+		{":ch\t\t\t2\r", "(?s)1 OF 23.*Project Gutenberg"},
+		{":\x1b[A\x1b[A\r", "Bookmarks: rabbit hole"},
+		{":theme nope\r", "Theme not found: nope"},
+		{":theme dark\r", "1 OF 23"},
+		{":set width=60\r", "(?m)^ {10}Alice's Adventures in Wonderland /"},
+	} {
+		for i := 0; i < len(tc.keys); i++ {
+			switch tc.keys[i] {
+			case '\r':
+				ts.InjectKey(tcell.KeyEnter, 0, tcell.ModNone)
+			case '\t':
+				ts.InjectKey(tcell.KeyTab, 0, tcell.ModNone)
+			case '\x1b':
+				// Only the up arrow escape sequence is used here.
+				ts.InjectKey(tcell.KeyUp, 0, tcell.ModNone)
+				i += 2
+			default:
+				ts.InjectKey(tcell.KeyRune, rune(tc.keys[i]), tcell.ModNone)
+			}
+		}
+
+		assertScreen(t, app, ts, tc.search)
+	}
+
+	// Key presses are handled in order, so the book is exported before the
+	// application quits.
+	for _, line := range []string{"export md " + export, "quit"} {
+		ts.InjectKey(tcell.KeyRune, ':', tcell.ModNone)
+		for _, r := range line {
+			ts.InjectKey(tcell.KeyRune, r, tcell.ModNone)
+		}
+		ts.InjectKey(tcell.KeyEnter, 0, tcell.ModNone)
+	}
+	assert.NoError(t, eg.Wait())
+
+	data, err := os.ReadFile(export)
+	if assert.NoError(t, err) {
+		assert.Regexp(t, `(?m)^CHAPTER I$`, string(data))
+	}
+}
@@ -0,0 +1,182 @@
+package views
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/taylorskalyo/goreader/config"
+)
+
+// sequenceTimeout is how long to wait for the rest of a key sequence when the
+// keys typed so far are also bound to an action on their own.
+const sequenceTimeout = time.Second
+
+// maxCount limits count prefixes, so that a mistyped count does not keep the
+// application busy repeating an action.
+const maxCount = 99999
+
+// keyTrie is a tree of key sequences. Each node is reached by pressing the
+// chords along the path from the root, and may be bound to an action.
+type keyTrie struct {
+	binding  config.Binding
+	bound    bool
+	children map[config.KeyChord]*keyTrie
+}
+
+// newKeyTrie builds a keyTrie from keybindings.
+func newKeyTrie(bindings config.Keybindings) *keyTrie {
+	root := &keyTrie{}
+	for sequence, binding := range bindings {
+		node := root
+		for _, chord := range sequence.Chords() {
+			if node.children == nil {
+				node.children = map[config.KeyChord]*keyTrie{}
+			}
+
+			next, ok := node.children[chord]
+			if !ok {
+				next = &keyTrie{}
+				node.children[chord] = next
+			}
+			node = next
+		}
+
+		node.binding = binding
+		node.bound = true
+	}
+
+	return root
+}
+
+// pendingKeys holds input that does not yet amount to an action: a count
+// prefix and the start of a key sequence.
+type pendingKeys struct {
+	count int
+	node  *keyTrie
+	typed []string
+
+	// generation is incremented whenever pending input is consumed, so that a
+	// timer started for earlier input can tell that it has expired.
+	generation int
+}
+
+// String renders pending input as it was typed, e.g. "10]".
+func (p pendingKeys) String() string {
+	var b strings.Builder
+	if p.count > 0 {
+		b.WriteString(strconv.Itoa(p.count))
+	}
+	b.WriteString(strings.Join(p.typed, ""))
+
+	return b.String()
+}
+
+// handleKey feeds a key press into the pending key sequence. Once the sequence
+// matches a binding, its action is run with any count typed before it.
+func (app *Application) handleKey(chord config.KeyChord) {
+	p := &app.pending
+	if p.node == nil {
+		p.node = app.keys
+	}
+
+	// Escape discards pending input rather than triggering its binding.
+	if chord.Key == tcell.KeyEscape && (p.count > 0 || p.node != app.keys) {
+		app.resetPending()
+		return
+	}
+
+	if app.isCountDigit(chord) {
+		p.count = p.count*10 + int(chord.Rune-'0')
+		if p.count > maxCount {
+			p.count = maxCount
+		}
+		app.showPending()
+		return
+	}
+
+	next, ok := p.node.children[chord]
+	if !ok {
+		// The keys typed so far are bound on their own, so run their action
+		// before handling the key that did not continue the sequence.
+		if p.node != app.keys && p.node.bound {
+			app.runPending()
+			app.handleKey(chord)
+			return
+		}
+
+		app.resetPending()
+		return
+	}
+
+	if len(next.children) == 0 {
+		count := p.count
+		app.resetPending()
+		app.run(next.binding.WithCount(count))
+		return
+	}
+
+	p.node = next
+	p.typed = append(p.typed, chord.String())
+	app.showPending()
+
+	if next.bound {
+		generation := p.generation
+		time.AfterFunc(sequenceTimeout, func() {
+			app.QueueUpdateDraw(func() {
+				if app.pending.generation == generation {
+					app.runPending()
+				}
+			})
+		})
+	}
+}
+
+// isCountDigit reports whether a chord continues, or starts, a count prefix.
+// A count cannot start with zero, or with a digit that is bound to an action.
+func (app *Application) isCountDigit(chord config.KeyChord) bool {
+	p := app.pending
+	if chord.Key != tcell.KeyRune || chord.ModMask != 0 || p.node != app.keys {
+		return false
+	} else if chord.Rune < '0' || chord.Rune > '9' {
+		return false
+	} else if p.count > 0 {
+		return true
+	}
+
+	_, bound := app.keys.children[chord]
+
+	return chord.Rune != '0' && !bound
+}
+
+// runPending runs the action bound to the keys typed so far.
+func (app *Application) runPending() {
+	node, count := app.pending.node, app.pending.count
+	app.resetPending()
+	if node != nil && node.bound {
+		app.run(node.binding.WithCount(count))
+	}
+}
+
+// run runs the function for a binding's action with its argument.
+func (app *Application) run(binding config.Binding) {
+	if fn, ok := app.actions[binding.Action]; ok {
+		fn(binding.Arg)
+	}
+}
+
+// resetPending discards pending input.
+func (app *Application) resetPending() {
+	app.pending = pendingKeys{
+		node:       app.keys,
+		generation: app.pending.generation + 1,
+	}
+
+	app.status = ""
+}
+
+// showPending shows pending input in the status bar.
+func (app *Application) showPending() {
+	app.setStatus(app.pending.String())
+}
@@ -0,0 +1,79 @@
+package views
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/taylorskalyo/goreader/config"
+	"github.com/taylorskalyo/goreader/epub"
+	"golang.org/x/sync/errgroup"
+)
+
+func TestBindingArguments(t *testing.T) {
+	eg := new(errgroup.Group)
+
+	ts := newTestScreen(t)
+	app := newTestApp(t)
+	app.SetScreen(ts)
+
+	rc, _ := epub.OpenReader("../epub/_test_files/alice.epub")
+	defer rc.Close()
+
+	app.config.Keybindings["5"] = config.Binding{Action: config.ActionGotoPercent, Arg: "50"}
+	app.config.Keybindings["M"] = config.Binding{Action: config.ActionBookmark, Arg: "rabbit hole"}
+	app.keys = newKeyTrie(app.config.Keybindings)
+
+	eg.Go(app.Run)
+
+	app.QueueUpdateDraw(func() {
+		ts.SetSize(80, 20)
+		app.OpenBook(rc.DefaultRendition())
+		app.gotoChapter(2)
+	})
+
+	// Wait for the book to be measured, so that percentages are of its lines.
+	measured := func() (done bool) {
+		app.QueueUpdate(func() { done = app.length != nil })
+		return done
+	}
+	for i := 0; i < 100 && !measured(); i++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	ctrlD := tcell.NewEventKey(tcell.KeyCtrlD, rune(tcell.KeyCtrlD), tcell.ModCtrl)
+	ctrlU := tcell.NewEventKey(tcell.KeyCtrlU, rune(tcell.KeyCtrlU), tcell.ModCtrl)
+	for _, tc := range []struct {
+		keys   []*tcell.EventKey
+		search string
+	}{
+		{nil, "(?s)1 OF 17.*CHAPTER I"},
+		{[]*tcell.EventKey{ctrlD}, "2 OF 17"},
+		{[]*tcell.EventKey{ctrlU}, "(?s)1 OF 17.*CHAPTER I"},
+		{[]*tcell.EventKey{runeKey('1'), runeKey('3'), ctrlD}, "2 OF 17"},
+		{[]*tcell.EventKey{runeKey('M')}, "Bookmarked rabbit hole"},
+		{[]*tcell.EventKey{runeKey('m')}, "Bookmark name:"},
+		{[]*tcell.EventKey{runeKey('x'), tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone)}, "Bookmarked x"},
+		{[]*tcell.EventKey{runeKey('5')}, "█████░░░░░ 50%"},
+		{[]*tcell.EventKey{runeKey('2'), runeKey('5')}, `(?m)^\s*25\s*$`},
+		{[]*tcell.EventKey{runeKey('%')}, "██░░░░░░░░ 25%"},
+	} {
+		for _, event := range tc.keys {
+			ts.InjectKey(event.Key(), event.Rune(), event.Modifiers())
+		}
+
+		assertScreen(t, app, ts, tc.search)
+	}
+
+	app.QueueUpdate(func() {
+		assert.Len(t, app.progress.Bookmarks, 2)
+	})
+
+	ts.InjectKey(tcell.KeyRune, 'q', tcell.ModNone)
+	assert.NoError(t, eg.Wait())
+}
+
+func runeKey(r rune) *tcell.EventKey {
+	return tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone)
+}
@@ -0,0 +1,205 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/taylorskalyo/goreader/config"
+)
+
+// Markers placed around text that may be shortened to fit a bar. They are
+// taken from the Unicode private use area, so should not appear in books.
+const (
+	shortenStart = "\uE000"
+	shortenEnd   = "\uE001"
+)
+
+// barFuncs are the functions available to bar templates.
+var barFuncs = template.FuncMap{
+	// shorten marks text that may be truncated if the bar is too narrow.
+	"shorten": func(text string) string {
+		return shortenStart + text + shortenEnd
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// barData holds the variables available to bar templates.
+type barData struct {
+	Title  string
+	Author string
+
+	// ChapterTitle is empty if the chapter does not appear in the table of
+	// contents. Chapter is numbered from 1.
+	ChapterTitle string
+	Chapter      int
+	Chapters     int
+
+	// Page and Pages count screens within the current chapter.
+	Page  int
+	Pages int
+
+	// PrintPage is the page number of the print edition, if known.
+	PrintPage string
+
+	// BookPercent is empty until the book has been measured.
+	BookPercent string
+	percent     float64
+
+	// TimeLeft and ChapterTimeLeft are empty until reading speed has been
+	// measured.
+	TimeLeft        string
+	ChapterTimeLeft string
+
+	Clock string
+}
+
+// Bar draws a progress bar of the given width for the whole book.
+func (d barData) Bar(width int) string {
+	return progressBar(d.percent, width)
+}
+
+// barTemplates holds the parsed templates of a bar.
+type barTemplates struct {
+	hidden bool
+	left   *template.Template
+	center *template.Template
+	right  *template.Template
+}
+
+// parseBar parses the templates of a bar.
+func parseBar(name string, bar config.Bar) (*barTemplates, error) {
+	t := &barTemplates{hidden: bar.Hidden}
+	for _, slot := range []struct {
+		name string
+		text string
+		tmpl **template.Template
+	}{
+		{"left", bar.Left, &t.left},
+		{"center", bar.Center, &t.center},
+		{"right", bar.Right, &t.right},
+	} {
+		tmpl, err := template.New(slot.name).Funcs(barFuncs).Parse(slot.text)
+		if err != nil {
+			return nil, fmt.Errorf("layout.%s.%s: %w", name, slot.name, err)
+		}
+		*slot.tmpl = tmpl
+	}
+
+	return t, nil
+}
+
+// render executes the bar's templates and arranges their output within the
+// given width. Text marked with shorten is truncated if the output would not
+// otherwise fit.
+func (t *barTemplates) render(data barData, width int) (string, error) {
+	var slots [3]string
+	for i, tmpl := range []*template.Template{t.left, t.center, t.right} {
+		var b strings.Builder
+		if err := tmpl.Execute(&b, data); err != nil {
+			return "", err
+		}
+		slots[i] = strings.ReplaceAll(b.String(), "\n", " ")
+	}
+
+	if width <= 0 {
+		return strings.TrimSpace(unmark(strings.Join(slots[:], " "))), nil
+	}
+
+	excess := barWidth(slots[:]...) - width
+	for i := range slots {
+		slots[i], excess = shorten(slots[i], excess)
+	}
+	left, center, right := slots[0], slots[1], slots[2]
+
+	lw, cw, rw := runewidth.StringWidth(left), runewidth.StringWidth(center), runewidth.StringWidth(right)
+
+	// Center the center slot within the whole width if possible, otherwise
+	// within the space between the left and right slots.
+	start := (width - cw) / 2
+	if start < lw+1 && lw > 0 {
+		start = lw + 1
+	}
+	if start+cw > width-rw-1 && rw > 0 {
+		start = width - rw - 1 - cw
+	}
+	if start < 0 || cw == 0 {
+		start = lw
+	}
+
+	var b strings.Builder
+	b.WriteString(left)
+	b.WriteString(strings.Repeat(" ", atLeast(start-lw, 0)))
+	b.WriteString(center)
+	if rw > 0 {
+		b.WriteString(strings.Repeat(" ", atLeast(width-rw-atLeast(start, lw)-cw, 1)))
+		b.WriteString(right)
+	}
+
+	return b.String(), nil
+}
+
+// barWidth returns the width of the given slots when arranged in a bar,
+// including the space between them.
+func barWidth(slots ...string) int {
+	width, count := 0, 0
+	for _, slot := range slots {
+		if w := runewidth.StringWidth(unmark(slot)); w > 0 {
+			width += w
+			count++
+		}
+	}
+
+	if count > 1 {
+		width += count - 1
+	}
+
+	return width
+}
+
+// shorten truncates text marked with shorten by up to excess cells. It returns
+// the shortened text and the number of cells by which it is still too wide.
+func shorten(text string, excess int) (string, int) {
+	var b strings.Builder
+	for {
+		start := strings.Index(text, shortenStart)
+		end := strings.Index(text, shortenEnd)
+		if start < 0 || end < start {
+			b.WriteString(text)
+			return b.String(), excess
+		}
+
+		b.WriteString(text[:start])
+		marked := text[start+len(shortenStart) : end]
+		w := runewidth.StringWidth(marked)
+		if excess > 0 {
+			target := atLeast(w-excess, 1)
+			marked = runewidth.Truncate(marked, target, "…")
+			excess -= w - runewidth.StringWidth(marked)
+		}
+		b.WriteString(marked)
+		text = text[end+len(shortenEnd):]
+	}
+}
+
+// unmark removes shorten markers from text.
+func unmark(text string) string {
+	return strings.NewReplacer(shortenStart, "", shortenEnd, "").Replace(text)
+}
+
+// atLeast returns n, or min if n is smaller.
+func atLeast(n, min int) int {
+	if n < min {
+		return min
+	}
+
+	return n
+}
+
+// clock formats the current time for display in a bar.
+func clock(now time.Time) string {
+	return now.Format("15:04")
+}
@@ -0,0 +1,101 @@
+package views
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/taylorskalyo/goreader/config"
+	"github.com/taylorskalyo/goreader/epub"
+	"golang.org/x/sync/errgroup"
+)
+
+func TestBarRender(t *testing.T) {
+	data := barData{
+		Title:       "Alice's Adventures in Wonderland",
+		Chapter:     3,
+		Chapters:    14,
+		BookPercent: "42%",
+		percent:     42,
+	}
+
+	for _, tc := range []struct {
+		name     string
+		bar      config.Bar
+		width    int
+		expected string
+	}{
+		{
+			name:     "center",
+			bar:      config.Bar{Center: "{{.Chapter}}/{{.Chapters}}"},
+			width:    20,
+			expected: "        3/14",
+		},
+		{
+			name:     "slots",
+			bar:      config.Bar{Left: "{{.Chapter}}", Center: "{{.BookPercent}}", Right: "{{.Chapters}}"},
+			width:    20,
+			expected: "3       42%       14",
+		},
+		{
+			name:     "shorten",
+			bar:      config.Bar{Left: "{{shorten .Title}}", Right: "{{.Bar 4}} {{.BookPercent}}"},
+			width:    24,
+			expected: "Alice's Advent… █░░░ 42%",
+		},
+		{
+			name:     "functions",
+			bar:      config.Bar{Center: "{{upper .Title}}"},
+			width:    0,
+			expected: "ALICE'S ADVENTURES IN WONDERLAND",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			bar, err := parseBar("footer", tc.bar)
+			if assert.NoError(t, err) {
+				actual, err := bar.render(data, tc.width)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, actual)
+			}
+		})
+	}
+
+	_, err := parseBar("header", config.Bar{Center: "{{.Title"})
+	assert.ErrorContains(t, err, "layout.header.center")
+}
+
+func TestLayout(t *testing.T) {
+	eg := new(errgroup.Group)
+
+	ts := newTestScreen(t)
+	app := newTestApp(t)
+	app.SetScreen(ts)
+	app.config.Layout = config.Layout{
+		Header: config.Bar{Hidden: true},
+		Footer: config.Bar{Left: "{{.Author}}", Right: "chapter {{.Chapter}} of {{.Chapters}}"},
+	}
+	assert.NoError(t, app.parseLayout())
+
+	rc, _ := epub.OpenReader("../epub/_test_files/alice.epub")
+	defer rc.Close()
+
+	eg.Go(app.Run)
+
+	app.QueueUpdateDraw(func() {
+		ts.SetSize(80, 20)
+		app.OpenBook(rc.DefaultRendition())
+	})
+
+	time.Sleep(50 * time.Millisecond)
+	app.QueueUpdateDraw(func() {})
+	app.QueueUpdate(func() {
+		t.Logf("Simulated screen state:\n%s", ts.String())
+		assert.Regexp(t, `^\s*Cover`, ts.String())
+		assert.NotRegexp(t, "OF", ts.String())
+		assert.Regexp(t, `Lewis Carroll +chapter 1 of 14`, ts.String())
+	})
+
+	ts.InjectKey(tcell.KeyRune, 'q', tcell.ModNone)
+	assert.NoError(t, eg.Wait())
+}
@@ -0,0 +1,83 @@
+package views
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/taylorskalyo/goreader/epub"
+	"github.com/taylorskalyo/goreader/render"
+)
+
+// bookLength holds the rendered length of each chapter of a book, in lines.
+type bookLength struct {
+	chapters []int
+	total    int
+}
+
+// measureBook renders every chapter of a book in order to count its lines.
+// The renderer should be configured like the reader's, so that line counts
+// match those of the text view.
+func measureBook(ctx context.Context, book *epub.Rootfile, renderer render.Renderer) (*bookLength, error) {
+	renderer.SetFormat(render.FormatPlain)
+
+	length := &bookLength{
+		chapters: make([]int, len(book.Spine.Itemrefs)),
+	}
+
+	var b lineCounter
+	for i := range book.Spine.Itemrefs {
+		b.reset()
+		if err := renderer.RenderChapter(ctx, i, &b); err != nil {
+			return nil, err
+		}
+
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		length.chapters[i] = b.count()
+		length.total += length.chapters[i]
+	}
+
+	return length, nil
+}
+
+// before returns the number of lines in the chapters preceding the given one.
+func (l bookLength) before(chapter int) int {
+	lines := 0
+	for i := 0; i < chapter && i < len(l.chapters); i++ {
+		lines += l.chapters[i]
+	}
+
+	return lines
+}
+
+// lineCounter is an io.Writer that counts the lines written to it.
+type lineCounter struct {
+	lines   int
+	partial bool
+}
+
+// Write implements io.Writer.
+func (c *lineCounter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		c.lines += bytes.Count(p, []byte("\n"))
+		c.partial = p[len(p)-1] != '\n'
+	}
+
+	return len(p), nil
+}
+
+// count returns the number of lines written, including an unterminated last
+// line.
+func (c lineCounter) count() int {
+	if c.partial {
+		return c.lines + 1
+	}
+
+	return c.lines
+}
+
+func (c *lineCounter) reset() {
+	*c = lineCounter{}
+}
@@ -0,0 +1,439 @@
+package views
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/taylorskalyo/goreader/config"
+	"github.com/taylorskalyo/goreader/state"
+)
+
+// librarySort determines the order in which books are listed in the library.
+type librarySort int
+
+const (
+	sortLastOpened librarySort = iota
+	sortTitle
+	sortAuthor
+	sortProgress
+)
+
+var librarySortNames = map[librarySort]string{
+	sortLastOpened: "last opened",
+	sortTitle:      "title",
+	sortAuthor:     "author",
+	sortProgress:   "progress",
+}
+
+// libraryBook is a book listed in the library.
+type libraryBook struct {
+	id string
+	state.Progress
+}
+
+// library is a view listing every book that has been read.
+type library struct {
+	panel
+
+	table *tview.Table
+
+	// input is the input field shown in place of the footer, if any.
+	input *tview.InputField
+
+	// name is shown in the header, and hints in the footer.
+	name  string
+	hints string
+
+	books []libraryBook
+	shown []libraryBook
+
+	// aliases are used to match books found in library roots to the books
+	// that have been read.
+	aliases state.Aliases
+
+	order librarySort
+	query string
+
+	// status is a transient message shown in the footer until the next key
+	// press.
+	status string
+}
+
+// ShowLibrary displays every book that has been read, along with its reading
+// progress. Selecting a book opens it.
+func (app *Application) ShowLibrary() {
+	app.showLibrary()
+}
+
+// showLibrary displays every book that has been read, and returns the library
+// view.
+func (app *Application) showLibrary() *library {
+	// Time spent browsing the library is not spent reading.
+	app.endSession(time.Now())
+
+	books, err := app.store.LoadLibrary()
+	if err != nil {
+		app.error("load library", err)
+	}
+
+	lib := app.newLibrary()
+	if lib.aliases, err = app.store.LoadAliases(); err != nil {
+		app.error("load library", err)
+	}
+	for id, progress := range books {
+		lib.books = append(lib.books, libraryBook{id: id, Progress: progress})
+	}
+
+	// List books found in library roots, even those that have not been read.
+	if index, err := state.LoadIndex(); err == nil {
+		lib.addIndex(index)
+	}
+	lib.refresh()
+
+	app.root.AddAndSwitchToPage(pageLibrary, lib, true)
+	app.SetFocus(lib.table)
+
+	// Rescanning can take a while, so show the cached index in the meantime.
+	if roots := app.config.Library.RootDirs(); len(roots) > 0 {
+		go func() {
+			index, result, err := state.ScanLibrary(roots)
+			if err == nil {
+				err = result.Err()
+			}
+			app.QueueUpdateDraw(func() {
+				if err != nil {
+					lib.setStatus(fmt.Sprintf("Could not scan library: %s", err))
+				}
+
+				lib.addIndex(index)
+				lib.refresh()
+			})
+		}()
+	}
+
+	return lib
+}
+
+// ShowRecent lists the n books that were opened most recently. Selecting a
+// book opens it.
+func (app *Application) ShowRecent(n int) {
+	app.endSession(time.Now())
+
+	books, err := app.store.LoadLibrary()
+	if err != nil {
+		app.error("load library", err)
+	}
+
+	lib := app.newLibrary()
+	lib.name = "Recent"
+	for _, id := range state.Recent(books, n) {
+		lib.books = append(lib.books, libraryBook{id: id, Progress: books[id]})
+	}
+	lib.refresh()
+
+	app.root.AddAndSwitchToPage(pageLibrary, lib, true)
+	app.SetFocus(lib.table)
+}
+
+// ReopenLast opens the book that was read most recently. If no book has been
+// read, the library is shown instead, and if the book's file cannot be found,
+// the user is asked where it went.
+func (app *Application) ReopenLast() {
+	books, err := app.store.LoadLibrary()
+	if err != nil {
+		app.error("load library", err)
+	}
+
+	recent := state.Recent(books, 1)
+	if len(recent) == 0 {
+		app.ShowLibrary()
+		return
+	}
+
+	book := libraryBook{id: recent[0], Progress: books[recent[0]]}
+	if _, err := os.Stat(book.Path); book.Path == "" || os.IsNotExist(err) {
+		app.relocateBook(app.showLibrary(), book)
+	} else {
+		app.Open(book.Path)
+	}
+}
+
+// Open opens the epub file specified by name. If it cannot be opened, the
+// library is shown along with the error.
+func (app *Application) Open(name string) {
+	if err := app.OpenFile(name); err != nil {
+		app.showLibrary().setStatus(fmt.Sprintf("Could not open %s: %s", name, err))
+	}
+}
+
+// addIndex adds books found by scanning library roots. Books that have already
+// been read gain a file path if theirs is unknown.
+func (lib *library) addIndex(index state.Index) {
+	known := map[string]int{}
+	books := map[string]state.Progress{}
+	for i, book := range lib.books {
+		known[book.id] = i
+		books[book.id] = book.Progress
+	}
+
+	for _, entry := range index.Entries() {
+		if entry.Error != "" {
+			continue
+		}
+
+		id, _ := lib.aliases.Resolve(entry.Keys(), books)
+		if i, ok := known[id]; ok {
+			if lib.books[i].Path == "" {
+				lib.books[i].Path = entry.Path
+			}
+			continue
+		}
+
+		book := libraryBook{
+			id: id,
+			Progress: state.Progress{
+				Title:       entry.Title,
+				Author:      entry.Author,
+				Path:        entry.Path,
+				Chapters:    entry.Chapters,
+				Fingerprint: entry.Fingerprint,
+			},
+		}
+		known[id] = len(lib.books)
+		books[id] = book.Progress
+		lib.books = append(lib.books, book)
+	}
+}
+
+// newLibrary builds an empty library view.
+func (app *Application) newLibrary() *library {
+	lib := &library{
+		table: tview.NewTable(),
+		name:  "Library",
+		hints: app.panelHints("Enter open", "/ filter", "s sort", "Esc back"),
+	}
+
+	lib.table.
+		SetSelectable(true, false).
+		SetFixed(1, 0).
+		SetSelectedFunc(func(row, _ int) {
+			if row > 0 && row <= len(lib.shown) {
+				app.openLibraryBook(lib, lib.shown[row-1])
+			}
+		})
+
+	lib.panel = app.newPanel(lib.table, app.closeLibrary, func(event *tcell.EventKey) *tcell.EventKey {
+		lib.status = ""
+		lib.updateFooter()
+
+		switch {
+		case event.Rune() == 's':
+			lib.order = (lib.order + 1) % librarySort(len(librarySortNames))
+			lib.refresh()
+		case event.Rune() == '/':
+			app.filterLibrary(lib)
+		default:
+			return event
+		}
+
+		return nil
+	})
+
+	return lib
+}
+
+// closeLibrary returns to the open book, or exits if there is none.
+func (app *Application) closeLibrary() {
+	if app.book == nil {
+		app.Stop()
+		return
+	}
+
+	app.root.RemovePage(pageLibrary)
+	app.SetFocus(app.text)
+}
+
+// filterLibrary prompts for text with which to filter the library. Books are
+// filtered as the user types.
+func (app *Application) filterLibrary(lib *library) {
+	input := tview.NewInputField().
+		SetLabel("Filter: ").
+		SetText(lib.query).
+		SetChangedFunc(func(text string) {
+			lib.query = text
+			lib.refresh()
+		})
+	input.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEsc {
+			lib.query = ""
+			lib.refresh()
+		}
+
+		app.dismissLibraryInput(lib)
+	})
+
+	app.showLibraryInput(lib, input)
+}
+
+// relocateBook prompts for the new location of a book whose file cannot be
+// found, and then opens it.
+func (app *Application) relocateBook(lib *library, book libraryBook) {
+	input := tview.NewInputField().
+		SetLabel(fmt.Sprintf("Locate %s: ", book.title())).
+		SetText(book.Path)
+	input.SetDoneFunc(func(key tcell.Key) {
+		path := config.ExpandPath(strings.TrimSpace(input.GetText()))
+		app.dismissLibraryInput(lib)
+		if key != tcell.KeyEnter || path == "" {
+			return
+		}
+
+		if err := app.OpenFile(path); err != nil {
+			lib.setStatus(fmt.Sprintf("Could not open %s: %s", path, err))
+		}
+	})
+
+	app.showLibraryInput(lib, input)
+}
+
+// showLibraryInput temporarily replaces the library's footer with an input
+// field.
+func (app *Application) showLibraryInput(lib *library, input *tview.InputField) {
+	app.dismissLibraryInput(lib)
+
+	lib.input = input
+	lib.input.
+		SetFieldBackgroundColor(tcell.ColorDefault).
+		SetBorderPadding(1, 0, 0, 0)
+
+	lib.RemoveItem(lib.footer)
+	lib.AddItem(lib.input, 2, 0, true)
+	app.SetFocus(lib.input)
+}
+
+// dismissLibraryInput removes the input field shown in the library, if any,
+// and restores the footer.
+func (app *Application) dismissLibraryInput(lib *library) {
+	if lib.input == nil {
+		return
+	}
+
+	lib.RemoveItem(lib.input)
+	lib.AddItem(lib.footer, 2, 0, false)
+	lib.input = nil
+	app.SetFocus(lib.table)
+}
+
+// openLibraryBook opens a book that was selected in the library. If the book's
+// file cannot be found, the user is asked where it went.
+func (app *Application) openLibraryBook(lib *library, book libraryBook) {
+	if _, err := os.Stat(book.Path); book.Path == "" || os.IsNotExist(err) {
+		app.relocateBook(lib, book)
+		return
+	}
+
+	if err := app.OpenFile(book.Path); err != nil {
+		lib.setStatus(fmt.Sprintf("Could not open %s: %s", book.Path, err))
+	}
+}
+
+// setStatus shows a message in the footer until the next key press.
+func (lib *library) setStatus(msg string) {
+	lib.status = msg
+	lib.updateFooter()
+}
+
+// refresh sorts and filters the list of books, then redraws the table. The
+// selected book remains selected if it is still shown.
+func (lib *library) refresh() {
+	selected := ""
+	if row, _ := lib.table.GetSelection(); row > 0 && row <= len(lib.shown) {
+		selected = lib.shown[row-1].id
+	}
+
+	query := strings.ToLower(lib.query)
+	lib.shown = lib.shown[:0]
+	for _, book := range lib.books {
+		if strings.Contains(strings.ToLower(book.title()), query) ||
+			strings.Contains(strings.ToLower(book.Author), query) {
+			lib.shown = append(lib.shown, book)
+		}
+	}
+
+	sort.SliceStable(lib.shown, func(i, j int) bool {
+		a, b := lib.shown[i], lib.shown[j]
+		switch lib.order {
+		case sortTitle:
+			return strings.ToLower(a.title()) < strings.ToLower(b.title())
+		case sortAuthor:
+			return strings.ToLower(a.Author) < strings.ToLower(b.Author)
+		case sortProgress:
+			return a.Percent() > b.Percent()
+		}
+
+		return a.LastOpened.After(b.LastOpened)
+	})
+
+	lib.table.Clear()
+	for col, heading := range []string{"Title", "Author", "Read", "Last opened"} {
+		lib.table.SetCell(0, col, tview.NewTableCell(heading).
+			SetAttributes(tcell.AttrBold).
+			SetSelectable(false))
+	}
+
+	for i, book := range lib.shown {
+		lastOpened := ""
+		if !book.LastOpened.IsZero() {
+			lastOpened = book.LastOpened.Local().Format("2006-01-02")
+		}
+
+		row := i + 1
+		lib.table.SetCell(row, 0, tview.NewTableCell(tview.Escape(book.title())).SetExpansion(1))
+		lib.table.SetCell(row, 1, tview.NewTableCell(tview.Escape(book.Author)).SetMaxWidth(30))
+		lib.table.SetCell(row, 2, tview.NewTableCell(fmt.Sprintf("%.0f%%", book.Percent())).SetAlign(tview.AlignRight))
+		lib.table.SetCell(row, 3, tview.NewTableCell(lastOpened))
+	}
+
+	lib.table.Select(1, 0)
+	for i, book := range lib.shown {
+		if book.id == selected {
+			lib.table.Select(i+1, 0)
+		}
+	}
+
+	header := fmt.Sprintf("%s • %d books • sorted by %s", lib.name, len(lib.shown), librarySortNames[lib.order])
+	if lib.query != "" {
+		header += fmt.Sprintf(" • matching \"%s\"", lib.query)
+	}
+	lib.header.SetText(tview.Escape(header))
+	lib.updateFooter()
+}
+
+// updateFooter populates the library's footer.
+func (lib *library) updateFooter() {
+	if lib.status != "" {
+		lib.footer.SetText(tview.Escape(lib.status))
+		return
+	}
+
+	lib.footer.SetText(lib.hints)
+}
+
+// title returns the book's title, falling back to its file name.
+func (book libraryBook) title() string {
+	if book.Title != "" {
+		return book.Title
+	}
+
+	if book.Path != "" {
+		return filepath.Base(book.Path)
+	}
+
+	return book.id
+}
@@ -0,0 +1,174 @@
+package views
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/stretchr/testify/assert"
+	"github.com/taylorskalyo/goreader/config"
+	"github.com/taylorskalyo/goreader/state"
+	"golang.org/x/sync/errgroup"
+)
+
+func TestLibrary(t *testing.T) {
+	eg := new(errgroup.Group)
+
+	ts := newTestScreen(t)
+	app := newTestApp(t)
+	app.SetScreen(ts)
+
+	path, err := filepath.Abs("../epub/_test_files/alice.epub")
+	assert.NoError(t, err)
+
+	for id, progress := range map[string]state.Progress{
+		"isbn:1": {
+			Title:      "A Tale of Two Cities",
+			Author:     "Charles Dickens",
+			Chapter:    4,
+			Chapters:   10,
+			LastOpened: time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC),
+		},
+		"isbn:2": {
+			Title:      "Moby Dick",
+			Author:     "Herman Melville",
+			Chapters:   100,
+			LastOpened: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		},
+		"URI:http://www.gutenberg.org/ebooks/28885": {
+			Title:      "Alice's Adventures in Wonderland",
+			Author:     "Lewis Carroll",
+			Path:       path,
+			Chapter:    1,
+			Chapters:   14,
+			LastOpened: time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC),
+		},
+	} {
+		assert.NoError(t, app.store.StoreProgress(id, progress))
+	}
+
+	eg.Go(app.Run)
+
+	app.QueueUpdateDraw(func() {
+		ts.SetSize(100, 20)
+		app.ShowLibrary()
+	})
+
+	for _, tc := range []struct {
+		keys   string
+		search string
+	}{
+		{"", `(?s)3 books • sorted by last opened.*Moby Dick.*Tale of Two Cities +Charles Dickens +40% +2024-01-02.*Alice's`},
+		{"s", `(?s)sorted by title.*Tale of Two Cities.*Alice's.*Moby Dick`},
+		{"/", "Filter:"},
+		{"lewis", `(?s)1 books • sorted by title • matching "lewis".*Alice's`},
+		{"\r", `Enter open`},
+		{"\r", `(?s)1 OF 23.*Project Gutenberg`},
+	} {
+		for _, r := range tc.keys {
+			if r == '\r' {
+				ts.InjectKey(tcell.KeyEnter, 0, tcell.ModNone)
+			} else {
+				ts.InjectKey(tcell.KeyRune, r, tcell.ModNone)
+			}
+		}
+
+		assertScreen(t, app, ts, tc.search)
+	}
+
+	ts.InjectKey(tcell.KeyRune, 'q', tcell.ModNone)
+	assert.NoError(t, eg.Wait())
+
+	library, err := app.store.LoadLibrary()
+	assert.NoError(t, err)
+	alice := library["URI:http://www.gutenberg.org/ebooks/28885"]
+	assert.Equal(t, path, alice.Path)
+	assert.True(t, alice.LastOpened.After(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestReopenLast(t *testing.T) {
+	eg := new(errgroup.Group)
+
+	ts := newTestScreen(t)
+	app := newTestApp(t)
+	app.SetScreen(ts)
+
+	path, err := filepath.Abs("../epub/_test_files/alice.epub")
+	assert.NoError(t, err)
+
+	for id, progress := range map[string]state.Progress{
+		"isbn:1": {
+			Title:      "A Tale of Two Cities",
+			Path:       "/missing/two-cities.epub",
+			LastOpened: time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC),
+		},
+		"URI:http://www.gutenberg.org/ebooks/28885": {
+			Title:      "Alice's Adventures in Wonderland",
+			Path:       "/missing/alice.epub",
+			Chapter:    1,
+			Chapters:   14,
+			LastOpened: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		},
+	} {
+		assert.NoError(t, app.store.StoreProgress(id, progress))
+	}
+
+	eg.Go(app.Run)
+
+	app.QueueUpdateDraw(func() {
+		ts.SetSize(100, 20)
+		app.ShowRecent(1)
+	})
+	assertScreen(t, app, ts, `(?s)Recent • 1 books.*Alice's`)
+
+	// The last book has moved, so reopening it asks where it went.
+	app.QueueUpdateDraw(app.ReopenLast)
+	assertScreen(t, app, ts, `Locate Alice's Adventures in Wonderland: /missing/alice\.epub`)
+
+	app.QueueUpdateDraw(func() {
+		app.Application.GetFocus().(*tview.InputField).SetText(path)
+	})
+	ts.InjectKey(tcell.KeyEnter, 0, tcell.ModNone)
+	assertScreen(t, app, ts, `(?s)1 OF 23.*Project Gutenberg`)
+
+	// Books that cannot be opened are reported in the library.
+	app.QueueUpdateDraw(func() { app.Open("/missing/book.epub") })
+	assertScreen(t, app, ts, `Could not open /missing/book\.epub: open /missing/book\.epub: no such file`)
+
+	ts.InjectKey(tcell.KeyRune, 'q', tcell.ModNone)
+	assert.NoError(t, eg.Wait())
+
+	library, err := app.store.LoadLibrary()
+	assert.NoError(t, err)
+	assert.Equal(t, path, library["URI:http://www.gutenberg.org/ebooks/28885"].Path)
+}
+
+func TestLibraryExitKey(t *testing.T) {
+	eg := new(errgroup.Group)
+
+	ts := newTestScreen(t)
+	app := newTestApp(t)
+	app.SetScreen(ts)
+
+	// Exit is moved from q to x, leaving q unbound.
+	cfg := config.Default()
+	delete(cfg.Keybindings, "q")
+	cfg.Keybindings["x"] = config.Binding{Action: config.ActionExit}
+	app.setConfig(&cfg)
+
+	eg.Go(app.Run)
+
+	app.QueueUpdateDraw(func() {
+		ts.SetSize(100, 20)
+		app.ShowLibrary()
+	})
+	assertScreen(t, app, ts, `Enter open • / filter • s sort • Esc back • x quit`)
+
+	ts.InjectKey(tcell.KeyRune, 'q', tcell.ModNone)
+	assertScreen(t, app, ts, `0 books`)
+
+	ts.InjectKey(tcell.KeyRune, 'x', tcell.ModNone)
+	assert.NoError(t, eg.Wait())
+}
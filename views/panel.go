@@ -0,0 +1,89 @@
+package views
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/taylorskalyo/goreader/config"
+)
+
+// panel is a full-screen view, such as the library, with a header and a
+// footer around a body that handles its own keys.
+type panel struct {
+	*tview.Flex
+
+	header *tview.TextView
+	footer *tview.TextView
+}
+
+// panelBody is a primitive whose key presses can be intercepted.
+type panelBody interface {
+	tview.Primitive
+	SetInputCapture(capture func(event *tcell.EventKey) *tcell.EventKey) *tview.Box
+}
+
+// newPanel lays out a panel around body. Escape calls back and keys bound to
+// the Exit action quit, as in every panel. Other keys are passed to handle, if
+// it is not nil, which returns nil for the keys that it handles.
+func (app *Application) newPanel(body panelBody, back func(), handle func(event *tcell.EventKey) *tcell.EventKey) panel {
+	p := panel{
+		Flex:   tview.NewFlex().SetDirection(tview.FlexRow),
+		header: tview.NewTextView(),
+		footer: tview.NewTextView(),
+	}
+
+	p.header.
+		SetTextAlign(tview.AlignCenter).
+		SetWrap(false).
+		SetBorderPadding(0, 1, 0, 0)
+	p.footer.
+		SetTextAlign(tview.AlignCenter).
+		SetWrap(false).
+		SetBorderPadding(1, 0, 0, 0)
+
+	body.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		chord := config.KeyChordFromEvent(*event)
+		if chord.Key == tcell.KeyEscape {
+			back()
+			return nil
+		}
+
+		if node, ok := app.keys.children[chord]; ok && node.bound && node.binding.Action == config.ActionExit {
+			app.Stop()
+			return nil
+		}
+
+		if handle != nil {
+			return handle(event)
+		}
+
+		return event
+	})
+
+	p.Flex.
+		AddItem(p.header, 2, 0, false).
+		AddItem(body, 0, 1, true).
+		AddItem(p.footer, 2, 0, false)
+
+	return p
+}
+
+// panelHints joins descriptions of the keys a panel handles for its footer,
+// ending with the key that quits, if any.
+func (app *Application) panelHints(hints ...string) string {
+	quit := []string{}
+	for chord, node := range app.keys.children {
+		if node.bound && node.binding.Action == config.ActionExit && chord.Key != tcell.KeyEscape {
+			quit = append(quit, chord.String())
+		}
+	}
+	sort.Strings(quit)
+
+	if len(quit) > 0 {
+		hints = append(hints, quit[0]+" quit")
+	}
+
+	return strings.Join(hints, " • ")
+}
@@ -0,0 +1,50 @@
+package views
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// prompt temporarily replaces the footer with an input field. The done
+// function is called with the entered text once the user presses Enter. The
+// prompt is dismissed without calling done if the user presses Escape. The
+// input field is returned, or nil if a prompt is already shown.
+func (app *Application) prompt(label string, done func(text string)) *tview.InputField {
+	if app.input != nil {
+		return nil
+	}
+
+	app.input = tview.NewInputField().
+		SetLabel(label).
+		SetFieldBackgroundColor(tcell.ColorDefault).
+		SetDoneFunc(func(key tcell.Key) {
+			text := app.input.GetText()
+			if key != tcell.KeyEnter && key != tcell.KeyEscape {
+				return
+			}
+
+			app.dismissPrompt()
+			if key == tcell.KeyEnter {
+				done(text)
+			}
+		})
+	app.input.SetBorderPadding(1, 0, 0, 0)
+
+	app.container.RemoveItem(app.footer)
+	app.container.AddItem(app.input, 2, 0, true)
+	app.SetFocus(app.input)
+
+	return app.input
+}
+
+// dismissPrompt removes the input field and restores the footer.
+func (app *Application) dismissPrompt() {
+	if app.input == nil {
+		return
+	}
+
+	app.container.RemoveItem(app.input)
+	app.container.AddItem(app.footer, 2, 0, false)
+	app.SetFocus(app.text)
+	app.input = nil
+}
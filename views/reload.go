@@ -0,0 +1,102 @@
+package views
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/taylorskalyo/goreader/config"
+	"github.com/taylorskalyo/goreader/display"
+)
+
+// configPollInterval is how often the config file is checked for changes.
+const configPollInterval = time.Second
+
+// configStamp identifies a version of the config file, by when it was last
+// modified and its size. Its zero value stands for a missing file.
+type configStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// statConfig returns the stamp of the config file at path as it is now.
+func statConfig(path string) configStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return configStamp{}
+	}
+
+	return configStamp{modTime: info.ModTime(), size: info.Size()}
+}
+
+// watchConfig reloads the config whenever the config file at path is created,
+// changed, or removed, until done is closed. The file is polled rather than
+// watched, so that editors that replace the file rather than writing to it are
+// noticed too.
+func (app *Application) watchConfig(path string, done <-chan struct{}) {
+	ticker := time.NewTicker(configPollInterval)
+	defer ticker.Stop()
+
+	last := statConfig(path)
+	for {
+		select {
+		case <-ticker.C:
+			if stamp := statConfig(path); stamp != last {
+				last = stamp
+				app.queueUpdateDraw(done, app.Reload)
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// loadConfig loads and checks the config file.
+func loadConfig() (*config.Config, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return cfg, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+
+	if err := cfg.CheckBooks(); err != nil {
+		return cfg, err
+	}
+
+	for _, binding := range cfg.Keybindings {
+		if binding.Action == config.ActionExit {
+			return cfg, nil
+		}
+	}
+
+	return cfg, errors.New("no keybinding for Exit action")
+}
+
+// Reload loads the config file again and applies it: keybindings, layout,
+// and theme change, and the open chapter is rendered again at the same
+// position. If the config file cannot be loaded, the settings in use are kept
+// and the error is shown in the footer.
+func (app *Application) Reload() {
+	cfg, err := loadConfig()
+	if err != nil {
+		app.setStatus(fmt.Sprintf("Could not reload config: %s", display.OneLine(err.Error())))
+		return
+	}
+
+	app.userConfig = cfg
+	app.setStatus("Reloaded config")
+	if app.book != nil {
+		app.applySettings()
+		app.rerender()
+	} else {
+		app.setConfig(cfg)
+	}
+
+	if err := app.configureSync(); err != nil {
+		app.setStatus(fmt.Sprintf("Could not configure sync: %s", err))
+	}
+}
@@ -0,0 +1,69 @@
+package views
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taylorskalyo/goreader/config"
+	"github.com/taylorskalyo/goreader/epub"
+	"golang.org/x/sync/errgroup"
+)
+
+func TestReload(t *testing.T) {
+	eg := new(errgroup.Group)
+
+	ts := newTestScreen(t)
+	app := newTestApp(t)
+	app.SetScreen(ts)
+	require.NoError(t, os.MkdirAll(filepath.Dir(config.ConfigFile), 0755))
+
+	rc, _ := epub.OpenReader("../epub/_test_files/alice.epub")
+	defer rc.Close()
+
+	eg.Go(app.Run)
+
+	app.QueueUpdateDraw(func() {
+		ts.SetSize(80, 20)
+		app.OpenBook(rc.DefaultRendition())
+		app.ChapterNext()
+	})
+	assertScreen(t, app, ts, "1 OF 23")
+
+	// Changes to the config file are applied without losing the position.
+	require.NoError(t, os.WriteFile(config.ConfigFile, []byte(`
+keybindings:
+  x: Forward
+layout:
+  header: "RELOADED {{.Page}} OF {{.Pages}}"
+`), 0644))
+	assertScreen(t, app, ts, "(?s)RELOADED 1 OF 23.*Reloaded config")
+
+	ts.InjectKey(tcell.KeyRune, 'x', tcell.ModNone)
+	assertScreen(t, app, ts, "RELOADED 2 OF 23")
+
+	// Errors are shown in the footer, and the settings in use are kept.
+	require.NoError(t, os.WriteFile(config.ConfigFile, []byte("keybindings: [\n"), 0644))
+	assertScreen(t, app, ts, "(?s)RELOADED 2 OF 23.*Could not reload config: yaml:")
+
+	require.NoError(t, os.WriteFile(config.ConfigFile, []byte("width: 70\n"), 0644))
+	assertScreen(t, app, ts, "(?s)OF.*Reloaded config")
+	assertScreen(t, app, ts, "(?m)^ {5}[^ ]")
+
+	// The config can also be reloaded by command.
+	for _, r := range ":reload" {
+		ts.InjectKey(tcell.KeyRune, r, tcell.ModNone)
+	}
+	ts.InjectKey(tcell.KeyEnter, 0, tcell.ModNone)
+	assertScreen(t, app, ts, "Reloaded config")
+
+	app.QueueUpdate(func() {
+		assert.Equal(t, 70, app.config.Width)
+	})
+
+	ts.InjectKey(tcell.KeyRune, 'q', tcell.ModNone)
+	assert.NoError(t, eg.Wait())
+}
@@ -0,0 +1,78 @@
+package views
+
+import (
+	"time"
+
+	"github.com/taylorskalyo/goreader/state"
+)
+
+// minSession is how long a session must last to be recorded if no lines were
+// read during it, so that briefly opening a book is not counted as reading.
+const minSession = time.Minute
+
+// extendSession notes that the reader was active at a given time, starting a
+// new reading session if there is none.
+func (app *Application) extendSession(now time.Time) {
+	s := &app.session
+	if s.Start.IsZero() {
+		*s = state.Session{
+			ID:           app.bookID(),
+			Title:        app.book.Title,
+			Start:        now,
+			FirstChapter: app.progress.Chapter,
+		}
+	}
+
+	s.End = now
+	s.LastChapter = app.progress.Chapter
+}
+
+// checkIdle ends the reading session if the reader has been idle for too long.
+// The session is taken to have ended when the reader was last active.
+func (app *Application) checkIdle(now time.Time) {
+	if !app.session.Start.IsZero() && now.Sub(app.session.End) > idleLimit {
+		app.endSession(app.session.End)
+	}
+}
+
+// endSession records the reading session, if any, as having ended at a given
+// time. If the reader has been idle since, it ended when they were last
+// active instead.
+func (app *Application) endSession(now time.Time) {
+	s, ok := app.currentSession(now)
+	app.session = state.Session{}
+	if !ok || s.Lines == 0 && s.Duration() < minSession {
+		return
+	}
+
+	if err := app.store.RecordSession(s); err != nil {
+		app.error("record session", err)
+	}
+}
+
+// currentSession returns the reading session in progress, as if it ended at a
+// given time, and reports whether there is one.
+func (app *Application) currentSession(now time.Time) (state.Session, bool) {
+	s := app.session
+	if s.Start.IsZero() {
+		return s, false
+	}
+
+	if now.After(s.End) && now.Sub(s.End) <= idleLimit {
+		s.End = now
+	}
+
+	return s, true
+}
+
+// checkFinished records when the end of the book is first reached.
+func (app *Application) checkFinished(now time.Time) {
+	if !app.progress.Finished.IsZero() || app.progress.Chapter < len(app.book.Spine.Itemrefs)-1 {
+		return
+	}
+
+	top, height := app.viewport()
+	if height > 0 && top+height >= app.linecount {
+		app.progress.Finished = now
+	}
+}
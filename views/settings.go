@@ -0,0 +1,132 @@
+package views
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/taylorskalyo/goreader/config"
+	"github.com/taylorskalyo/goreader/display"
+	"github.com/taylorskalyo/goreader/render"
+)
+
+// newRenderer returns a renderer for the open book, configured with the
+// current settings.
+func (app *Application) newRenderer() render.Renderer {
+	renderer := render.New(&app.book.Package)
+	renderer.SetTheme(app.theme())
+	renderer.SetWidth(app.config.Width)
+	renderer.SetImages(app.config.Images)
+
+	return renderer
+}
+
+// setConfig switches to a configuration, rebuilding keybindings and the
+// layout. The open chapter is not rendered again. Pending input is discarded,
+// but not the status message, which may explain a problem with the config.
+func (app *Application) setConfig(cfg *config.Config) {
+	app.config = cfg
+	app.keys = newKeyTrie(cfg.Keybindings)
+	app.pending = pendingKeys{
+		node:       app.keys,
+		generation: app.pending.generation + 1,
+	}
+
+	if err := app.parseLayout(); err != nil {
+		app.setStatus(fmt.Sprintf("Invalid layout, using the default: %s", display.OneLine(err.Error())))
+		cfg.Layout = config.DefaultLayout()
+		_ = app.parseLayout()
+	}
+
+	app.reader.ResizeItem(app.container, cfg.Width, 0)
+}
+
+// bookConfig returns the configuration for the open book: the config file,
+// overridden by its settings for the book and then by the given settings,
+// which were changed while reading it. The config file's settings for a book
+// are applied from its least to most specific key.
+func (app *Application) bookConfig(settings config.Overrides) (*config.Config, error) {
+	overrides := []config.Overrides{}
+	seen := map[string]bool{}
+	for _, key := range append(app.bookKeys.List(), app.id) {
+		if o, ok := app.userConfig.Books[key]; ok && !seen[key] {
+			overrides = append(overrides, o)
+		}
+		seen[key] = true
+	}
+	overrides = append(overrides, settings)
+
+	return app.userConfig.Override(overrides...)
+}
+
+// applySettings switches to the configuration for the open book. If its
+// settings cannot be applied, the config file is used as is.
+func (app *Application) applySettings() {
+	cfg, err := app.bookConfig(app.progress.Settings)
+	if err != nil {
+		app.setStatus(fmt.Sprintf("Could not apply settings for this book: %s", err))
+		cfg = app.userConfig
+	}
+
+	app.setConfig(cfg)
+}
+
+// changeSetting changes a setting for the open book only, and renders the
+// book again with it. A nil value reverts to the config file's setting. The
+// setting is saved along with reading progress.
+func (app *Application) changeSetting(name string, value interface{}) error {
+	known := false
+	for _, n := range config.SettingNames() {
+		known = known || n == name
+	}
+	if !known && config.IsSetting(name) {
+		return fmt.Errorf("%s cannot be set for this book", name)
+	} else if !known {
+		return fmt.Errorf("Unknown setting: %s", name)
+	}
+
+	// Settings are replaced rather than changed in place, so that the last
+	// saved progress does not change along with them.
+	settings := config.Overrides{}
+	for k, v := range app.progress.Settings {
+		settings[k] = v
+	}
+	if value == nil {
+		delete(settings, name)
+	} else {
+		settings[name] = value
+	}
+
+	cfg, err := app.bookConfig(settings)
+	if err != nil {
+		return fmt.Errorf("Invalid %s: %s", name, strings.TrimPrefix(err.Error(), "yaml: "))
+	}
+
+	app.progress.Settings = settings
+	if len(settings) == 0 {
+		app.progress.Settings = nil
+	}
+	app.setConfig(cfg)
+	app.rerender()
+
+	return nil
+}
+
+// formatSettings lists the settings changed for the open book.
+func formatSettings(settings map[string]interface{}) string {
+	if len(settings) == 0 {
+		return "No settings changed for this book"
+	}
+
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for i, name := range names {
+		names[i] = fmt.Sprintf("%s=%v", name, settings[name])
+	}
+
+	return strings.Join(names, " ")
+}
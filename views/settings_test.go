@@ -0,0 +1,99 @@
+package views
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taylorskalyo/goreader/config"
+	"github.com/taylorskalyo/goreader/epub"
+	"golang.org/x/sync/errgroup"
+)
+
+func TestBookSettings(t *testing.T) {
+	eg := new(errgroup.Group)
+
+	ts := newTestScreen(t)
+	app := newTestApp(t)
+	app.SetScreen(ts)
+
+	userConfig := config.Default()
+	userConfig.Books = map[string]config.Overrides{
+		"URI:http://www.gutenberg.org/ebooks/28885": {"width": 60},
+	}
+	app.userConfig = &userConfig
+
+	rc, _ := epub.OpenReader("../epub/_test_files/alice.epub")
+	defer rc.Close()
+
+	eg.Go(app.Run)
+
+	app.QueueUpdateDraw(func() {
+		ts.SetSize(80, 20)
+		app.OpenBook(rc.DefaultRendition())
+		assert.Equal(t, 60, app.config.Width)
+	})
+
+	for _, tc := range []struct {
+		line   string
+		search string
+	}{
+		{"set", "No settings changed for this book"},
+		{"set width=10", "Invalid width"},
+		{"set colour=red", "Unknown setting: colour"},
+		{"set storage=bolt", "storage cannot be set for this book"},
+		{"set images=false width=70", "(?m)^ {5}Alice's Adventures in Wonderland /"},
+		{"set", "images=false width=70"},
+		{"set width=default", "(?m)^ {10}Alice's Adventures in Wonderland /"},
+		{"theme dark", "(?s)1 OF 1 .*Alt text: Cover"},
+		{"set", "images=false theme-name=dark"},
+	} {
+		ts.InjectKey(tcell.KeyRune, ':', tcell.ModNone)
+		for _, r := range tc.line {
+			ts.InjectKey(tcell.KeyRune, r, tcell.ModNone)
+		}
+		ts.InjectKey(tcell.KeyEnter, 0, tcell.ModNone)
+
+		assertScreen(t, app, ts, tc.search)
+	}
+
+	// Settings changed while reading are saved with the book, and are applied
+	// when it is opened again. They do not change the config file's settings.
+	app.QueueUpdateDraw(func() {
+		app.saveProgress()
+		app.setConfig(app.userConfig)
+		app.OpenBook(rc.DefaultRendition())
+
+		assert.Equal(t, 60, app.config.Width)
+		assert.False(t, app.config.Images)
+		assert.Equal(t, "dark", app.config.ThemeName)
+		assert.Equal(t, 80, app.userConfig.Width)
+		assert.True(t, app.userConfig.Images)
+	})
+
+	ts.InjectKey(tcell.KeyRune, 'q', tcell.ModNone)
+	assert.NoError(t, eg.Wait())
+}
+
+func TestConfigureBooks(t *testing.T) {
+	app := newTestApp(t)
+	require.NoError(t, os.MkdirAll(filepath.Dir(config.ConfigFile), 0755))
+	require.NoError(t, os.WriteFile(config.ConfigFile, []byte(`
+width: 70
+books:
+  "title:Alice":
+    width: 60
+    storage:
+      backend: bolt
+`), 0644))
+
+	// Settings that cannot be set for individual books are reported and
+	// dropped, and the rest of the config is used as is.
+	require.NoError(t, app.Configure())
+	assert.Equal(t, "Invalid config, ignoring settings: books: title:Alice: storage cannot be set for individual books", app.status)
+	assert.Equal(t, 70, app.userConfig.Width)
+	assert.Equal(t, config.Overrides{"width": 60}, app.userConfig.Books["title:Alice"])
+}
@@ -0,0 +1,83 @@
+package views
+
+import (
+	"strings"
+	"time"
+)
+
+// idleLimit is how long the viewport can stay still before the user is assumed
+// to have stopped reading. Time spent idle does not count towards reading
+// speed, and ends the reading session.
+const idleLimit = 5 * time.Minute
+
+// readingMark records where the viewport was and when it got there.
+type readingMark struct {
+	chapter int
+	line    int
+	at      time.Time
+}
+
+// trackReading measures reading speed as the viewport moves forward. Jumps of
+// more than a page (e.g. to another chapter) are not counted as reading.
+func (app *Application) trackReading(now time.Time) {
+	r, _ := app.text.GetScrollOffset()
+	_, _, _, height := app.text.GetRect()
+
+	app.checkIdle(now)
+
+	last := app.lastRead
+	if last.chapter == app.progress.Chapter && last.line == r && !last.at.IsZero() {
+		return
+	}
+	app.lastRead = readingMark{chapter: app.progress.Chapter, line: r, at: now}
+	app.extendSession(now)
+
+	lines := r - last.line
+	elapsed := now.Sub(last.at)
+	if last.at.IsZero() || last.chapter != app.progress.Chapter ||
+		lines <= 0 || lines > height || elapsed > idleLimit {
+		return
+	}
+
+	app.progress.ReadLines += lines
+	app.progress.ReadTime += elapsed
+	app.session.Lines += lines
+	app.session.Pages += float64(lines) / float64(height)
+}
+
+// linesPerMinute returns the measured reading speed. It is not reported until
+// at least a minute of reading has been measured.
+func (app *Application) linesPerMinute() (float64, bool) {
+	if app.progress.ReadTime < time.Minute || app.progress.ReadLines < 1 {
+		return 0, false
+	}
+
+	return float64(app.progress.ReadLines) / app.progress.ReadTime.Minutes(), true
+}
+
+// timeLeft estimates how long it will take to read the given number of lines.
+func (app *Application) timeLeft(lines int) (time.Duration, bool) {
+	speed, ok := app.linesPerMinute()
+	if !ok {
+		return 0, false
+	}
+
+	if lines < 0 {
+		lines = 0
+	}
+
+	return time.Duration(float64(lines) / speed * float64(time.Minute)), true
+}
+
+// progressBar draws a bar of the given width, filled in proportion to
+// percent.
+func progressBar(percent float64, width int) string {
+	filled := int(percent / 100 * float64(width))
+	if filled > width {
+		filled = width
+	} else if filled < 0 {
+		filled = 0
+	}
+
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
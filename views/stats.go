@@ -0,0 +1,116 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rivo/tview"
+	"github.com/taylorskalyo/goreader/display"
+	"github.com/taylorskalyo/goreader/state"
+)
+
+// recentSessions is the number of reading sessions listed in the stats view.
+const recentSessions = 10
+
+// Stats displays statistics about reading sessions in the open book and in
+// every book, including the session in progress.
+func (app *Application) Stats() {
+	if app.book == nil {
+		return
+	}
+
+	now := time.Now()
+	sessions, err := app.store.LoadSessions()
+	if err != nil {
+		app.error("load sessions", err)
+	}
+	if s, ok := app.currentSession(now); ok {
+		sessions = append(sessions, s)
+	}
+
+	library, err := app.store.LoadLibrary()
+	if err != nil || library == nil {
+		library = map[string]state.Progress{}
+	}
+	library[app.bookID()] = app.progress
+
+	stats := state.Summarize(sessions, library, now)
+
+	body := tview.NewTextView().
+		SetWrap(false).
+		SetText(tview.Escape(formatStats(stats, app.bookID(), sessions)))
+
+	panel := app.newPanel(body, func() {
+		app.root.RemovePage(pageStats)
+		app.SetFocus(app.text)
+	}, nil)
+	panel.header.SetText(tview.Escape(fmt.Sprintf("Statistics • %s", truncate(app.book.Title, 40))))
+	panel.footer.SetText(app.panelHints("Esc back"))
+
+	app.root.AddAndSwitchToPage(pageStats, panel, true)
+	app.SetFocus(body)
+}
+
+// formatStats describes reading statistics for the book with the given
+// identifier and for every book, and lists the most recent sessions.
+func formatStats(stats state.Stats, id string, sessions []state.Session) string {
+	var b strings.Builder
+
+	book := state.BookStats{ID: id}
+	for _, s := range stats.Books {
+		if s.ID == id {
+			book = s
+		}
+	}
+
+	fmt.Fprintln(&b, "This book")
+	fmt.Fprintf(&b, "  Time read       %s in %s\n", display.Duration(book.Time), display.Plural(book.Sessions, "session"))
+	fmt.Fprintf(&b, "  Pages read      %.0f, %.0f per hour\n", book.Pages, book.PagesPerHour())
+	if !book.FirstRead.IsZero() {
+		fmt.Fprintf(&b, "  Started         %s\n", book.FirstRead.Format("2006-01-02"))
+	}
+	if !book.Finished.IsZero() {
+		fmt.Fprintf(&b, "  Finished        %s\n", book.Finished.Format("2006-01-02"))
+	}
+
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "All books")
+	fmt.Fprintf(&b, "  Time read       %s in %s\n", display.Duration(stats.Time), display.Plural(stats.Sessions, "session"))
+	fmt.Fprintf(&b, "  Pages read      %.0f, %.0f per hour\n", stats.Pages, stats.PagesPerHour())
+	fmt.Fprintf(&b, "  Books finished  %d\n", stats.Finished)
+	fmt.Fprintf(&b, "  Streak          %s (longest %s)\n", display.Plural(stats.Streak, "day"), display.Plural(stats.LongestStreak, "day"))
+
+	if len(sessions) > 0 {
+		fmt.Fprintln(&b)
+		fmt.Fprintln(&b, "Recent sessions")
+
+		w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+		for i := len(sessions) - 1; i >= 0 && i >= len(sessions)-recentSessions; i-- {
+			s := sessions[i]
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n",
+				s.Start.Format("2006-01-02 15:04"),
+				display.Duration(s.Duration()),
+				display.Plural(int(s.Pages+0.5), "page"),
+				chapterRange(s.FirstChapter, s.LastChapter),
+				s.Title)
+		}
+		w.Flush()
+	}
+
+	return b.String()
+}
+
+// chapterRange describes the chapters read in a session.
+func chapterRange(first, last int) string {
+	if first > last {
+		first, last = last, first
+	}
+
+	if first == last {
+		return fmt.Sprintf("ch. %d", first+1)
+	}
+
+	return fmt.Sprintf("ch. %d–%d", first+1, last+1)
+}
@@ -0,0 +1,73 @@
+package views
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taylorskalyo/goreader/epub"
+	"github.com/taylorskalyo/goreader/state"
+	"golang.org/x/sync/errgroup"
+)
+
+func TestStats(t *testing.T) {
+	eg := new(errgroup.Group)
+
+	ts := newTestScreen(t)
+	app := newTestApp(t)
+	app.SetScreen(ts)
+
+	rc, _ := epub.OpenReader("../epub/_test_files/alice.epub")
+	defer rc.Close()
+
+	eg.Go(app.Run)
+
+	app.QueueUpdateDraw(func() {
+		ts.SetSize(80, 20)
+		app.OpenBook(rc.DefaultRendition())
+		app.gotoChapter(2)
+	})
+	assertScreen(t, app, ts, "(?s)1 OF 17.*CHAPTER I")
+
+	ts.InjectKey(tcell.KeyRune, 'f', tcell.ModNone)
+	assertScreen(t, app, ts, "2 OF 17")
+	ts.InjectKey(tcell.KeyRune, 'f', tcell.ModNone)
+	assertScreen(t, app, ts, "3 OF 17")
+
+	// The session in progress is included.
+	ts.InjectKey(tcell.KeyRune, 'S', tcell.ModNone)
+	assertScreen(t, app, ts, `(?s)Statistics.*This book\s+Time read.*1 session.*Streak\s+1 day.*Recent sessions\s+\S+ \S+\s+\S+ min\s+\d+ pages?\s+ch\. 3`)
+	ts.InjectKey(tcell.KeyEscape, 0, tcell.ModNone)
+	assertScreen(t, app, ts, "3 OF 17")
+
+	// A session ends once the reader has been idle for too long.
+	app.QueueUpdateDraw(func() {
+		app.session.Start = app.session.Start.Add(-2 * idleLimit)
+		app.session.End = app.session.End.Add(-2 * idleLimit)
+		app.lastRead.at = app.session.End
+	})
+
+	var sessions []state.Session
+	require.Eventually(t, func() bool {
+		sessions, _ = app.store.LoadSessions()
+		return len(sessions) == 1
+	}, 5*time.Second, 20*time.Millisecond)
+
+	s := sessions[0]
+	assert.Equal(t, 2, s.FirstChapter)
+	assert.Equal(t, 2, s.LastChapter)
+	assert.Greater(t, s.Lines, 0)
+	app.QueueUpdate(func() {
+		assert.True(t, app.session.Start.IsZero())
+	})
+
+	// Idle time is not counted when the book is closed.
+	app.Stop()
+	assert.NoError(t, eg.Wait())
+
+	sessions, err := app.store.LoadSessions()
+	require.NoError(t, err)
+	assert.Len(t, sessions, 1)
+}
@@ -0,0 +1,166 @@
+package views
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/taylorskalyo/goreader/state"
+)
+
+// syncTimeout limits how long requests to the sync server may take, so that an
+// unreachable server does not hold up opening or closing a book for long.
+const syncTimeout = 5 * time.Second
+
+// exitSyncTimeout limits how long goreader waits on exit for progress to reach
+// the sync server.
+const exitSyncTimeout = 2 * time.Second
+
+// pushes tracks progress being sent to the sync server in the background.
+// Once the application has stopped, failures are collected in errs, to be
+// reported after the screen is restored.
+type pushes struct {
+	wg sync.WaitGroup
+
+	mu      sync.Mutex
+	stopped bool
+	errs    []error
+}
+
+// configureSync connects to the sync server set in the config, if any.
+func (app *Application) configureSync() error {
+	app.sync = nil
+	cfg := app.config.Sync
+	if !cfg.Enabled() {
+		return nil
+	}
+
+	deviceID, err := state.DeviceID()
+	if err != nil {
+		return err
+	}
+
+	app.sync = &state.SyncClient{
+		Server:     cfg.Server,
+		Username:   cfg.Username,
+		Key:        cfg.AuthKey(),
+		Device:     cfg.DeviceName(),
+		DeviceID:   deviceID,
+		HTTPClient: &http.Client{Timeout: syncTimeout},
+	}
+
+	return nil
+}
+
+// pullProgress syncs progress in the open book with the sync server in the
+// background. If the server holds more recent progress, made on another
+// device, the book is moved to it.
+func (app *Application) pullProgress() {
+	if app.sync == nil || app.document == "" {
+		return
+	}
+
+	client, document, book := app.sync, app.document, app.book
+	progress := app.progress
+	progress.Chapters = len(book.Spine.Itemrefs)
+	progress.Position = app.getPosition()
+	app.synced = progress.Modified
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), syncTimeout)
+		defer cancel()
+
+		p, remote, result, err := client.SyncProgress(ctx, document, progress)
+		app.QueueUpdateDraw(func() {
+			if app.book != book {
+				return
+			}
+
+			if err != nil {
+				app.setStatus(fmt.Sprintf("Could not sync progress: %s", err))
+				return
+			}
+
+			app.synced = p.Modified
+			if result == state.SyncPulled {
+				app.gotoChapter(p.Chapter)
+				app.setPosition(p.Position)
+				app.progress.Modified = p.Modified
+				app.setStatus(fmt.Sprintf("Synced position from %s", remote.Device))
+			}
+		})
+	}()
+}
+
+// pushProgress sends progress in the open book to the sync server in the
+// background, if it has changed since it was last synced. It must be called
+// after progress is saved.
+func (app *Application) pushProgress() {
+	if app.sync == nil || app.document == "" || !app.progress.Modified.After(app.synced) {
+		return
+	}
+
+	client, remote := app.sync, state.NewRemoteProgress(app.document, app.progress)
+	p := app.pushes
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), syncTimeout)
+		defer cancel()
+
+		err := client.Push(ctx, remote)
+		if err == nil {
+			return
+		}
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.stopped {
+			p.errs = append(p.errs, err)
+			return
+		}
+
+		// The status is shown without waiting for it, since the application
+		// may stop before it can be.
+		go app.QueueUpdateDraw(func() {
+			app.setStatus(fmt.Sprintf("Could not sync progress: %s", err))
+		})
+	}()
+}
+
+// stopPushes marks the application as stopped, so that progress pushed from
+// now on reports failures through waitPushes rather than the footer.
+func (app *Application) stopPushes() {
+	app.pushes.mu.Lock()
+	defer app.pushes.mu.Unlock()
+
+	app.pushes.stopped = true
+}
+
+// waitPushes waits for progress still being sent to the sync server, for up to
+// exitSyncTimeout, and then reports any failures. It must be called after the
+// application has stopped.
+func (app *Application) waitPushes() {
+	done := make(chan struct{})
+	go func() {
+		app.pushes.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(exitSyncTimeout):
+		app.warn("Gave up syncing progress; the sync server did not respond in time.")
+		return
+	}
+
+	app.pushes.mu.Lock()
+	defer app.pushes.mu.Unlock()
+	for _, err := range app.pushes.errs {
+		app.error("sync progress", err)
+	}
+}
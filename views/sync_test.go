@@ -0,0 +1,147 @@
+package views
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taylorskalyo/goreader/config"
+	"github.com/taylorskalyo/goreader/state"
+	"golang.org/x/sync/errgroup"
+)
+
+func TestSync(t *testing.T) {
+	const book = "../epub/_test_files/alice.epub"
+	document, err := state.DocumentHash(book)
+	require.NoError(t, err)
+
+	// A stand-in sync server, already holding progress from another device.
+	var mu sync.Mutex
+	stored := state.RemoteProgress{
+		Document:   document,
+		Progress:   "/body/DocFragment[3]/body/p[2]",
+		Percentage: 2.0 / 14,
+		Device:     "kobo",
+		DeviceID:   "B",
+		Timestamp:  time.Now().Unix(),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/syncs/progress/"+document:
+			json.NewEncoder(w).Encode(stored)
+		case r.Method == http.MethodPut && r.URL.Path == "/syncs/progress":
+			json.NewDecoder(r.Body).Decode(&stored)
+			stored.Timestamp = time.Now().Unix()
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	eg := new(errgroup.Group)
+
+	ts := newTestScreen(t)
+	app := newTestApp(t)
+	app.SetScreen(ts)
+	app.config.Sync = config.Sync{Server: server.URL, Username: "alice", Password: "secret"}
+	require.NoError(t, app.configureSync())
+
+	eg.Go(app.Run)
+
+	app.QueueUpdateDraw(func() {
+		ts.SetSize(80, 20)
+		assert.NoError(t, app.OpenFile(book))
+	})
+	assertScreen(t, app, ts, "(?s)CHAPTER I.*Synced position from kobo")
+
+	ts.InjectKey(tcell.KeyRune, 'f', tcell.ModNone)
+	assertScreen(t, app, ts, "2 OF 17")
+
+	app.Stop()
+	assert.NoError(t, eg.Wait())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, "goreader", stored.Device)
+	assert.NotEqual(t, "B", stored.DeviceID)
+	assert.True(t, strings.HasPrefix(stored.Progress, "/body/DocFragment[3]/"), stored.Progress)
+}
+
+func TestSyncUnresponsive(t *testing.T) {
+	// A sync server that never responds.
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	eg := new(errgroup.Group)
+
+	ts := newTestScreen(t)
+	app := newTestApp(t)
+	app.SetScreen(ts)
+	app.config.Sync = config.Sync{Server: server.URL, Username: "alice", Password: "secret"}
+	require.NoError(t, app.configureSync())
+
+	eg.Go(app.Run)
+
+	app.QueueUpdateDraw(func() {
+		ts.SetSize(80, 20)
+		assert.NoError(t, app.OpenFile("../epub/_test_files/alice.epub"))
+	})
+	assertScreen(t, app, ts, "1 OF 4")
+
+	ts.InjectKey(tcell.KeyRune, 'f', tcell.ModNone)
+	assertScreen(t, app, ts, "2 OF 4")
+
+	// Exiting gives up on the push rather than waiting out syncTimeout.
+	start := time.Now()
+	app.Stop()
+	assert.NoError(t, eg.Wait())
+	assert.Less(t, time.Since(start), syncTimeout)
+}
+
+func TestSyncPushError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	eg := new(errgroup.Group)
+
+	ts := newTestScreen(t)
+	app := newTestApp(t)
+	app.SetScreen(ts)
+	app.config.Sync = config.Sync{Server: server.URL, Username: "alice", Password: "secret"}
+	require.NoError(t, app.configureSync())
+
+	eg.Go(app.Run)
+
+	app.QueueUpdateDraw(func() {
+		ts.SetSize(80, 20)
+		assert.NoError(t, app.OpenFile("../epub/_test_files/alice.epub"))
+	})
+	assertScreen(t, app, ts, "1 OF 4")
+
+	ts.InjectKey(tcell.KeyRune, 'f', tcell.ModNone)
+	assertScreen(t, app, ts, "2 OF 4")
+
+	// The push on exit fails, and its error is recorded before Run returns.
+	app.Stop()
+	assert.NoError(t, eg.Wait())
+
+	app.pushes.mu.Lock()
+	defer app.pushes.mu.Unlock()
+	assert.Len(t, app.pushes.errs, 1)
+}
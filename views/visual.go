@@ -0,0 +1,330 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/taylorskalyo/goreader/config"
+	"github.com/taylorskalyo/goreader/render"
+	"github.com/taylorskalyo/goreader/state"
+)
+
+// visualMode holds the text selected in visual mode, from which highlights are
+// made. Selected text is located by offsets within the open chapter; see
+// render.Renderer.Locate.
+type visualMode struct {
+	active bool
+
+	// anchor is where the selection started and cursor is where it ends. The
+	// cursor moves as the selection is extended.
+	anchor, cursor int
+
+	// color is the color to highlight the selection in, or empty for the
+	// theme's highlight style.
+	color string
+
+	// dragging is set while the selection is being made with the mouse.
+	dragging bool
+}
+
+// selection returns the offsets of the first selected character and of the
+// character following the last one.
+func (v visualMode) selection() (start, end int) {
+	if v.anchor < v.cursor {
+		return v.anchor, v.cursor + 1
+	}
+
+	return v.cursor, v.anchor + 1
+}
+
+// Visual starts selecting text to highlight, from the first word in view.
+func (app *Application) Visual() {
+	top, height := app.viewport()
+	for line := top; line < top+height && line < app.linecount; line++ {
+		if offset, ok := app.renderer.OffsetAt(line, 0); ok {
+			app.startVisual(offset)
+			return
+		}
+	}
+
+	app.setStatus("No text to select")
+}
+
+// startVisual starts selecting text at an offset.
+func (app *Application) startVisual(offset int) {
+	app.visual = visualMode{
+		active: true,
+		anchor: offset,
+		cursor: offset,
+		color:  app.visual.color,
+	}
+	app.showVisual()
+}
+
+// stopVisual stops selecting text.
+func (app *Application) stopVisual() {
+	app.visual.active = false
+	app.visual.dragging = false
+	app.status = ""
+}
+
+// showVisual describes the selection in the status bar.
+func (app *Application) showVisual() {
+	color := app.visual.color
+	if color == "" {
+		color = "default"
+	}
+
+	app.setStatus(fmt.Sprintf("-- VISUAL -- %s • c color • Enter highlight • Esc cancel", color))
+}
+
+// visualKey handles a key press in visual mode. Motions move the cursor,
+// extending the selection.
+func (app *Application) visualKey(event *tcell.EventKey) {
+	v := &app.visual
+	switch {
+	case event.Key() == tcell.KeyEscape:
+		app.stopVisual()
+		return
+	case event.Key() == tcell.KeyEnter:
+		app.highlightSelection()
+		return
+	case event.Key() == tcell.KeyLeft || event.Rune() == 'h':
+		app.moveCursor(v.cursor-1, -1)
+	case event.Key() == tcell.KeyRight || event.Rune() == 'l':
+		app.moveCursor(v.cursor+1, 1)
+	case event.Rune() == 'w':
+		app.moveCursor(app.renderer.Word(v.cursor, 1), 1)
+	case event.Rune() == 'b':
+		app.moveCursor(app.renderer.Word(v.cursor, -1), -1)
+	case event.Rune() == 'e':
+		app.moveCursor(app.wordEnd(v.cursor), 1)
+	case event.Key() == tcell.KeyDown || event.Rune() == 'j':
+		app.moveCursorLines(1)
+	case event.Key() == tcell.KeyUp || event.Rune() == 'k':
+		app.moveCursorLines(-1)
+	case event.Rune() == 'o':
+		v.anchor, v.cursor = v.cursor, v.anchor
+		app.scrollToOffset(v.cursor)
+	case event.Rune() == 'c':
+		app.cycleColor()
+	}
+
+	app.showVisual()
+}
+
+// moveCursor moves the visual mode cursor to an offset. Text that cannot be
+// located, such as text within tables, is skipped in the given direction.
+func (app *Application) moveCursor(offset, direction int) {
+	length := app.renderer.Length()
+	for ; offset >= 0 && offset < length; offset += direction {
+		if _, _, ok := app.renderer.Locate(offset); ok {
+			app.visual.cursor = offset
+			app.scrollToOffset(offset)
+			return
+		}
+	}
+}
+
+// wordEnd returns the offset of the end of the word following offset, or of
+// the word containing it if offset is not already at the end.
+func (app *Application) wordEnd(offset int) int {
+	next := app.renderer.Word(offset+1, 1)
+	if next <= offset+1 {
+		// The last word ends the chapter.
+		return app.renderer.Length() - 1
+	}
+
+	return next - 1
+}
+
+// moveCursorLines moves the visual mode cursor up or down by a number of lines
+// that contain text, keeping its column where possible.
+func (app *Application) moveCursorLines(n int) {
+	line, col, ok := app.renderer.Locate(app.visual.cursor)
+	if !ok {
+		return
+	}
+
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+
+	for line += step; line >= 0 && line < app.linecount; line += step {
+		if offset, ok := app.renderer.OffsetAt(line, col); ok {
+			app.visual.cursor = offset
+			if n--; n == 0 {
+				break
+			}
+		}
+	}
+
+	app.scrollToOffset(app.visual.cursor)
+}
+
+// cycleColor switches the color that the selection is highlighted in.
+func (app *Application) cycleColor() {
+	colors := append([]string{""}, config.HighlightColors...)
+	for i, color := range colors {
+		if color == app.visual.color {
+			app.visual.color = colors[(i+1)%len(colors)]
+			return
+		}
+	}
+
+	app.visual.color = ""
+}
+
+// scrollToOffset scrolls the viewport, if needed, so that the text at an offset
+// is visible.
+func (app *Application) scrollToOffset(offset int) {
+	line, _, ok := app.renderer.Locate(offset)
+	if !ok {
+		return
+	}
+
+	top, height := app.viewport()
+	if line < top {
+		app.text.ScrollTo(line, 0)
+	} else if line >= top+height {
+		app.text.ScrollTo(line-height+1, 0)
+	}
+}
+
+// highlightSelection prompts for a note and then highlights the selected text.
+// The highlight is discarded if the prompt is cancelled.
+func (app *Application) highlightSelection() {
+	start, end := app.visual.selection()
+	h := state.Highlight{
+		Chapter: app.progress.Chapter,
+		Start:   start,
+		End:     end,
+		Text:    app.renderer.Text(start, end),
+		Color:   app.visual.color,
+	}
+	app.stopVisual()
+
+	app.prompt("Note (optional): ", func(text string) {
+		h.Note = strings.TrimSpace(text)
+		h.Created = time.Now()
+		app.progress.AddHighlight(h)
+		app.rerender()
+		app.setStatus(fmt.Sprintf("Highlighted \"%s\"", truncate(h.Text, 40)))
+	})
+}
+
+// highlights returns the highlights in the open book, styled by the theme.
+func (app *Application) highlights() []render.Highlight {
+	theme := app.theme()
+	highlights := make([]render.Highlight, len(app.progress.Highlights))
+	for i, h := range app.progress.Highlights {
+		highlights[i] = render.Highlight{
+			Chapter: h.Chapter,
+			Start:   h.Start,
+			End:     h.End,
+			Style:   theme.HighlightStyle(h.Color),
+		}
+	}
+
+	return highlights
+}
+
+// drawSelection shows the text selected in visual mode in reverse video. It is
+// drawn over the rendered text, so that the selection can change without the
+// chapter being rendered again.
+func (app *Application) drawSelection(screen tcell.Screen) {
+	if !app.visual.active || !app.text.HasFocus() {
+		return
+	}
+
+	x, y, width, _ := app.text.GetInnerRect()
+	top, height := app.viewport()
+	start, end := app.visual.selection()
+
+	first, _, ok1 := app.renderer.Locate(start)
+	last, _, ok2 := app.renderer.Locate(end - 1)
+	if !ok1 || !ok2 {
+		return
+	}
+
+	for line := first; line <= last; line++ {
+		if line < top || line >= top+height {
+			continue
+		}
+
+		from, ok := app.lineColumn(line, start, 0)
+		if !ok {
+			continue
+		}
+		to, _ := app.lineColumn(line, end-1, width)
+
+		for col := from; col <= to && col < width; col++ {
+			mainc, combc, style, _ := screen.GetContent(x+col, y+line-top)
+			screen.SetContent(x+col, y+line-top, mainc, combc, style.Reverse(true))
+		}
+	}
+}
+
+// lineColumn returns the column of a line at which the character at offset
+// was rendered or, if it was rendered on another line, the column of the
+// character nearest col.
+func (app *Application) lineColumn(line, offset, col int) (int, bool) {
+	if l, c, ok := app.renderer.Locate(offset); ok && l == line {
+		return c, true
+	}
+
+	nearest, ok := app.renderer.OffsetAt(line, col)
+	if !ok {
+		return 0, false
+	}
+
+	_, c, _ := app.renderer.Locate(nearest)
+
+	return c, true
+}
+
+// mouseHandler selects text by dragging the mouse over it.
+func (app *Application) mouseHandler(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
+	if app.book == nil {
+		return action, event
+	}
+
+	offset, ok := app.offsetAt(event.Position())
+	switch action {
+	case tview.MouseLeftDown:
+		if ok {
+			app.startVisual(offset)
+			app.visual.dragging = true
+		}
+	case tview.MouseMove:
+		if app.visual.dragging && ok {
+			app.visual.cursor = offset
+		}
+	case tview.MouseLeftUp:
+		if app.visual.dragging {
+			app.visual.dragging = false
+			if app.visual.anchor == app.visual.cursor {
+				// A click, rather than a drag, selects nothing.
+				app.stopVisual()
+			}
+		}
+	}
+
+	return action, event
+}
+
+// offsetAt returns the offset of the text shown nearest to a screen position.
+func (app *Application) offsetAt(x, y int) (int, bool) {
+	left, top, width, height := app.text.GetInnerRect()
+	if x < left || x >= left+width || y < top || y >= top+height {
+		return 0, false
+	}
+
+	line, _ := app.viewport()
+
+	return app.renderer.OffsetAt(line+y-top, x-left)
+}
@@ -0,0 +1,96 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taylorskalyo/goreader/epub"
+	"golang.org/x/sync/errgroup"
+)
+
+func TestHighlights(t *testing.T) {
+	eg := new(errgroup.Group)
+
+	ts := newTestScreen(t)
+	app := newTestApp(t)
+	app.SetScreen(ts)
+
+	rc, _ := epub.OpenReader("../epub/_test_files/alice.epub")
+	defer rc.Close()
+
+	eg.Go(app.Run)
+
+	app.QueueUpdateDraw(func() {
+		ts.SetSize(80, 20)
+		app.OpenBook(rc.DefaultRendition())
+		app.gotoChapter(2)
+	})
+	assertScreen(t, app, ts, "(?s)1 OF 17.*CHAPTER I")
+
+	typeKeys := func(keys string) {
+		for _, r := range keys {
+			if r == '\r' {
+				ts.InjectKey(tcell.KeyEnter, 0, tcell.ModNone)
+			} else {
+				ts.InjectKey(tcell.KeyRune, r, tcell.ModNone)
+			}
+		}
+	}
+
+	// Select the first three words in view with the keyboard.
+	typeKeys("v")
+	assertScreen(t, app, ts, "-- VISUAL -- default")
+	typeKeys("wwec")
+	assertScreen(t, app, ts, "-- VISUAL -- yellow")
+	typeKeys("\r")
+	assertScreen(t, app, ts, "Note")
+	typeKeys("first words\r")
+
+	assertScreen(t, app, ts, `Highlighted "The Project Gutenberg"`)
+
+	// Select a passage by dragging the mouse over it.
+	var x, y int
+	for page := 2; page < 6 && y == 0; page++ {
+		typeKeys("f")
+		assertScreen(t, app, ts, fmt.Sprintf("%d OF 17", page))
+
+		app.QueueUpdate(func() {
+			for i, line := range strings.Split(ts.String(), "\n") {
+				if j := strings.Index(line, "was beginning"); j >= 0 {
+					x, y = len([]rune(line[:j])), i
+					break
+				}
+			}
+		})
+	}
+	require.NotZero(t, y, "passage not found on screen:\n%s", ts.String())
+
+	ts.InjectMouse(x, y, tcell.Button1, tcell.ModNone)
+	ts.InjectMouse(x+len("was beginnin"), y, tcell.Button1, tcell.ModNone)
+	ts.InjectMouse(x+len("was beginnin"), y, tcell.ButtonNone, tcell.ModNone)
+	typeKeys("\r\r")
+	assertScreen(t, app, ts, `Highlighted "was beginning"`)
+
+	typeKeys("a")
+	assertScreen(t, app, ts, `(?s)Annotations • .* • 2 highlights.*The Project Gutenberg .*first words.*was beginning`)
+
+	// Selecting a highlight returns to it.
+	ts.InjectKey(tcell.KeyDown, 0, tcell.ModNone)
+	typeKeys("\r")
+	assertScreen(t, app, ts, `(?m)^\s*LICE was beginning`)
+
+	ts.InjectKey(tcell.KeyRune, 'q', tcell.ModNone)
+	assert.NoError(t, eg.Wait())
+
+	progress, err := app.store.LoadProgress(app.bookID())
+	if assert.NoError(t, err) && assert.Len(t, progress.Highlights, 2) {
+		h := progress.Highlights[0]
+		assert.Equal(t, "first words", h.Note)
+		assert.Equal(t, "yellow", h.Color)
+		assert.Equal(t, "was beginning", progress.Highlights[1].Text)
+	}
+}